@@ -1,9 +1,31 @@
 package app
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	authorRouter "github.com/foxfurry/simple-rest/internal/author/http/router"
+	bookDB "github.com/foxfurry/simple-rest/internal/book/db"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/router"
+	bookSearch "github.com/foxfurry/simple-rest/internal/book/search"
+	"github.com/foxfurry/simple-rest/internal/book/trash"
+	collectionRouter "github.com/foxfurry/simple-rest/internal/collection/http/router"
 	dbpool "github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	movieDB "github.com/foxfurry/simple-rest/internal/movie/db"
+	movieRouter "github.com/foxfurry/simple-rest/internal/movie/http/router"
+	movieSearch "github.com/foxfurry/simple-rest/internal/movie/search"
+	musicDB "github.com/foxfurry/simple-rest/internal/music/db"
+	musicRouter "github.com/foxfurry/simple-rest/internal/music/http/router"
+	musicSearch "github.com/foxfurry/simple-rest/internal/music/search"
+	searchRouter "github.com/foxfurry/simple-rest/internal/search/http/router"
+	userDB "github.com/foxfurry/simple-rest/internal/user/db"
+	userRepository "github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	userRouter "github.com/foxfurry/simple-rest/internal/user/http/router"
+	"github.com/foxfurry/simple-rest/internal/user/seed"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
@@ -15,53 +37,161 @@ import (
 // It embeds http server and provides router and database instances
 type app struct {
 	*http.Server
-	Router   *gin.Engine
-	Database *sql.DB
+	Router     *gin.Engine
+	Database   *sql.DB
+	BookPurger *trash.BookPurger
 }
 
-// Start allows app to serve a http server on port from environment
+// Start allows app to serve a http server on port from environment. Trash of books is purged in background meanwhile
 func (a *app) Start() {
+	go a.BookPurger.Run(context.Background())
 	log.Fatal(http.ListenAndServe(viper.GetString("server.port"), a.Router))
 }
 
-// NewApp returns an instance of app with configured router and database.
-// Configuration is loaded from viper environment
-func NewApp() *app {
-	newApp := &app{
-		Router: gin.New(),
-		Database: dbpool.CreateDBPool(
-			viper.GetString("Database.host"),
-			viper.GetInt("Database.port"),
-			viper.GetString("Database.user"),
-			viper.GetString("Database.password"),
-			viper.GetString("Database.dbname"),
-			viper.GetInt("Database.maxidleconnections"),
-			viper.GetInt("Database.maxopenconnections"),
-			viper.GetDuration("Database.maxconnidletime"),
-		),
+// Values of database.driver configuration key
+const (
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite" // Books and users only, stored in a local file
+	driverMemory   = "memory" // Books and users only, kept in memory. Meant for development without a database
+)
+
+// registerRoutes registers routes of users, every media module and the cross-media search over them. Movies, music,
+// authors and collections are stored in postgres only, with other drivers only books and users are served
+func (a *app) registerRoutes() {
+	var bookRepo repository.BookRepository
+	var bookUnitOfWork repository.BookUnitOfWork
+	if a.Database != nil {
+		dbRepo := bookDB.NewBookRepo(a.Database)
+		dbUnitOfWork := bookDB.NewBookUnitOfWork(a.Database)
+		bookRepo, bookUnitOfWork = &dbRepo, &dbUnitOfWork
+	} else {
+		memoryRepo := bookDB.NewBookMemoryRepo()
+		bookRepo, bookUnitOfWork = memoryRepo, memoryRepo
 	}
 
-	router.RegisterBookRoutes(newApp.Router, newApp.Database)
+	var userRepo userRepository.UserRepository
+	if a.Database != nil {
+		dbRepo := userDB.NewUserRepo(a.Database)
+		userRepo = &dbRepo
+	} else {
+		userRepo = userDB.NewUserMemoryRepo()
+	}
+	appointAdmin(userRepo)
 
-	return newApp
+	// Requests denied by the guard are recorded along with the users
+	signer := newSigner()
+	guard := common_auth.NewGuard(signer, userRepo)
+
+	userRouter.RegisterUserRoutes(a.Router, userRepo, signer, guard)
+	router.RegisterBookRoutes(a.Router, bookRepo, bookUnitOfWork, guard)
+
+	a.BookPurger = trash.NewBookPurger(
+		bookRepo,
+		viper.GetDuration("trash.retention"),
+		viper.GetDuration("trash.purgeinterval"),
+		viper.GetDuration("database.timeouts.purge"),
+	)
+
+	providers := []search.Provider{bookSearch.NewBookSearchProvider(bookRepo)}
+
+	if a.Database != nil && dbpool.DialectOf(a.Database) == dbpool.Postgres {
+		movieRepo := movieDB.NewMovieRepo(a.Database)
+		musicRepo := musicDB.NewMusicRepo(a.Database)
+
+		movieRouter.RegisterMovieRoutes(a.Router, a.Database, guard)
+		musicRouter.RegisterMusicRoutes(a.Router, a.Database, guard)
+		authorRouter.RegisterAuthorRoutes(a.Router, a.Database, guard)
+		collectionRouter.RegisterCollectionRoutes(a.Router, a.Database, guard)
+
+		providers = append(providers,
+			movieSearch.NewMovieSearchProvider(&movieRepo),
+			musicSearch.NewMusicSearchProvider(&musicRepo),
+		)
+	} else {
+		log.Printf("Database is not postgres, movies, music, authors and collections are not served")
+	}
+
+	searchRouter.RegisterSearchRoutes(a.Router, providers...)
 }
 
-func NewTestApp() *app {
+// appointAdmin makes the user named by auth.admin key of viper environment an admin, registering the user with
+// auth.adminpassword if needed. Failing to do so is only logged, the other users are served either way
+func appointAdmin(repo userRepository.UserRepository) {
+	username := viper.GetString("auth.admin")
+	if username == "" {
+		log.Printf("auth.admin is not set, no user is appointed admin")
+		return
+	}
+
+	admin, err := seed.Admin(context.Background(), repo, username, viper.GetString("auth.adminpassword"))
+	if err != nil {
+		log.Printf("Could not appoint %v admin: %v", username, err)
+		return
+	}
+
+	log.Printf("User %v is an admin", admin.Username)
+}
+
+// newSigner returns signer of tokens configured by the auth section of viper environment. Without a configured secret
+// a random one is made, so tokens do not outlive the process
+func newSigner() *common_auth.Signer {
+	secret := []byte(viper.GetString("auth.secret"))
+	if len(secret) == 0 {
+		log.Printf("auth.secret is not set, signing tokens by a random key")
+
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Panicf("Could not make a key: %v", err)
+		}
+	}
+
+	return common_auth.NewSigner(secret, viper.GetDuration("auth.accessttl"), viper.GetDuration("auth.refreshttl"))
+}
+
+// newApp returns an instance of app configured by the section of viper environment (database or database_test).
+// The driver key of the section selects the storage, postgres being the default
+func newApp(section string) *app {
 	newApp := &app{
 		Router: gin.New(),
-		Database: dbpool.CreateDBPool(
-			viper.GetString("database_test.host"),
-			viper.GetInt("database_test.port"),
-			viper.GetString("database_test.user"),
-			viper.GetString("database_test.password"),
-			viper.GetString("database_test.dbname"),
-			viper.GetInt("database_test.maxidleconnections"),
-			viper.GetInt("database_test.maxopenconnections"),
-			viper.GetDuration("database_test.maxconnidletime"),
-		),
 	}
+	newApp.Router.Use(common_request.Middleware())
 
-	router.RegisterBookRoutes(newApp.Router, newApp.Database)
+	switch driver := viper.GetString(section + ".driver"); driver {
+	case driverMemory:
+		log.Printf("Database driver is %v, serving books from memory", driver)
+	case driverSQLite:
+		newApp.Database = dbpool.CreateSQLitePool(
+			viper.GetString(section+".path"),
+			viper.GetInt(section+".maxidleconnections"),
+			viper.GetInt(section+".maxopenconnections"),
+			viper.GetDuration(section+".maxconnidletime"),
+		)
+	case driverPostgres, "":
+		newApp.Database = dbpool.CreateDBPool(
+			viper.GetString(section+".host"),
+			viper.GetInt(section+".port"),
+			viper.GetString(section+".user"),
+			viper.GetString(section+".password"),
+			viper.GetString(section+".dbname"),
+			viper.GetInt(section+".maxidleconnections"),
+			viper.GetInt(section+".maxopenconnections"),
+			viper.GetDuration(section+".maxconnidletime"),
+		)
+	default:
+		log.Panicf("Unknown database driver: %v", driver)
+	}
+
+	newApp.registerRoutes()
 
 	return newApp
 }
+
+// NewApp returns an instance of app with configured router and database.
+// Configuration is loaded from viper environment
+func NewApp() *app {
+	return newApp("database")
+}
+
+func NewTestApp() *app {
+	return newApp("database_test")
+}
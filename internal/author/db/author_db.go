@@ -0,0 +1,402 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/author/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/author/http/errors"
+	"github.com/foxfurry/simple-rest/internal/author/http/validators"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"log"
+)
+
+type AuthorDBRepository struct {
+	database *sql.DB
+}
+
+func NewAuthorRepo(db *sql.DB) AuthorDBRepository {
+	return AuthorDBRepository{database: db}
+}
+
+var _ repository.AuthorRepository = &AuthorDBRepository{}
+
+const (
+	// QuerySaveAuthor inserts nothing if an author with the same name key exists, so it is reported as sql.ErrNoRows
+	QuerySaveAuthor     = `INSERT INTO authors (name, name_key) VALUES ($1, $2) ON CONFLICT (name_key) DO NOTHING RETURNING id`
+	QueryGetAuthor      = `SELECT id, name FROM authors WHERE id=$1`
+	QueryCountAuthors   = `SELECT COUNT(*) FROM authors WHERE name_key LIKE $1 ESCAPE '\'`
+	QueryGetAuthors     = `SELECT id, name FROM authors WHERE name_key LIKE $1 ESCAPE '\' ORDER BY name_key, id LIMIT $2 OFFSET $3`
+	QueryGetAuthorByKey = `SELECT id FROM authors WHERE name_key=$1`
+	QueryUpdateAuthor   = `UPDATE authors SET name=$2, name_key=$3 WHERE id=$1`
+	QueryDeleteAuthor   = `DELETE FROM authors WHERE id=$1`
+	// QueryGetAuthorBooks returns a single row with NULL book for an existing author without books, to tell it apart
+	// from a missing author
+	QueryGetAuthorBooks = `SELECT b.id, b.title, b.author, b.year, b.description, b.isbn FROM authors a
+		LEFT JOIN book_authors ba ON ba.author_id = a.id
+		LEFT JOIN bookstore b ON b.id = ba.book_id AND b.deleted_at IS NULL
+		WHERE a.id=$1 ORDER BY b.year, b.id`
+	// QueryGetBookAuthors works similar to QueryGetAuthorBooks, returning a single row with NULL author for a book
+	// without authors
+	QueryGetBookAuthors = `SELECT a.id, a.name FROM bookstore b
+		LEFT JOIN book_authors ba ON ba.book_id = b.id
+		LEFT JOIN authors a ON a.id = ba.author_id
+		WHERE b.id=$1 AND b.deleted_at IS NULL ORDER BY ba.position`
+	QueryLockBook = `SELECT id FROM bookstore WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	// QueryUpsertAuthor returns the author with the name key, creating it if needed. Name of an existing author is
+	// kept as it is
+	QueryUpsertAuthor = `INSERT INTO authors (name, name_key) VALUES ($1, $2)
+		ON CONFLICT (name_key) DO UPDATE SET name_key=EXCLUDED.name_key RETURNING id, name`
+	QueryUnlinkBookAuthors = `DELETE FROM book_authors WHERE book_id=$1`
+	QueryLinkBookAuthor    = `INSERT INTO book_authors (book_id, author_id, position) VALUES ($1, $2, $3)`
+)
+
+// scanAuthors reads all the rows into a slice of authors. Rows with NULL author, produced by outer joins, and rows
+// which could not be scanned are skipped
+func scanAuthors(rows *sql.Rows) []entity.Author {
+	authors := []entity.Author{}
+
+	for rows.Next() {
+		var id sql.NullInt64
+		var name sql.NullString
+
+		if err := rows.Scan(&id, &name); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+		if !id.Valid {
+			continue
+		}
+
+		authors = append(authors, entity.Author{ID: uint64(id.Int64), Name: name.String})
+	}
+
+	return authors
+}
+
+func (r *AuthorDBRepository) SaveAuthor(ctx context.Context, author *entity.Author) (*entity.Author, error) {
+	var authorID uint64
+
+	err := r.database.QueryRowContext(ctx, QuerySaveAuthor, author.Name, entity.AuthorNameKey(author.Name)).Scan(&authorID)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Author named like %v already exists", author.Name)
+		return nil, errors.NewAuthorAlreadyExists(author.Name)
+	} else if err != nil {
+		log.Printf("Unable to save author to db: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	returnAuthor := *author
+	returnAuthor.ID = authorID
+	return &returnAuthor, nil
+}
+
+func (r *AuthorDBRepository) GetAuthor(ctx context.Context, authorID uint64) (*entity.Author, error) {
+	if authorID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewAuthorInvalidSerial()
+	}
+	var author entity.Author
+
+	err := r.database.QueryRowContext(ctx, QueryGetAuthor, authorID).Scan(&author.ID, &author.Name)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Author id#%v not found", authorID)
+		return nil, errors.NewAuthorsNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	return &author, nil
+}
+
+// GetAuthors returns authors of the limit/offset window, ordered by name, and the total count of authors named like
+// name. Empty name matches every author. Finding no authors is not an error
+func (r *AuthorDBRepository) GetAuthors(ctx context.Context, name string, limit int, offset int) ([]entity.Author, int64, error) {
+	var total int64
+	pattern := database.ContainsPattern(entity.AuthorNameKey(name))
+
+	err := r.database.QueryRowContext(ctx, QueryCountAuthors, pattern).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count authors: %v", err)
+		return nil, 0, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if total == 0 {
+		return []entity.Author{}, 0, nil
+	}
+
+	rows, err := r.database.QueryContext(ctx, QueryGetAuthors, pattern, limit, offset)
+	if err != nil {
+		log.Printf("Unable to get authors: %v", err)
+		return nil, 0, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	return scanAuthors(rows), total, nil
+}
+
+func (r *AuthorDBRepository) UpdateAuthor(ctx context.Context, authorID uint64, author *entity.Author) (*entity.Author, error) {
+	if authorID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewAuthorInvalidSerial()
+	}
+
+	nameKey := entity.AuthorNameKey(author.Name)
+
+	var existingID uint64
+	err := r.database.QueryRowContext(ctx, QueryGetAuthorByKey, nameKey).Scan(&existingID)
+	if err == nil && existingID != authorID {
+		log.Printf("Author named like %v already exists", author.Name)
+		return nil, errors.NewAuthorAlreadyExists(author.Name)
+	} else if err != nil && err != sql.ErrNoRows {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	res, err := r.database.ExecContext(ctx, QueryUpdateAuthor, authorID, author.Name, nameKey)
+	if err != nil {
+		log.Printf("Unable to update author: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows author: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return nil, errors.NewAuthorsNotFound()
+	}
+
+	returnAuthor := *author
+	returnAuthor.ID = authorID
+	return &returnAuthor, nil
+}
+
+// DeleteAuthor removes the author along with its links to books. The books themselves are kept
+func (r *AuthorDBRepository) DeleteAuthor(ctx context.Context, authorID uint64) (int64, error) {
+	if authorID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewAuthorInvalidSerial()
+	}
+
+	res, err := r.database.ExecContext(ctx, QueryDeleteAuthor, authorID)
+	if err != nil {
+		log.Printf("Unable to delete author: %v", err)
+		return 0, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows author: %v", err)
+		return 0, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewAuthorsNotFound()
+	}
+
+	log.Printf("Deleted rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
+
+// GetAuthorBooks returns books of the author which are not in the trash. Author without books is not an error
+func (r *AuthorDBRepository) GetAuthorBooks(ctx context.Context, authorID uint64) ([]bookEntity.Book, error) {
+	if authorID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewAuthorInvalidSerial()
+	}
+
+	rows, err := r.database.QueryContext(ctx, QueryGetAuthorBooks, authorID)
+	if err != nil {
+		log.Printf("Could not get books of author id#%v: %v", authorID, err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	found := false
+	books := []bookEntity.Book{}
+
+	for rows.Next() {
+		found = true
+
+		var id, year sql.NullInt64
+		var title, author, description, isbn sql.NullString
+
+		if err = rows.Scan(&id, &title, &author, &year, &description, &isbn); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+		if !id.Valid {
+			continue
+		}
+
+		books = append(books, bookEntity.Book{
+			ID:          uint64(id.Int64),
+			Title:       title.String,
+			Author:      author.String,
+			Year:        int(year.Int64),
+			Description: description.String,
+			ISBN:        isbn.String,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if !found {
+		log.Printf("Author id#%v not found", authorID)
+		return nil, errors.NewAuthorsNotFound()
+	}
+
+	return books, nil
+}
+
+// GetBookAuthors returns co-authors of the book in the order of the authorship. Book without authors is not an error
+func (r *AuthorDBRepository) GetBookAuthors(ctx context.Context, bookID uint64) ([]entity.Author, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewAuthorInvalidSerial()
+	}
+
+	rows, err := r.database.QueryContext(ctx, QueryGetBookAuthors, bookID)
+	if err != nil {
+		log.Printf("Could not get authors of book id#%v: %v", bookID, err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	return r.bookAuthors(rows, bookID)
+}
+
+// bookAuthors reads authors of QueryGetBookAuthors, reporting a missing book if there are no rows at all
+func (r *AuthorDBRepository) bookAuthors(rows *sql.Rows, bookID uint64) ([]entity.Author, error) {
+	found := false
+	authors := []entity.Author{}
+
+	for rows.Next() {
+		found = true
+
+		var id sql.NullInt64
+		var name sql.NullString
+
+		if err := rows.Scan(&id, &name); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+		if id.Valid {
+			authors = append(authors, entity.Author{ID: uint64(id.Int64), Name: name.String})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if !found {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewAuthorBookNotFound()
+	}
+
+	return authors, nil
+}
+
+// SetBookAuthors replaces co-authors of the book by authors with given names, in the given order, creating the ones
+// which do not exist yet. Names sharing a name key are one author. Everything is done in a single transaction
+func (r *AuthorDBRepository) SetBookAuthors(ctx context.Context, bookID uint64, names []string) ([]entity.Author, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewAuthorInvalidSerial()
+	}
+
+	var fieldErrors []ct.FieldError
+	for idx, name := range names {
+		if entity.AuthorNameKey(name) == "" {
+			fieldErrors = append(fieldErrors, validators.NewFieldAuthorNameEmpty(idx))
+		}
+	}
+	if len(names) > validators.MaxBookAuthors {
+		fieldErrors = append(fieldErrors, validators.FieldAuthorsTooMany)
+	}
+	if fieldErrors != nil {
+		return nil, errors.NewAuthorValidatorError(fieldErrors)
+	}
+
+	tx, err := r.database.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Could not begin the transaction: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	authors, err := setBookAuthors(ctx, tx, bookID, names)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("Could not roll back the transaction: %v", rollbackErr)
+		}
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Could not commit the transaction: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	return authors, nil
+}
+
+func setBookAuthors(ctx context.Context, tx *sql.Tx, bookID uint64, names []string) ([]entity.Author, error) {
+	var lockedID uint64
+	err := tx.QueryRowContext(ctx, QueryLockBook, bookID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewAuthorBookNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	if _, err = tx.ExecContext(ctx, QueryUnlinkBookAuthors, bookID); err != nil {
+		log.Printf("Unable to unlink authors of book id#%v: %v", bookID, err)
+		return nil, errors.NewAuthorCouldNotQuery(err.Error())
+	}
+
+	authors := []entity.Author{}
+	linked := make(map[uint64]bool, len(names))
+
+	for _, name := range names {
+		var author entity.Author
+
+		err = tx.QueryRowContext(ctx, QueryUpsertAuthor, name, entity.AuthorNameKey(name)).Scan(&author.ID, &author.Name)
+		if err != nil {
+			log.Printf("Unable to save author to db: %v", err)
+			return nil, errors.NewAuthorCouldNotQuery(err.Error())
+		}
+
+		if linked[author.ID] {
+			continue
+		}
+		linked[author.ID] = true
+
+		if _, err = tx.ExecContext(ctx, QueryLinkBookAuthor, bookID, author.ID, len(authors)); err != nil {
+			log.Printf("Unable to link author id#%v to book id#%v: %v", author.ID, bookID, err)
+			return nil, errors.NewAuthorCouldNotQuery(err.Error())
+		}
+
+		authors = append(authors, author)
+	}
+
+	return authors, nil
+}
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/author/http/errors"
+	"github.com/foxfurry/simple-rest/internal/author/http/validators"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"regexp"
+	"testing"
+)
+
+var (
+	authorColumns = []string{"id", "name"}
+	bookColumns   = []string{"id", "title", "author", "year", "description", "isbn"}
+)
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestAuthorDBRepository_SaveAuthor(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewAuthorRepo(db)
+
+	saveAuthorMocks := []struct {
+		testName       string
+		input          entity.Author
+		expectedOutput *entity.Author
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          entity.Author{Name: "J.R.R. Tolkien"},
+			expectedOutput: &entity.Author{ID: 1, Name: "J.R.R. Tolkien"},
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveAuthor)).WithArgs("J.R.R. Tolkien", "j r r tolkien").WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Same name key exists",
+			input:         entity.Author{Name: "J. R. R. Tolkien"},
+			expectedError: errors.NewAuthorAlreadyExists("J. R. R. Tolkien"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveAuthor)).WithArgs("J. R. R. Tolkien", "j r r tolkien").WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+		},
+	}
+
+	for _, tc := range saveAuthorMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.SaveAuthor(context.Background(), &tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthorDBRepository_GetAuthors(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewAuthorRepo(db)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryCountAuthors)).WithArgs("%tolkien%").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthors)).WithArgs("%tolkien%", 2, 0).
+			WillReturnRows(mock.NewRows(authorColumns).AddRow(2, "Christopher Tolkien").AddRow(1, "J.R.R. Tolkien"))
+
+		authors, total, err := repo.GetAuthors(context.Background(), "Tolkien", 2, 0)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, []entity.Author{{ID: 2, Name: "Christopher Tolkien"}, {ID: 1, Name: "J.R.R. Tolkien"}}, authors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Successful: Nothing found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryCountAuthors)).WithArgs("%pratchett%").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(0))
+
+		authors, total, err := repo.GetAuthors(context.Background(), "Pratchett", 2, 0)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Equal(t, []entity.Author{}, authors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAuthorDBRepository_UpdateAuthor(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewAuthorRepo(db)
+
+	updateAuthorMocks := []struct {
+		testName       string
+		id             uint64
+		input          entity.Author
+		expectedOutput *entity.Author
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful: Same author renamed",
+			id:             1,
+			input:          entity.Author{Name: "J. R. R. Tolkien"},
+			expectedOutput: &entity.Author{ID: 1, Name: "J. R. R. Tolkien"},
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorByKey)).WithArgs("j r r tolkien").WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(QueryUpdateAuthor)).WithArgs(1, "J. R. R. Tolkien", "j r r tolkien").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Another author has the name",
+			id:            2,
+			input:         entity.Author{Name: "J.R.R. Tolkien"},
+			expectedError: errors.NewAuthorAlreadyExists("J.R.R. Tolkien"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorByKey)).WithArgs("j r r tolkien").WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			id:            3,
+			input:         entity.Author{Name: "Terry Pratchett"},
+			expectedError: errors.NewAuthorsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorByKey)).WithArgs("terry pratchett").WillReturnRows(mock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexp.QuoteMeta(QueryUpdateAuthor)).WithArgs(3, "Terry Pratchett", "terry pratchett").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			input:         entity.Author{Name: "Terry Pratchett"},
+			expectedError: errors.NewAuthorInvalidSerial(),
+			mockFunc:      func() {},
+		},
+	}
+
+	for _, tc := range updateAuthorMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.UpdateAuthor(context.Background(), tc.id, &tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthorDBRepository_GetAuthorBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewAuthorRepo(db)
+
+	getAuthorBooksMocks := []struct {
+		testName       string
+		expectedOutput []bookEntity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			expectedOutput: []bookEntity.Book{{ID: 1, Title: "The Hobbit", Author: "J.R.R. Tolkien", Year: 1937, ISBN: "9780261102217"}},
+			mockFunc: func() {
+				rows := mock.NewRows(bookColumns).AddRow(1, "The Hobbit", "J.R.R. Tolkien", 1937, "", "9780261102217")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorBooks)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Author without books",
+			expectedOutput: []bookEntity.Book{},
+			mockFunc: func() {
+				rows := mock.NewRows(bookColumns).AddRow(nil, nil, nil, nil, nil, nil)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorBooks)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Author not found",
+			expectedError: errors.NewAuthorsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAuthorBooks)).WithArgs(1).WillReturnRows(mock.NewRows(bookColumns))
+			},
+		},
+	}
+
+	for _, tc := range getAuthorBooksMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.GetAuthorBooks(context.Background(), 1)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthorDBRepository_SetBookAuthors(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewAuthorRepo(db)
+
+	setBookAuthorsMocks := []struct {
+		testName       string
+		names          []string
+		expectedOutput []entity.Author
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful: Spellings of one author are linked once",
+			names:          []string{"Terry Pratchett", "Neil Gaiman", "terry  pratchett"},
+			expectedOutput: []entity.Author{{ID: 1, Name: "Terry Pratchett"}, {ID: 2, Name: "Neil Gaiman"}},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(7).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec(regexp.QuoteMeta(QueryUnlinkBookAuthors)).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpsertAuthor)).WithArgs("Terry Pratchett", "terry pratchett").
+					WillReturnRows(mock.NewRows(authorColumns).AddRow(1, "Terry Pratchett"))
+				mock.ExpectExec(regexp.QuoteMeta(QueryLinkBookAuthor)).WithArgs(7, 1, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpsertAuthor)).WithArgs("Neil Gaiman", "neil gaiman").
+					WillReturnRows(mock.NewRows(authorColumns).AddRow(2, "Neil Gaiman"))
+				mock.ExpectExec(regexp.QuoteMeta(QueryLinkBookAuthor)).WithArgs(7, 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpsertAuthor)).WithArgs("terry  pratchett", "terry pratchett").
+					WillReturnRows(mock.NewRows(authorColumns).AddRow(1, "Terry Pratchett"))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			names:         []string{"Terry Pratchett"},
+			expectedError: errors.NewAuthorBookNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(7).WillReturnRows(mock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Empty name",
+			names:         []string{"Terry Pratchett", " . "},
+			expectedError: errors.NewAuthorValidatorError([]ct.FieldError{validators.NewFieldAuthorNameEmpty(1)}),
+			mockFunc:      func() {},
+		},
+	}
+
+	for _, tc := range setBookAuthorsMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.SetBookAuthors(context.Background(), 7, tc.names)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Author is a person who wrote books. Authors whose names share the name key are the same author
+type Author struct {
+	ID   uint64 `json:"id,omitempty" binding:"omitempty,numeric,validID"`
+	Name string `json:"name" binding:"required"`
+}
+
+var nameKeySeparators = regexp.MustCompile(`[[:space:].]+`)
+
+var coAuthorSeparators = regexp.MustCompile(`[&;]`)
+
+// AuthorNameKey returns the key telling authors apart: the lower case name with dots and runs of spaces collapsed
+// into single spaces, so "J.R.R. Tolkien" and "J. R. R. Tolkien" share it. Migration creating the authors table
+// computes the same key in SQL
+func AuthorNameKey(name string) string {
+	return strings.TrimSpace(nameKeySeparators.ReplaceAllString(strings.ToLower(name), " "))
+}
+
+// AuthorNames splits the author of a book into the names of its co-authors, separated by '&' or ';' the way the
+// migration creating the authors table splits them. Names without a name key are left out
+func AuthorNames(author string) []string {
+	var names []string
+	for _, name := range coAuthorSeparators.Split(author, -1) {
+		if AuthorNameKey(name) != "" {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
+
+// Equal returns true if all fields in receiver are same as in parameter
+func (lhs Author) Equal(rhs Author) bool {
+	return rhs == lhs
+}
+
+// EqualNoID works similar to Equal, except it ignores ID
+func (lhs Author) EqualNoID(rhs Author) bool {
+	rhs.ID = lhs.ID
+	return rhs == lhs
+}
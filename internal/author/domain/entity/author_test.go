@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/author/http/validators"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func init() {
+	validators.RegisterAuthorValidators()
+}
+
+func TestAuthor_EqualNoID(t *testing.T) {
+	base := Author{ID: 1, Name: "1"}
+
+	assert.True(t, base.Equal(Author{ID: 1, Name: "1"}))
+	assert.True(t, !base.Equal(Author{ID: 2, Name: "1"}))
+	assert.True(t, base.EqualNoID(Author{ID: 2, Name: "1"}))
+	assert.True(t, !base.EqualNoID(Author{ID: 1, Name: "2"}))
+}
+
+func TestAuthorNameKey(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedKey string
+	}{
+		{name: "J.R.R. Tolkien", expectedKey: "j r r tolkien"},
+		{name: "J. R. R. Tolkien", expectedKey: "j r r tolkien"},
+		{name: "  j r r  TOLKIEN ", expectedKey: "j r r tolkien"},
+		{name: "Tolkien", expectedKey: "tolkien"},
+		{name: " . ", expectedKey: ""},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedKey, AuthorNameKey(tc.name), "Unexpected key of %q", tc.name)
+	}
+}
+
+func TestAuthorNames(t *testing.T) {
+	testCases := []struct {
+		author        string
+		expectedNames []string
+	}{
+		{author: "J.R.R. Tolkien", expectedNames: []string{"J.R.R. Tolkien"}},
+		{author: "Terry Pratchett & Neil Gaiman", expectedNames: []string{"Terry Pratchett", "Neil Gaiman"}},
+		{author: "Arkady Strugatsky; Boris Strugatsky;", expectedNames: []string{"Arkady Strugatsky", "Boris Strugatsky"}},
+		{author: " & . ", expectedNames: nil},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedNames, AuthorNames(tc.author), "Unexpected names of %q", tc.author)
+	}
+}
+
+func TestAuthor_IsValid(t *testing.T) {
+	testCasesValid := []Author{
+		{Name: "J.R.R. Tolkien"},
+		{Name: "Terry Pratchett"},
+	}
+	testCasesInvalid := []Author{
+		{},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Author expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Author expected to be invalid, but found valid: %v", tc)
+	}
+}
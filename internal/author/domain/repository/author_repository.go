@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+)
+
+// AuthorRepository stores authors and which books they wrote. A book may have several co-authors, kept in the order
+// of the authorship
+type AuthorRepository interface {
+	SaveAuthor(context.Context, *entity.Author) (*entity.Author, error) // Fails if an author with the same name key exists
+	GetAuthor(context.Context, uint64) (*entity.Author, error)
+	GetAuthors(context.Context, string, int, int) ([]entity.Author, int64, error) // Limit/offset window of authors whose name key contains the key of the name, and their total count
+	UpdateAuthor(context.Context, uint64, *entity.Author) (*entity.Author, error) // Fails if another author has the same name key
+	DeleteAuthor(context.Context, uint64) (int64, error)                          // Books of the author are kept
+	GetAuthorBooks(context.Context, uint64) ([]bookEntity.Book, error)            // Books not in the trash, the earliest first
+	GetBookAuthors(context.Context, uint64) ([]entity.Author, error)              // Co-authors of a book not in the trash
+	SetBookAuthors(context.Context, uint64, []string) ([]entity.Author, error)    // Replaces co-authors of the book by names, creating authors not known yet
+}
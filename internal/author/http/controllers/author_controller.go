@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"database/sql"
+	authorDB "github.com/foxfurry/simple-rest/internal/author/db"
+	"github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/author/http/errors"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type AuthorService struct {
+	dbRepo authorDB.AuthorDBRepository
+}
+
+func NewAuthorService(db *sql.DB) AuthorService {
+	return AuthorService{
+		dbRepo: authorDB.NewAuthorRepo(db),
+	}
+}
+
+// bindBody reads the request body into obj and responds with an error if the body is missing or invalid
+func bindBody(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		if err == io.EOF {
+			errors.HandleAuthorError(c, errors.NewAuthorEmptyBody())
+		} else {
+			errors.HandleAuthorError(c, errors.NewAuthorValidatorError(common_translators.Translate(err)))
+		}
+		return false
+	}
+	return true
+}
+
+// paramID parses the id path parameter and responds with an error if it is not a number
+func paramID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleAuthorError(c, errors.NewAuthorInvalidSerial())
+		return 0, false
+	}
+	return uint64(id), true
+}
+
+func (a *AuthorService) SaveAuthor(c *gin.Context) {
+	var author entity.Author
+
+	if !bindBody(c, &author) {
+		return
+	}
+
+	saveAuthor, err := a.dbRepo.SaveAuthor(c.Request.Context(), &author)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, saveAuthor, nil)
+}
+
+func (a *AuthorService) GetAuthor(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	getAuthor, err := a.dbRepo.GetAuthor(c.Request.Context(), id)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, getAuthor, nil)
+}
+
+// GetAuthors responds with the requested page of authors ordered by name. Optional name parameter keeps only
+// authors named like it
+func (a *AuthorService) GetAuthors(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleAuthorError(c, errors.NewAuthorValidatorError(fieldErrors))
+		return
+	}
+
+	authors, total, err := a.dbRepo.GetAuthors(c.Request.Context(), c.Query("name"), page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, authors, common_pagination.NewPageMeta(c, page, total))
+}
+
+func (a *AuthorService) UpdateAuthor(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var author entity.Author
+
+	if !bindBody(c, &author) {
+		return
+	}
+
+	updatedAuthor, err := a.dbRepo.UpdateAuthor(c.Request.Context(), id, &author)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, updatedAuthor, nil)
+}
+
+func (a *AuthorService) DeleteAuthor(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	_, err := a.dbRepo.DeleteAuthor(c.Request.Context(), id)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, nil, nil)
+}
+
+func (a *AuthorService) GetAuthorBooks(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	books, err := a.dbRepo.GetAuthorBooks(c.Request.Context(), id)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, books, nil)
+}
+
+func (a *AuthorService) GetBookAuthors(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	authors, err := a.dbRepo.GetBookAuthors(c.Request.Context(), id)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, authors, nil)
+}
+
+// SetBookAuthors replaces co-authors of the book by a JSON array of names, in the order of the authorship
+func (a *AuthorService) SetBookAuthors(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var names []string
+
+	if !bindBody(c, &names) {
+		return
+	}
+
+	authors, err := a.dbRepo.SetBookAuthors(c.Request.Context(), id, names)
+	if err != nil {
+		errors.HandleAuthorError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, authors, nil)
+}
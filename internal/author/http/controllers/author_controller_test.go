@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	authordb "github.com/foxfurry/simple-rest/internal/author/db"
+	"github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/author/http/errors"
+	"github.com/foxfurry/simple-rest/internal/author/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type authorResponse struct {
+	Data  *entity.Author `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type authorsResponse struct {
+	Data []entity.Author `json:"data"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+	Error expectedErrors `json:"error"`
+}
+
+var authorColumns = []string{"id", "name"}
+
+func init() {
+	validators.RegisterAuthorValidators()
+}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestAuthorService_SaveAuthor(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewAuthorService(db)
+
+	saveAuthorServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		requestBody    *entity.Author
+		expectedStatus int
+		expectedBody   *entity.Author
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(authordb.QuerySaveAuthor)).WithArgs("J.R.R. Tolkien", "j r r tolkien").WillReturnRows(rows)
+			},
+			requestBody:    &entity.Author{Name: "J.R.R. Tolkien"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Author{ID: 1, Name: "J.R.R. Tolkien"},
+		},
+		{
+			testName: "Test Unsuccessful: Already exists",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(authordb.QuerySaveAuthor)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			requestBody:    &entity.Author{Name: "J. R. R. Tolkien"},
+			expectedStatus: http.StatusConflict,
+			expectedError: expectedErrors{
+				Msg: errors.NewAuthorAlreadyExists("J. R. R. Tolkien").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty name",
+			requestBody:    &entity.Author{},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldNameEmpty},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty request body",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewAuthorEmptyBody().Error(),
+			},
+		},
+	}
+
+	for _, tc := range saveAuthorServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			var body interface{}
+			if tc.requestBody != nil {
+				body = tc.requestBody
+			}
+
+			w := tests.Serve(service.SaveAuthor, http.MethodPost, "/author/", nil, body)
+
+			var response authorResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, response.Data)
+			assert.Equal(t, tc.expectedError, response.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthorService_GetAuthors(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewAuthorService(db)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(authordb.QueryCountAuthors)).WithArgs("%tolkien%").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(authordb.QueryGetAuthors)).WithArgs("%tolkien%", 10, 0).
+			WillReturnRows(sqlmock.NewRows(authorColumns).AddRow(1, "J.R.R. Tolkien"))
+
+		w := tests.Serve(service.GetAuthors, http.MethodGet, "/author/?name=Tolkien&per_page=10", nil, nil)
+
+		var response authorsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []entity.Author{{ID: 1, Name: "J.R.R. Tolkien"}}, response.Data)
+		assert.Equal(t, int64(1), response.Meta.Total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAuthorService_SetBookAuthors(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewAuthorService(db)
+
+	setBookAuthorsServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		param          string
+		requestBody    interface{}
+		expectedStatus int
+		expectedBody   []entity.Author
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(authordb.QueryLockBook)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectExec(regexp.QuoteMeta(authordb.QueryUnlinkBookAuthors)).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(authordb.QueryUpsertAuthor)).WithArgs("J. R. R. Tolkien", "j r r tolkien").
+					WillReturnRows(sqlmock.NewRows(authorColumns).AddRow(1, "J.R.R. Tolkien"))
+				mock.ExpectExec(regexp.QuoteMeta(authordb.QueryLinkBookAuthor)).WithArgs(3, 1, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			param:          "3",
+			requestBody:    []string{"J. R. R. Tolkien"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []entity.Author{{ID: 1, Name: "J.R.R. Tolkien"}},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			param:          "abc",
+			requestBody:    []string{"J.R.R. Tolkien"},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewAuthorInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range setBookAuthorsServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.SetBookAuthors, http.MethodPut, "/book/"+tc.param+"/authors", gin.Params{{Key: "id", Value: tc.param}}, tc.requestBody)
+
+			var response struct {
+				Data  []entity.Author `json:"data"`
+				Error expectedErrors  `json:"error"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, response.Data)
+			assert.Equal(t, tc.expectedError, response.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
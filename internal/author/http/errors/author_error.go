@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type authorsNotFound struct {
+	common_errors.CommonError
+}
+
+type authorBookNotFound struct {
+	common_errors.CommonError
+}
+
+type authorAlreadyExists struct {
+	common_errors.CommonError
+}
+
+type authorCouldNotQuery struct {
+	common_errors.CommonError
+}
+
+type authorInvalidSerial struct {
+	common_errors.CommonError
+}
+
+type authorUnexpectedError struct {
+	common_errors.CommonError
+}
+
+type authorEmptyBody struct {
+	common_errors.CommonError
+}
+
+type authorValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewAuthorsNotFound() authorsNotFound {
+	return authorsNotFound{
+		common_errors.CommonError{Msg: "Author(s) not found in db"},
+	}
+}
+
+func NewAuthorBookNotFound() authorBookNotFound {
+	return authorBookNotFound{
+		common_errors.CommonError{Msg: "Book not found in db"},
+	}
+}
+
+func NewAuthorAlreadyExists(name string) authorAlreadyExists {
+	return authorAlreadyExists{
+		common_errors.CommonError{Msg: fmt.Sprintf("Author named like %v already exists", name)},
+	}
+}
+
+func NewAuthorCouldNotQuery(msg string) authorCouldNotQuery {
+	return authorCouldNotQuery{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not execute query: %v", msg)},
+	}
+}
+
+func NewAuthorInvalidSerial() authorInvalidSerial {
+	return authorInvalidSerial{
+		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
+	}
+}
+
+func NewAuthorUnexpectedError(msg string) authorUnexpectedError {
+	return authorUnexpectedError{
+		common_errors.CommonError{Msg: fmt.Sprintf("Unexpected error: %v", msg)},
+	}
+}
+
+func NewAuthorEmptyBody() authorEmptyBody {
+	return authorEmptyBody{
+		common_errors.CommonError{Msg: "Expected body, found EOF"},
+	}
+}
+
+func NewAuthorValidatorError(fields []validator.FieldError) authorValidatorError {
+	return authorValidatorError{Fields: fields}
+}
+
+func (a authorValidatorError) Error() string {
+	var res = ""
+	for _, f := range a.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+func HandleAuthorError(c *gin.Context, err error) {
+	switch err.(type) {
+	case authorsNotFound, authorBookNotFound:
+		common_errors.RespondNotFound(c, err)
+	case authorAlreadyExists:
+		common_errors.RespondAlreadyExists(c, err)
+	case authorValidatorError, authorInvalidSerial, authorEmptyBody:
+		common_errors.RespondBadRequest(c, err)
+	case authorUnexpectedError, authorCouldNotQuery:
+		common_errors.RespondInternalError(c, err)
+	default:
+		common_errors.RespondInternalError(c, err)
+	}
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/author/http/controllers"
+	"github.com/foxfurry/simple-rest/internal/author/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/gin-gonic/gin"
+)
+
+var authorPolicy = common_auth.Policy{
+	"POST /author/":         common_auth.PermWrite,
+	"PUT /author/:id":       common_auth.PermWrite,
+	"DELETE /author/:id":    common_auth.PermWrite,
+	"PUT /book/:id/authors": common_auth.PermWrite,
+}
+
+func RegisterAuthorRoutes(router *gin.Engine, db *sql.DB, guard *common_auth.Guard) {
+	authorRepo := controllers.NewAuthorService(db)
+
+	author := router.Group("/author", guard.Enforce(authorPolicy))
+	{
+		author.GET("/:id", authorRepo.GetAuthor)
+
+		author.GET("/:id/books", authorRepo.GetAuthorBooks)
+
+		author.GET("/", authorRepo.GetAuthors)
+
+		author.POST("/", authorRepo.SaveAuthor)
+
+		author.PUT("/:id", authorRepo.UpdateAuthor)
+
+		author.DELETE("/:id", authorRepo.DeleteAuthor)
+	}
+
+	book := router.Group("/book", guard.Enforce(authorPolicy))
+	{
+		book.GET("/:id/authors", authorRepo.GetBookAuthors)
+
+		book.PUT("/:id/authors", authorRepo.SetBookAuthors)
+	}
+
+	validators.RegisterAuthorValidators()
+}
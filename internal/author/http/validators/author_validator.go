@@ -0,0 +1,71 @@
+package validators
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"log"
+)
+
+const (
+	idTag         = "validID"
+	requiredTag   = "required"
+	emptyFieldMsg = "cannot be empty"
+
+	// MaxBookAuthors bounds co-authors of one book
+	MaxBookAuthors = 20
+)
+
+var (
+	FieldNameEmpty = common_translators.FieldError{
+		Field: "Name",
+		Msg:   "Name " + emptyFieldMsg,
+	}
+	FieldAuthorsTooMany = common_translators.FieldError{
+		Field: "authors",
+		Msg:   fmt.Sprintf("A book can have up to %v authors", MaxBookAuthors),
+	}
+)
+
+// NewFieldAuthorNameEmpty reports an empty name at idx of the list of co-authors
+func NewFieldAuthorNameEmpty(idx int) common_translators.FieldError {
+	return common_translators.FieldError{
+		Field: fmt.Sprintf("authors[%v]", idx),
+		Msg:   "Name " + emptyFieldMsg,
+	}
+}
+
+var validID validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() >= 1
+}
+
+var trslValidID validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(idTag, "{0} should be positive non-null number", true)
+}
+
+var requiredMessage validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(requiredTag, "{0} "+emptyFieldMsg, true)
+}
+
+// translateTag returns a translation function which renders the message registered for tag
+func translateTag(tag string) validator.TranslationFunc {
+	return func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	}
+}
+
+func RegisterAuthorValidators() {
+	errTranslator := common_translators.GetTranslator()
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation(idTag, validID)
+		v.RegisterTranslation(idTag, errTranslator, trslValidID, translateTag(idTag))
+
+		v.RegisterTranslation(requiredTag, errTranslator, requiredMessage, translateTag(requiredTag))
+	} else {
+		log.Panicf("Could not register common_translators: %v", ok)
+	}
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	"log"
+	"time"
+)
+
+const (
+	QueryInsertBookAudit = `INSERT INTO book_audit (book_id, action, old_value, new_value, actor, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	QueryAuditDeleteAllBooks = `INSERT INTO book_audit (book_id, action, old_value, new_value, actor, request_id, created_at)
+		SELECT id, $1::text, json_build_object('id', id, 'title', title, 'author', author, 'year', year, 'description', COALESCE(description, ''), 'isbn', isbn),
+		NULL, $2::text, $3::text, $4::timestamptz FROM bookstore WHERE deleted_at IS NULL`
+	QueryAuditPurgeBooks = `INSERT INTO book_audit (book_id, action, old_value, new_value, actor, request_id, created_at)
+		SELECT id, $1::text, json_build_object('id', id, 'title', title, 'author', author, 'year', year, 'description', COALESCE(description, ''), 'isbn', isbn),
+		NULL, $2::text, $3::text, $4::timestamptz FROM bookstore WHERE deleted_at IS NOT NULL AND deleted_at < $5`
+	QueryGetBookForUpdate = `SELECT id, title, author, year, description, isbn FROM bookstore WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+)
+
+// atomically runs fn with a repository whose queries share one transaction, so a mutation is never stored without
+// its audit record. Repository which already runs in a transaction is passed to fn as is
+func (r *BookDBRepository) atomically(ctx context.Context, fn func(*BookDBRepository) error) error {
+	db, ok := r.database.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+
+	uow := BookDBUnitOfWork{database: db, dialect: r.dialect}
+	return uow.Atomically(ctx, func(tx repository.BookRepository) error {
+		return fn(tx.(*BookDBRepository))
+	})
+}
+
+// bookForUpdate returns the live book with bookID, locking its row until the transaction ends
+func (r *BookDBRepository) bookForUpdate(ctx context.Context, bookID uint64) (*entity.Book, error) {
+	var book entity.Book
+
+	err := r.database.QueryRowContext(ctx, r.query(QueryGetBookForUpdate), bookID).Scan(&book.ID, &book.Title,
+		&book.Author, &book.Year, &book.Description, &book.ISBN)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return &book, nil
+}
+
+// recordChange writes the audit record of a mutation of one book, made by the actor of ctx
+func (r *BookDBRepository) recordChange(ctx context.Context, bookID uint64, action string, oldValue *entity.Book, newValue *entity.Book) error {
+	oldJSON, err := auditValue(oldValue)
+	if err != nil {
+		return errors.NewBookUnexpectedError(err.Error())
+	}
+	newJSON, err := auditValue(newValue)
+	if err != nil {
+		return errors.NewBookUnexpectedError(err.Error())
+	}
+
+	_, err = r.database.ExecContext(ctx, r.query(QueryInsertBookAudit), bookID, action, oldJSON, newJSON,
+		common_request.Actor(ctx), common_request.RequestID(ctx), time.Now().UTC())
+	if err != nil {
+		log.Printf("Unable to record the change of book id#%v: %v", bookID, err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+// recordChanges writes audit records of books removed by a bulk mutation. The query selects the books itself, taking
+// the action, the actor, the request id and the time as its first arguments, followed by args
+func (r *BookDBRepository) recordChanges(ctx context.Context, auditQuery string, action string, args ...interface{}) error {
+	args = append([]interface{}{action, common_request.Actor(ctx), common_request.RequestID(ctx), time.Now().UTC()}, args...)
+
+	if _, err := r.database.ExecContext(ctx, r.query(auditQuery), args...); err != nil {
+		log.Printf("Unable to record the changes of books: %v", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+// GetAudit returns audit records of the limit/offset window of query, the latest first, and the total count of
+// records matching it. Finding no records is not an error
+func (r *BookDBRepository) GetAudit(ctx context.Context, query repository.BookAuditQuery) ([]entity.BookChange, int64, error) {
+	var total int64
+
+	countQuery, countArgs := buildAuditCountQuery(r.dialect, query)
+
+	err := r.database.QueryRowContext(ctx, r.query(countQuery), countArgs...).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count audit records: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	changes := []entity.BookChange{}
+	if total == 0 {
+		return changes, 0, nil
+	}
+
+	pageQuery, pageArgs := buildAuditPageQuery(r.dialect, query)
+
+	rows, err := r.database.QueryContext(ctx, r.query(pageQuery), pageArgs...)
+	if err != nil {
+		log.Printf("Unable to get audit records: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var change entity.BookChange
+		var oldJSON, newJSON sql.NullString
+
+		err = rows.Scan(&change.ID, &change.BookID, &change.Action, &oldJSON, &newJSON, &change.Actor,
+			&change.RequestID, &change.CreatedAt)
+		if err != nil {
+			log.Printf("Unable to scan the audit record: %v", err)
+			continue
+		}
+
+		if change.OldValue, err = parseAuditValue(oldJSON); err != nil {
+			log.Printf("Unable to parse old value of audit record #%v: %v", change.ID, err)
+		}
+		if change.NewValue, err = parseAuditValue(newJSON); err != nil {
+			log.Printf("Unable to parse new value of audit record #%v: %v", change.ID, err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return changes, total, nil
+}
+
+// auditValue encodes book as JSON for the audit log. Missing book is stored as NULL
+func auditValue(book *entity.Book) (interface{}, error) {
+	if book == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// parseAuditValue is the inverse of auditValue
+func parseAuditValue(value sql.NullString) (*entity.Book, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+
+	var book entity.Book
+	if err := json.Unmarshal([]byte(value.String), &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
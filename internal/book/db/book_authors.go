@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	authorEntity "github.com/foxfurry/simple-rest/internal/author/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"log"
+)
+
+const (
+	// QueryUpsertBookAuthor returns id of the author with the name key, creating the author if needed. Name of an
+	// existing author is kept as it is
+	QueryUpsertBookAuthor = `INSERT INTO authors (name, name_key) VALUES ($1, $2)
+		ON CONFLICT (name_key) DO UPDATE SET name_key=EXCLUDED.name_key RETURNING id`
+	QueryLinkBookAuthor    = `INSERT INTO book_authors (book_id, author_id, position) VALUES ($1, $2, $3)`
+	QueryUnlinkBookAuthors = `DELETE FROM book_authors WHERE book_id=$1`
+)
+
+// authorKey returns what books of author are looked up by: the name key on postgres, where books are linked to
+// authors, and the author itself with other dialects. SQLite stores no authors, so searching by author there matches
+// the author of the book exactly, case and co-authors included
+func authorKey(dialect database.Dialect, author string) string {
+	if dialect != database.Postgres {
+		return author
+	}
+	return authorEntity.AuthorNameKey(author)
+}
+
+// linkAuthors links the book to the authors named by author, creating the ones which do not exist yet. Co-authors
+// are told apart by authorEntity.AuthorNames. Authors are stored in postgres only, with other dialects it does nothing
+// and books are searched by their author field instead, see authorKey
+func (r *BookDBRepository) linkAuthors(ctx context.Context, bookID uint64, author string) error {
+	if r.dialect != database.Postgres {
+		return nil
+	}
+
+	linked := make(map[uint64]bool)
+	for _, name := range authorEntity.AuthorNames(author) {
+		var authorID uint64
+
+		err := r.database.QueryRowContext(ctx, r.query(QueryUpsertBookAuthor), name, authorEntity.AuthorNameKey(name)).Scan(&authorID)
+		if err != nil {
+			log.Printf("Unable to save author of book id#%v: %v", bookID, err)
+			return queryError(ctx, err)
+		}
+
+		if linked[authorID] {
+			continue
+		}
+
+		if _, err = r.database.ExecContext(ctx, r.query(QueryLinkBookAuthor), bookID, authorID, len(linked)); err != nil {
+			log.Printf("Unable to link author id#%v to book id#%v: %v", authorID, bookID, err)
+			return queryError(ctx, err)
+		}
+		linked[authorID] = true
+	}
+
+	return nil
+}
+
+// relinkAuthors replaces the authors of the book once its author has changed. Authors of a book whose author stays
+// the same are kept, including the co-authors set through the authors endpoints
+func (r *BookDBRepository) relinkAuthors(ctx context.Context, oldBook *entity.Book, newBook *entity.Book) error {
+	if r.dialect != database.Postgres || oldBook.Author == newBook.Author {
+		return nil
+	}
+
+	if _, err := r.database.ExecContext(ctx, r.query(QueryUnlinkBookAuthors), oldBook.ID); err != nil {
+		log.Printf("Unable to unlink authors of book id#%v: %v", oldBook.ID, err)
+		return queryError(ctx, err)
+	}
+
+	return r.linkAuthors(ctx, oldBook.ID, newBook.Author)
+}
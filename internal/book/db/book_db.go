@@ -1,87 +1,161 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
 	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/errors"
 	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/database"
 	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
 	"log"
+	"time"
 )
 
+// BookDBRepository stores books in a SQL database. Repositories handed out by BookDBUnitOfWork run their queries in
+// a transaction
 type BookDBRepository struct {
-	database *sql.DB
+	database database.Conn
+	dialect  database.Dialect
 }
 
 func NewBookRepo(db *sql.DB) BookDBRepository {
-	return BookDBRepository{database: db}
+	return BookDBRepository{database: db, dialect: database.DialectOf(db)}
 }
 
 var _ repository.BookRepository = &BookDBRepository{}
 
 const (
-	QuerySaveBook = `INSERT INTO bookstore (title, author, year, description) VALUES ($1, $2, $3, $4) RETURNING id`
-	QueryGetBook            = `SELECT * FROM bookstore WHERE id=$1`
-	QueryGetAll             = `SELECT * FROM bookstore`
-	QuerySearchByAuthorBook = `SELECT * FROM bookstore WHERE author=$1`
-	QuerySearchByTitleBook = `SELECT * FROM bookstore WHERE title=$1`
-	QueryUpdateBook             = `UPDATE bookstore SET title=$2, author=$3, year=$4, description=$5 WHERE id=$1`
-	QueryDeleteBook             = `DELETE FROM bookstore WHERE id=$1`
-	QueryDeleteAllBooksAndAlter = `DELETE FROM bookstore; ALTER SEQUENCE bookstore_id_seq RESTART WITH 1`
+	QuerySaveBook = `INSERT INTO bookstore (title, author, year, description, isbn) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	QueryGetBook            = `SELECT id, title, author, year, description, isbn, version FROM bookstore WHERE id=$1 AND deleted_at IS NULL`
+	QueryGetBookByISBN      = `SELECT id, title, author, year, description, isbn, version FROM bookstore WHERE isbn=$1 AND deleted_at IS NULL`
+	QueryGetBookVersion     = `SELECT version FROM bookstore WHERE id=$1 AND deleted_at IS NULL`
+	QueryGetAll             = `SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL`
+	// QuerySearchByAuthorBook finds books through the authors they are linked to, so every spelling sharing the name
+	// key of the author finds them, as does the name of any co-author
+	QuerySearchByAuthorBook = `SELECT b.id, b.title, b.author, b.year, b.description, b.isbn FROM bookstore b
+		JOIN book_authors ba ON ba.book_id = b.id JOIN authors a ON a.id = ba.author_id
+		WHERE a.name_key=$1 AND b.deleted_at IS NULL ORDER BY b.id`
+	QuerySearchByTitleBook = `SELECT id, title, author, year, description, isbn FROM bookstore WHERE title=$1 AND deleted_at IS NULL`
+	QuerySearchByKeywordBook = `SELECT id, title, author, year, description, isbn FROM bookstore
+		WHERE (title ILIKE $1 OR author ILIKE $1 OR description ILIKE $1) AND deleted_at IS NULL ORDER BY id LIMIT $2`
+	QueryFullTextSearchBook = `SELECT id, title, author, year, description, isbn, ts_rank(search_vector, query) AS rank,
+		ts_headline('english', title || ' ' || COALESCE(description, ''), query) AS headline
+		FROM bookstore, websearch_to_tsquery('english', $1) query WHERE search_vector @@ query AND deleted_at IS NULL
+		ORDER BY rank DESC, id LIMIT $2 OFFSET $3`
+	QuerySimilarByAuthorBook = `SELECT id, title, author, year, description, isbn, similarity(author, $1) AS score FROM bookstore
+		WHERE similarity(author, $1) >= $2 AND deleted_at IS NULL ORDER BY score DESC, id LIMIT $3`
+	QuerySimilarByTitleBook = `SELECT id, title, author, year, description, isbn, similarity(title, $1) AS score FROM bookstore
+		WHERE similarity(title, $1) >= $2 AND deleted_at IS NULL ORDER BY score DESC, id LIMIT $3`
+	QueryUpdateBook             = `UPDATE bookstore SET title=$2, author=$3, year=$4, description=$5, isbn=$6, version=version+1
+		WHERE id=$1 AND deleted_at IS NULL AND ($7=0 OR version=$7) RETURNING version`
+	QueryDeleteBook     = `UPDATE bookstore SET deleted_at=$2 WHERE id=$1 AND deleted_at IS NULL`
+	QueryDeleteAllBooks = `UPDATE bookstore SET deleted_at=$1 WHERE deleted_at IS NULL`
+	QueryCountTrash     = `SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NOT NULL`
+	QueryGetTrash       = `SELECT id, title, author, year, description, isbn, deleted_at FROM bookstore WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id LIMIT $1 OFFSET $2`
+	QueryRestoreBook = `UPDATE bookstore SET deleted_at=NULL, version=version+1 WHERE id=$1 AND deleted_at IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM bookstore live WHERE live.isbn = bookstore.isbn AND live.isbn <> '' AND live.deleted_at IS NULL)
+		RETURNING id, title, author, year, description, isbn, version`
+	QueryGetTrashedBookISBN = `SELECT isbn FROM bookstore WHERE id=$1 AND deleted_at IS NOT NULL`
+	QueryPurgeBooks = `DELETE FROM bookstore WHERE deleted_at IS NOT NULL AND deleted_at < $1`
 )
 
-func (r *BookDBRepository) SaveBook(book *entity.Book) (*entity.Book, error) {
-	var bookID uint64
+// sqliteQueries replaces queries relying on postgres only syntax. Full-text search, similarity and JSON objects are
+// computed by functions the SQLite driver registers, see database.CreateSQLitePool. SQLite has no row locks, writers
+// are serialized by the database lock instead. There are no authors either, books of an author are the ones whose
+// author is exactly the one searched for
+var sqliteQueries = map[string]string{
+	QuerySearchByAuthorBook: `SELECT id, title, author, year, description, isbn FROM bookstore WHERE author=$1 AND deleted_at IS NULL`,
+	QuerySearchByKeywordBook: `SELECT id, title, author, year, description, isbn FROM bookstore
+		WHERE (title LIKE $1 ESCAPE '\' OR author LIKE $1 ESCAPE '\' OR description LIKE $1 ESCAPE '\') AND deleted_at IS NULL
+		ORDER BY id LIMIT $2`,
+	QueryFullTextSearchBook: `SELECT id, title, author, year, description, isbn,
+		text_rank($1, title, author, COALESCE(description, '')) AS rank,
+		text_headline(title || ' ' || COALESCE(description, ''), $1) AS headline
+		FROM bookstore WHERE rank > 0 AND deleted_at IS NULL ORDER BY rank DESC, id LIMIT $2 OFFSET $3`,
+	QueryAuditDeleteAllBooks: `INSERT INTO book_audit (book_id, action, old_value, new_value, actor, request_id, created_at)
+		SELECT id, $1, json_build_object('id', id, 'title', title, 'author', author, 'year', year, 'description', COALESCE(description, ''), 'isbn', isbn),
+		NULL, $2, $3, $4 FROM bookstore WHERE deleted_at IS NULL`,
+	QueryAuditPurgeBooks: `INSERT INTO book_audit (book_id, action, old_value, new_value, actor, request_id, created_at)
+		SELECT id, $1, json_build_object('id', id, 'title', title, 'author', author, 'year', year, 'description', COALESCE(description, ''), 'isbn', isbn),
+		NULL, $2, $3, $4 FROM bookstore WHERE deleted_at IS NOT NULL AND deleted_at < $5`,
+	QueryGetBookForUpdate: `SELECT id, title, author, year, description, isbn FROM bookstore WHERE id=$1 AND deleted_at IS NULL`,
+}
+
+// query returns query in the dialect of the database
+func (r *BookDBRepository) query(query string) string {
+	if r.dialect == database.SQLite {
+		if replacement, ok := sqliteQueries[query]; ok {
+			query = replacement
+		}
+	}
+	return r.dialect.Rebind(query)
+}
 
-	err := r.database.QueryRow(QuerySaveBook, book.Title, book.Author, book.Year, book.Description).Scan(&bookID)
+func (r *BookDBRepository) SaveBook(ctx context.Context, book *entity.Book) (*entity.Book, error) {
+	returnBook := withCanonicalISBN(*book)
+
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		err := repo.database.QueryRowContext(ctx, repo.query(QuerySaveBook), returnBook.Title, returnBook.Author,
+			returnBook.Year, returnBook.Description, returnBook.ISBN).Scan(&returnBook.ID)
+
+		if err != nil {
+			log.Printf("Unable to save book to db: %v", err)
+			return writeError(ctx, err, returnBook.ISBN)
+		}
 
+		if err = repo.linkAuthors(ctx, returnBook.ID, returnBook.Author); err != nil {
+			return err
+		}
+
+		return repo.recordChange(ctx, returnBook.ID, entity.BookActionCreate, nil, &returnBook)
+	})
 	if err != nil {
-		log.Printf("Unable to save book to db: %v", err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, err
 	}
 
-	returnBook := *book
-	returnBook.ID = bookID
 	return &returnBook, nil
 }
 
-func (r *BookDBRepository) GetBook(bookID uint64) (*entity.Book, error) {
+func (r *BookDBRepository) GetBook(ctx context.Context, bookID uint64) (*entity.VersionedBook, error) {
 	if bookID < 1 {
 		log.Printf("Serial is less than 1")
 		return nil, errors.NewBookInvalidSerial()
 	}
-	var book entity.Book
+	var book entity.VersionedBook
 
-	row := r.database.QueryRow(QueryGetBook, bookID)
+	row := r.database.QueryRowContext(ctx, r.query(QueryGetBook), bookID)
 
-	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.Description)
+	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.Description, &book.ISBN, &book.Version)
 
 	if err == sql.ErrNoRows {
 		log.Printf("Book id#%v not found", bookID)
 		return nil, errors.NewBooksNotFound()
 	} else if err != nil {
 		log.Printf("Could not execute the query: %v", err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, queryError(ctx, err)
 	}
 
 	return &book, nil
 }
 
-func (r *BookDBRepository) GetAllBooks() ([]entity.Book, error) {
+func (r *BookDBRepository) GetAllBooks(ctx context.Context) ([]entity.Book, error) {
 	var books []entity.Book
 
-	rows, err := r.database.Query(QueryGetAll)
+	rows, err := r.database.QueryContext(ctx, r.query(QueryGetAll))
 	if err != nil {
 		log.Printf("Unable to get all books: %v", err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, queryError(ctx, err)
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
 		var tempBook entity.Book
-		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description)
+		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description, &tempBook.ISBN)
 
 		if err != nil {
 			log.Printf("Unable to scan the user: %v", err)
@@ -91,6 +165,11 @@ func (r *BookDBRepository) GetAllBooks() ([]entity.Book, error) {
 		books = append(books, tempBook)
 	}
 
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
 	if len(books) == 0 {
 		log.Printf("Could not get all the books\n")
 		return nil, errors.NewBooksNotFound()
@@ -99,16 +178,158 @@ func (r *BookDBRepository) GetAllBooks() ([]entity.Book, error) {
 	return books, nil
 }
 
-func (r *BookDBRepository) SearchByAuthor(author string) ([]entity.Book, error) {
+// GetBooksPage returns books of the limit/offset window of query, and the total count of books matching it.
+// Window past the last book is not an error as long as there are books at all
+func (r *BookDBRepository) GetBooksPage(ctx context.Context, query repository.BookListQuery) ([]entity.Book, int64, error) {
+	var total int64
+
+	countQuery, countArgs := buildCountQuery(r.dialect, query)
+
+	err := r.database.QueryRowContext(ctx, r.query(countQuery), countArgs...).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count books: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	if total == 0 {
+		log.Printf("Could not get the page of books\n")
+		return nil, 0, notFoundError(query)
+	}
+
+	pageQuery, pageArgs := buildPageQuery(r.dialect, query)
+
+	rows, err := r.database.QueryContext(ctx, r.query(pageQuery), pageArgs...)
+	if err != nil {
+		log.Printf("Unable to get the page of books: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		log.Printf("Could not read the page of books: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return books, total, nil
+}
+
+// GetBooksAfter returns up to limit books which follow the keyset of query, and the keyset the next page starts
+// after. Next keyset is nil when there are no more books. Empty first page is reported as not found
+func (r *BookDBRepository) GetBooksAfter(ctx context.Context, query repository.BookListQuery) ([]entity.Book, *repository.Keyset, error) {
+	keysetQuery, args := buildKeysetQuery(r.dialect, query, query.Limit+1) // One extra book tells if there is a next page
+
+	rows, err := r.database.QueryContext(ctx, r.query(keysetQuery), args...)
+	if err != nil {
+		log.Printf("Unable to get books after keyset: %v", err)
+		return nil, nil, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		log.Printf("Could not read books after keyset: %v", err)
+		return nil, nil, queryError(ctx, err)
+	}
+
+	if len(books) == 0 && query.After == nil {
+		log.Printf("Could not get the first page of books\n")
+		return nil, nil, notFoundError(query)
+	}
+
+	var next *repository.Keyset
+	if len(books) > query.Limit {
+		books = books[:query.Limit]
+		next = bookKeyset(books[len(books)-1], query.Sort)
+	}
+
+	return books, next, nil
+}
+
+// ExportBooks calls each for every book matching filters of query, straight from the cursor, so the books are never
+// held in memory all at once. Error of each stops the export and is returned as is. A row which cannot be scanned
+// stops the export too, rather than being left out of it unnoticed
+func (r *BookDBRepository) ExportBooks(ctx context.Context, query repository.BookListQuery, each func(entity.Book) error) error {
+	exportQuery, args := buildExportQuery(r.dialect, query)
+
+	rows, err := r.database.QueryContext(ctx, r.query(exportQuery), args...)
+	if err != nil {
+		log.Printf("Unable to export books: %v", err)
+		return queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tempBook entity.Book
+
+		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description, &tempBook.ISBN)
+		if err != nil {
+			log.Printf("Unable to scan the book: %v", err)
+			return queryError(ctx, err)
+		}
+
+		if err = each(tempBook); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+// queryError wraps the error of a failed query, reporting a timeout when the context deadline has passed
+func queryError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.NewBookQueryTimeout()
+	}
+	return errors.NewBookCouldNotQuery(err.Error())
+}
+
+// scanBooks reads all the rows into a slice of books. Rows which could not be scanned are skipped, the error is
+// returned only when reading the rows was interrupted
+func scanBooks(rows *sql.Rows) ([]entity.Book, error) {
+	books := []entity.Book{}
+
+	for rows.Next() {
+		var tempBook entity.Book
+
+		err := rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description, &tempBook.ISBN)
+		if err != nil {
+			log.Printf("Unable to scan the book: %v", err)
+			continue
+		}
+
+		books = append(books, tempBook)
+	}
+
+	return books, rows.Err()
+}
+
+// notFoundError returns the error for a listing without books, naming the filter which left it empty
+func notFoundError(query repository.BookListQuery) error {
+	if query.Author != "" {
+		return errors.NewBookNotFoundByAuthor(query.Author)
+	}
+	return errors.NewBooksNotFound()
+}
+
+func (r *BookDBRepository) SearchByAuthor(ctx context.Context, author string) ([]entity.Book, error) {
 	if author == "" {
 		log.Printf("Author field is empty")
 		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldAuthorEmpty})
 	}
-	rows, err := r.database.Query(QuerySearchByAuthorBook, author)
+	rows, err := r.database.QueryContext(ctx, r.query(QuerySearchByAuthorBook), authorKey(r.dialect, author))
 
 	if err != nil {
 		log.Printf("Could not get all books with author %v: %v", author, err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, queryError(ctx, err)
 	}
 
 	defer rows.Close()
@@ -117,7 +338,7 @@ func (r *BookDBRepository) SearchByAuthor(author string) ([]entity.Book, error)
 	for rows.Next() {
 		var tempBook entity.Book
 
-		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description)
+		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description, &tempBook.ISBN)
 
 		if err != nil {
 			log.Printf("Could not scan the row: %v", err)
@@ -127,6 +348,11 @@ func (r *BookDBRepository) SearchByAuthor(author string) ([]entity.Book, error)
 		books = append(books, tempBook)
 	}
 
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
 	if len(books) == 0 {
 		log.Printf("Could not get all the books by author: %v\n", author)
 		return books, errors.NewBookNotFoundByAuthor(author)
@@ -135,7 +361,7 @@ func (r *BookDBRepository) SearchByAuthor(author string) ([]entity.Book, error)
 	return books, nil
 }
 
-func (r *BookDBRepository) SearchByTitle(title string) (*entity.Book, error) {
+func (r *BookDBRepository) SearchByTitle(ctx context.Context, title string) (*entity.Book, error) {
 	var book entity.Book
 
 	if title == "" {
@@ -143,86 +369,419 @@ func (r *BookDBRepository) SearchByTitle(title string) (*entity.Book, error) {
 		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldTitleEmpty})
 	}
 
-	row := r.database.QueryRow(QuerySearchByTitleBook, title)
+	row := r.database.QueryRowContext(ctx, r.query(QuerySearchByTitleBook), title)
 
-	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.Description)
+	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.Description, &book.ISBN)
 	if err == sql.ErrNoRows {
 		log.Printf("Book title#%v not found", title)
 		return nil, errors.NewBookNotFoundByTitle(title)
 	} else if err != nil {
 		log.Printf("Could not execute the query: %v", err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, queryError(ctx, err)
 	}
 
 	return &book, nil
 }
 
-func (r *BookDBRepository) UpdateBook(bookID uint64, book *entity.Book) (*entity.Book, error) {
+// SearchByKeyword returns books which contain keyword in title, author or description. Unlike other searches it does
+// not treat empty result as an error, since it is used to merge results of several media
+func (r *BookDBRepository) SearchByKeyword(ctx context.Context, keyword string, limit int) ([]entity.Book, error) {
+	rows, err := r.database.QueryContext(ctx, r.query(QuerySearchByKeywordBook), database.ContainsPattern(keyword), limit)
+	if err != nil {
+		log.Printf("Could not search books by keyword %v: %v", keyword, err)
+		return nil, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	books := []entity.Book{}
+	for rows.Next() {
+		var tempBook entity.Book
+
+		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description, &tempBook.ISBN)
+
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		books = append(books, tempBook)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return books, nil
+}
+
+// FullTextSearch returns books matching the web search style query, the most relevant first. Every match comes with
+// a snippet of its title and description where matched words are highlighted
+func (r *BookDBRepository) FullTextSearch(ctx context.Context, query string, limit int, offset int) ([]entity.BookMatch, error) {
+	rows, err := r.database.QueryContext(ctx, r.query(QueryFullTextSearchBook), query, limit, offset)
+	if err != nil {
+		log.Printf("Could not search books by text %v: %v", query, err)
+		return nil, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	matches := []entity.BookMatch{}
+	for rows.Next() {
+		var tempMatch entity.BookMatch
+
+		err = rows.Scan(&tempMatch.ID, &tempMatch.Title, &tempMatch.Author, &tempMatch.Year, &tempMatch.Description, &tempMatch.ISBN,
+			&tempMatch.Rank, &tempMatch.Headline)
+
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		matches = append(matches, tempMatch)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
+	if len(matches) == 0 && offset == 0 {
+		log.Printf("Could not find books matching %v\n", query)
+		return nil, errors.NewBookNothingMatches(query)
+	}
+
+	return matches, nil
+}
+
+// similarQueries maps fields books can be compared by to queries ranking books by trigram similarity of the field
+var similarQueries = map[string]string{
+	"author": QuerySimilarByAuthorBook,
+	"title":  QuerySimilarByTitleBook,
+}
+
+// SearchSimilar returns up to limit books whose field (author or title) is at least threshold similar to value, the
+// most similar first. Finding no candidates is not an error
+func (r *BookDBRepository) SearchSimilar(ctx context.Context, field string, value string, threshold float64, limit int) ([]entity.BookCandidate, error) {
+	query, ok := similarQueries[field]
+	if !ok {
+		log.Printf("Books cannot be compared by %v", field)
+		return nil, errors.NewBookUnexpectedError(fmt.Sprintf("books cannot be compared by %v", field))
+	}
+
+	rows, err := r.database.QueryContext(ctx, r.query(query), value, threshold, limit)
+	if err != nil {
+		log.Printf("Could not search books with %v similar to %v: %v", field, value, err)
+		return nil, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	candidates := []entity.BookCandidate{}
+	for rows.Next() {
+		var tempCandidate entity.BookCandidate
+
+		err = rows.Scan(&tempCandidate.ID, &tempCandidate.Title, &tempCandidate.Author, &tempCandidate.Year,
+			&tempCandidate.Description, &tempCandidate.ISBN, &tempCandidate.Similarity)
+
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		candidates = append(candidates, tempCandidate)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return candidates, nil
+}
+
+// UpdateBook replaces the book, provided its version is still the given one. Zero version replaces any version.
+// Returned book has the version after the update
+func (r *BookDBRepository) UpdateBook(ctx context.Context, bookID uint64, version uint64, book *entity.Book) (*entity.VersionedBook, error) {
 	if bookID < 1 {
 		log.Printf("Serial is less than 1")
 		return nil, errors.NewBookInvalidSerial()
 	}
-	_, err := r.database.Exec(QueryUpdateBook, bookID, book.Title, book.Author, book.Year, book.Description)
 
-	returnBook := *book
+	returnBook := entity.VersionedBook{Book: withCanonicalISBN(*book)}
 	returnBook.ID = bookID
+
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		oldBook, err := repo.bookForUpdate(ctx, bookID)
+		if err != nil {
+			return err
+		}
+
+		err = repo.database.QueryRowContext(ctx, repo.query(QueryUpdateBook), bookID, returnBook.Title, returnBook.Author,
+			returnBook.Year, returnBook.Description, returnBook.ISBN, version).Scan(&returnBook.Version)
+
+		if err == sql.ErrNoRows {
+			return repo.updateMissed(ctx, bookID)
+		} else if err != nil {
+			log.Printf("Unable to update book: %v", err)
+			return writeError(ctx, err, returnBook.ISBN)
+		}
+
+		if err = repo.relinkAuthors(ctx, oldBook, &returnBook.Book); err != nil {
+			return err
+		}
+
+		return repo.recordChange(ctx, bookID, entity.BookActionUpdate, oldBook, &returnBook.Book)
+	})
 	if err != nil {
-		log.Printf("Unable to update book: %v", err)
-		return nil, errors.NewBookCouldNotQuery(err.Error())
+		return nil, err
 	}
 
 	return &returnBook, nil
 }
 
-func (r *BookDBRepository) DeleteBook(bookID uint64) (int64, error) {
+// PatchBook changes only the fields set in patch, provided the version of the book is still the given one. Zero
+// version patches any version. Returned book is the whole book after the update
+func (r *BookDBRepository) PatchBook(ctx context.Context, bookID uint64, version uint64, patch entity.BookPatch) (*entity.VersionedBook, error) {
 	if bookID < 1 {
 		log.Printf("Serial is less than 1")
-		return 0, errors.NewBookInvalidSerial()
+		return nil, errors.NewBookInvalidSerial()
 	}
 
-	res, err := r.database.Exec(QueryDeleteBook, bookID)
+	patch = patchWithCanonicalISBN(patch)
+	patchQuery, args := buildPatchQuery(r.dialect, bookID, version, patch)
+
+	var book entity.VersionedBook
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		oldBook, err := repo.bookForUpdate(ctx, bookID)
+		if err != nil {
+			return err
+		}
+
+		err = repo.database.QueryRowContext(ctx, repo.query(patchQuery), args...).Scan(&book.ID, &book.Title, &book.Author,
+			&book.Year, &book.Description, &book.ISBN, &book.Version)
+
+		if err == sql.ErrNoRows {
+			return repo.updateMissed(ctx, bookID)
+		} else if err != nil {
+			log.Printf("Unable to patch book: %v", err)
+			return writeError(ctx, err, patch.Apply(*oldBook).ISBN)
+		}
+
+		if err = repo.relinkAuthors(ctx, oldBook, &book.Book); err != nil {
+			return err
+		}
 
+		return repo.recordChange(ctx, bookID, entity.BookActionPatch, oldBook, &book.Book)
+	})
 	if err != nil {
-		log.Printf("Unable to delete book: %v", err)
-		return 0, errors.NewBookCouldNotQuery(err.Error())
+		return nil, err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	return &book, nil
+}
 
-	if err != nil {
-		log.Printf("Unable to get affected rows book: %v", err)
-		return 0, errors.NewBookCouldNotQuery(err.Error())
+// updateMissed tells why an update matched no row: either the book is gone or its version has moved on
+func (r *BookDBRepository) updateMissed(ctx context.Context, bookID uint64) error {
+	var current uint64
+
+	err := r.database.QueryRowContext(ctx, r.query(QueryGetBookVersion), bookID).Scan(&current)
+	if err == sql.ErrNoRows {
+		log.Printf("Book id#%v not found", bookID)
+		return errors.NewBooksNotFound()
+	} else if err != nil {
+		log.Printf("Could not get the version of the book: %v", err)
+		return queryError(ctx, err)
 	}
 
-	if rowsAffected == 0 {
-		return 0, errors.NewBooksNotFound()
+	log.Printf("Book id#%v is at version %v, update rejected", bookID, current)
+	return errors.NewBookVersionMismatch(current)
+}
+
+// DeleteBook moves the book to the trash. Book already in the trash is not found. On postgres the book also leaves
+// every collection it was in, by the trigger created along with collections
+func (r *BookDBRepository) DeleteBook(ctx context.Context, bookID uint64) (int64, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewBookInvalidSerial()
+	}
+
+	var rowsAffected int64
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		oldBook, err := repo.bookForUpdate(ctx, bookID)
+		if err != nil {
+			return err
+		}
+
+		res, err := repo.database.ExecContext(ctx, repo.query(QueryDeleteBook), bookID, time.Now().UTC())
+
+		if err != nil {
+			log.Printf("Unable to delete book: %v", err)
+			return queryError(ctx, err)
+		}
+
+		rowsAffected, err = res.RowsAffected()
+
+		if err != nil {
+			log.Printf("Unable to get affected rows book: %v", err)
+			return queryError(ctx, err)
+		}
+
+		if rowsAffected == 0 {
+			return errors.NewBooksNotFound()
+		}
+
+		return repo.recordChange(ctx, bookID, entity.BookActionDelete, oldBook, nil)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	log.Printf("Deleted rows: %v", rowsAffected)
 
-	return rowsAffected, err
+	return rowsAffected, nil
 }
 
-func (r *BookDBRepository) DeleteAllBooks() (int64, error) {
-	res, err := r.database.Exec(QueryDeleteAllBooksAndAlter)
+// DeleteAllBooks moves every book to the trash
+func (r *BookDBRepository) DeleteAllBooks(ctx context.Context) (int64, error) {
+	var rowsAffected int64
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		if err := repo.recordChanges(ctx, QueryAuditDeleteAllBooks, entity.BookActionDelete); err != nil {
+			return err
+		}
+
+		res, err := repo.database.ExecContext(ctx, repo.query(QueryDeleteAllBooks), time.Now().UTC())
 
+		if err != nil {
+			log.Printf("Unable to delete books: %v", err)
+			return queryError(ctx, err)
+		}
+
+		rowsAffected, err = res.RowsAffected()
+
+		if err != nil {
+			log.Printf("Unable to get affected rows book: %v", err)
+			return queryError(ctx, err)
+		}
+
+		if rowsAffected == 0 {
+			return errors.NewBooksNotFound()
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Unable to delete book or alter the sequence: %v", err)
-		return 0, errors.NewBookCouldNotQuery(err.Error())
+		return 0, err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	log.Printf("Rows affected: %v", rowsAffected)
 
+	return rowsAffected, nil
+}
+
+// GetTrash returns books of the limit/offset window of the trash, the latest deleted first, and the total count of
+// books in the trash. Empty trash is not an error
+func (r *BookDBRepository) GetTrash(ctx context.Context, limit int, offset int) ([]entity.DeletedBook, int64, error) {
+	var total int64
+
+	err := r.database.QueryRowContext(ctx, r.query(QueryCountTrash)).Scan(&total)
 	if err != nil {
-		log.Printf("Unable to get affected rows book: %v", err)
-		return 0, errors.NewBookCouldNotQuery(err.Error())
+		log.Printf("Unable to count books in the trash: %v", err)
+		return nil, 0, queryError(ctx, err)
 	}
 
-	if rowsAffected == 0 {
-		return 0, errors.NewBooksNotFound()
+	books := []entity.DeletedBook{}
+	if total == 0 {
+		return books, 0, nil
 	}
 
-	log.Printf("Rows affected: %v", rowsAffected)
+	rows, err := r.database.QueryContext(ctx, r.query(QueryGetTrash), limit, offset)
+	if err != nil {
+		log.Printf("Unable to get the trash: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tempBook entity.DeletedBook
+
+		err = rows.Scan(&tempBook.ID, &tempBook.Title, &tempBook.Author, &tempBook.Year, &tempBook.Description,
+			&tempBook.ISBN, &tempBook.DeletedAt)
+		if err != nil {
+			log.Printf("Unable to scan the book: %v", err)
+			continue
+		}
+
+		books = append(books, tempBook)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return books, total, nil
+}
+
+// RestoreBook takes the book out of the trash. Restoring counts as a change, so the version of the book is bumped
+func (r *BookDBRepository) RestoreBook(ctx context.Context, bookID uint64) (*entity.VersionedBook, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	var book entity.VersionedBook
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		err := repo.database.QueryRowContext(ctx, repo.query(QueryRestoreBook), bookID).Scan(&book.ID, &book.Title,
+			&book.Author, &book.Year, &book.Description, &book.ISBN, &book.Version)
+
+		if err == sql.ErrNoRows {
+			return repo.restoreError(ctx, bookID)
+		} else if err != nil {
+			log.Printf("Unable to restore book: %v", err)
+			return queryError(ctx, err)
+		}
+
+		return repo.recordChange(ctx, bookID, entity.BookActionRestore, nil, &book.Book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
+}
+
+// PurgeBooks removes for good the books deleted before the given time
+func (r *BookDBRepository) PurgeBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var rowsAffected int64
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		if err := repo.recordChanges(ctx, QueryAuditPurgeBooks, entity.BookActionPurge, deletedBefore.UTC()); err != nil {
+			return err
+		}
+
+		res, err := repo.database.ExecContext(ctx, repo.query(QueryPurgeBooks), deletedBefore.UTC())
+
+		if err != nil {
+			log.Printf("Unable to purge books: %v", err)
+			return queryError(ctx, err)
+		}
+
+		rowsAffected, err = res.RowsAffected()
+
+		if err != nil {
+			log.Printf("Unable to get affected rows book: %v", err)
+			return queryError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if rowsAffected > 0 {
+		log.Printf("Purged rows: %v", rowsAffected)
+	}
 
-	return rowsAffected, err
+	return rowsAffected, nil
 }
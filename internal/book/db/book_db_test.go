@@ -1,18 +1,24 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	goerrors "errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	authorEntity "github.com/foxfurry/simple-rest/internal/author/domain/entity"
 	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/errors"
 	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
 	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
 	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"log"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func newMock() (*sql.DB, sqlmock.Sqlmock) {
@@ -24,6 +30,32 @@ func newMock() (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// expectBookForUpdate expects the live book with bookID to be locked, finding book if it is not nil
+func expectBookForUpdate(mock sqlmock.Sqlmock, bookID uint64, book *entity.Book) {
+	rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+	if book != nil {
+		rows.AddRow(book.ID, book.Title, book.Author, book.Year, book.Description, book.ISBN)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookForUpdate)).WithArgs(bookID).WillReturnRows(rows)
+}
+
+// expectAudit expects the audit record of a mutation of book bookID, made by an anonymous actor out of any request
+func expectAudit(mock sqlmock.Sqlmock, bookID uint64, action string) {
+	mock.ExpectExec(regexp.QuoteMeta(QueryInsertBookAudit)).
+		WithArgs(bookID, action, sqlmock.AnyArg(), sqlmock.AnyArg(), common_request.AnonymousActor, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectLinkAuthors expects authors with names to be saved and linked to book bookID in the given order. Authors get
+// ids counting from 1
+func expectLinkAuthors(mock sqlmock.Sqlmock, bookID uint64, names ...string) {
+	for idx, name := range names {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryUpsertBookAuthor)).WithArgs(name, authorEntity.AuthorNameKey(name)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(idx + 1))
+		mock.ExpectExec(regexp.QuoteMeta(QueryLinkBookAuthor)).WithArgs(bookID, idx+1, idx).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
 func TestBookDBRepository_SaveBook(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
@@ -56,7 +88,39 @@ func TestBookDBRepository_SaveBook(t *testing.T) {
 			expectedError: nil,
 			mockFunc: func() {
 				rows := mock.NewRows([]string{"id"}).AddRow(1)
-				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "test description").WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "test description", "").WillReturnRows(rows)
+				expectLinkAuthors(mock, 1, "test author")
+				expectAudit(mock, 1, entity.BookActionCreate)
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+		},
+		{
+			testName:       "Test Successful: Co-authors",
+			input:          entity.Book{Title: "Good Omens", Author: "Terry Pratchett & Neil Gaiman", Year: 1990},
+			expectedOutput: entity.Book{ID: 2, Title: "Good Omens", Author: "Terry Pratchett & Neil Gaiman", Year: 1990},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("Good Omens", "Terry Pratchett & Neil Gaiman", 1990, "", "").
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(2))
+				expectLinkAuthors(mock, 2, "Terry Pratchett", "Neil Gaiman")
+				expectAudit(mock, 2, entity.BookActionCreate)
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+		},
+		{
+			testName:      "Test Unsuccessful: Author cannot be linked",
+			input:         entity.Book{Title: "test title", Author: "test author", Year: 1},
+			expectedError: errors.NewBookCouldNotQuery("failure"),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "", "").
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpsertBookAuthor)).WithArgs("test author", "test author").
+					WillReturnError(goerrors.New("failure"))
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 		},
@@ -72,7 +136,26 @@ func TestBookDBRepository_SaveBook(t *testing.T) {
 			expectedError:  errors.NewBookCouldNotQuery("sql: no rows in result set"),
 			mockFunc: func() {
 				rows := mock.NewRows([]string{"id"})
-				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "test description").WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "test description", "").WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+		},
+		{
+			testName: "Test Unsuccessful: ISBN already exists",
+			input: entity.Book{
+				Title:  "test title",
+				Author: "test author",
+				Year:   1,
+				ISBN:   "0-306-40615-2",
+			},
+			expectedOutput: entity.Book{},
+			expectedError:  errors.NewBookISBNAlreadyExists("9780306406157"),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WithArgs("test title", "test author", 1, "", "9780306406157").WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 		},
@@ -92,7 +175,7 @@ func TestBookDBRepository_SaveBook(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.SaveBook(&test.input)
+			res, err := test.mockRepo.SaveBook(context.Background(), &test.input)
 			if (err != nil) && (err != test.expectedError) {
 				t.Errorf("Unexpected error:\nExpected: %v\nActual: %v", test.expectedError, err)
 				return
@@ -111,7 +194,7 @@ func TestBookDBRepository_GetBook(t *testing.T) {
 
 	getBookMocks := []struct {
 		testName       string
-		expectedOutput entity.Book
+		expectedOutput entity.VersionedBook
 		expectedError  error
 		mockFunc       func()
 		mockRepo       BookDBRepository
@@ -119,16 +202,19 @@ func TestBookDBRepository_GetBook(t *testing.T) {
 	}{
 		{
 			testName: "Test Successful",
-			expectedOutput: entity.Book{
-				ID:          1,
-				Title:       "test title",
-				Author:      "test author",
-				Year:        1,
-				Description: "test description",
+			expectedOutput: entity.VersionedBook{
+				Book: entity.Book{
+					ID:          1,
+					Title:       "test title",
+					Author:      "test author",
+					Year:        1,
+					Description: "test description",
+				},
+				Version: 2,
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).AddRow(1, "test title", "test author", 1, "test description")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "test title", "test author", 1, "test description", "", 2)
 				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBook)).WithArgs(1).WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -136,10 +222,10 @@ func TestBookDBRepository_GetBook(t *testing.T) {
 		},
 		{
 			testName:       "Test Unsuccessful: Book not found",
-			expectedOutput: entity.Book{},
+			expectedOutput: entity.VersionedBook{},
 			expectedError:  errors.NewBooksNotFound(),
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"})
 				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBook)).WithArgs(2).WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -147,7 +233,7 @@ func TestBookDBRepository_GetBook(t *testing.T) {
 		},
 		{
 			testName:       "Test Unsuccessful: Invalid serial",
-			expectedOutput: entity.Book{},
+			expectedOutput: entity.VersionedBook{},
 			expectedError:  errors.NewBookInvalidSerial(),
 			mockRepo: repo,
 			getID:    0,
@@ -169,11 +255,78 @@ func TestBookDBRepository_GetBook(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.GetBook(test.getID)
+			res, err := test.mockRepo.GetBook(context.Background(), test.getID)
 			if (err != nil) && (err != test.expectedError) {
 				t.Errorf("Unexpected error:\nExpected: %v\nActual: %v", test.expectedError, err)
 				return
-			} else if (err == nil) && !test.expectedOutput.Equal(*res) {
+			} else if (err == nil) && test.expectedOutput != *res {
+				t.Errorf("Unexpected result:\nExpected: %v\nActual: %v", test.expectedOutput, res)
+			}
+		})
+	}
+}
+
+func TestBookDBRepository_GetBookByISBN(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	getBookMocks := []struct {
+		testName       string
+		expectedOutput entity.VersionedBook
+		expectedError  error
+		mockFunc       func()
+		isbn           string
+	}{
+		{
+			testName: "Test Successful",
+			expectedOutput: entity.VersionedBook{
+				Book: entity.Book{
+					ID:     1,
+					Title:  "test title",
+					Author: "test author",
+					Year:   1,
+					ISBN:   "9780306406157",
+				},
+				Version: 2,
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "test title", "test author", 1, "", "9780306406157", 2)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookByISBN)).WithArgs("9780306406157").WillReturnRows(rows)
+			},
+			isbn: "9780306406157",
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			expectedError: errors.NewBookNotFoundByISBN("9780804429573"),
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookByISBN)).WithArgs("9780804429573").WillReturnRows(rows)
+			},
+			isbn: "9780804429573",
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			isbn: "9780306406157",
+		},
+	}
+
+	for _, test := range getBookMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
+			}
+
+			res, err := repo.GetBookByISBN(context.Background(), test.isbn)
+			if (err != nil) && (err != test.expectedError) {
+				t.Errorf("Unexpected error:\nExpected: %v\nActual: %v", test.expectedError, err)
+				return
+			} else if (err == nil) && test.expectedOutput != *res {
 				t.Errorf("Unexpected result:\nExpected: %v\nActual: %v", test.expectedOutput, res)
 			}
 		})
@@ -220,10 +373,10 @@ func TestBookDBRepository_GetAllBooks(t *testing.T) {
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "test title 1", "test author 1", 1, "test description 1").
-					AddRow(2, "test title 2", "test author 2", 2, "test description 2").
-					AddRow(3, "test title 3", "test author 3", 3, "test description 3")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author 1", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author 2", 2, "test description 2", "").
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "")
 				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAll)).WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -248,10 +401,10 @@ func TestBookDBRepository_GetAllBooks(t *testing.T) {
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "test title 1", "test author 1", 1, "test description 1").
-					AddRow(2, "test title 2", "test author 2", "error", "test description 2").
-					AddRow(3, "test title 3", "test author 3", 3, "test description 3")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author 1", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author 2", "error", "test description 2", "").
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "")
 				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAll)).WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -283,7 +436,7 @@ func TestBookDBRepository_GetAllBooks(t *testing.T) {
 			},
 			expectedError: errors.NewBooksNotFound(),
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
 				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAll)).WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -304,7 +457,7 @@ func TestBookDBRepository_GetAllBooks(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.GetAllBooks()
+			res, err := test.mockRepo.GetAllBooks(context.Background())
 			if (err != nil) && (err != test.expectedError) {
 				t.Errorf("Unexpected error:\nExpected: %v\nActual: %v", test.expectedError, err)
 				return
@@ -363,11 +516,11 @@ func TestBookDBRepository_SearchByAuthor(t *testing.T) {
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "test title 1", "test author", 1, "test description 1").
-					AddRow(2, "test title 2", "test author", 2, "test description 2").
-					AddRow(3, "test title 3", "test author", 3, "test description 3").
-					AddRow(5, "test title 5", "test author", 5, "test description 5")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author", 2, "test description 2", "").
+					AddRow(3, "test title 3", "test author", 3, "test description 3", "").
+					AddRow(5, "test title 5", "test author", 5, "test description 5", "")
 				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByAuthorBook)).WithArgs("test author").WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -407,12 +560,12 @@ func TestBookDBRepository_SearchByAuthor(t *testing.T) {
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "test title 1", "test author", 1, "test description 1").
-					AddRow(2, "test title 2", "test author", 2, "test description 2").
-					AddRow(3, "test title 3", "test author", 3, "test description 3").
-					AddRow(4, "test title 5", "test author", "error", "test description 4").
-					AddRow(5, "test title 5", "test author", 5, "test description 5")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author", 2, "test description 2", "").
+					AddRow(3, "test title 3", "test author", 3, "test description 3", "").
+					AddRow(4, "test title 5", "test author", "error", "test description 4", "").
+					AddRow(5, "test title 5", "test author", 5, "test description 5", "")
 				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByAuthorBook)).WithArgs("test author").WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -427,7 +580,7 @@ func TestBookDBRepository_SearchByAuthor(t *testing.T) {
 			testName:      "Test Unsuccessful: Books not found",
 			expectedError: errors.NewBookNotFoundByAuthor("test author"),
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
 				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByAuthorBook)).WithArgs("test author").WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -450,7 +603,7 @@ func TestBookDBRepository_SearchByAuthor(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.SearchByAuthor(test.author)
+			res, err := test.mockRepo.SearchByAuthor(context.Background(), test.author)
 
 			if test.expectedError != nil {
 				assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
@@ -487,8 +640,8 @@ func TestBookDBRepository_SearchByTitle(t *testing.T) {
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "test title", "test author", 1, "test description 1")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title", "test author", 1, "test description 1", "")
 				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByTitleBook)).WithArgs("test title").WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -503,7 +656,7 @@ func TestBookDBRepository_SearchByTitle(t *testing.T) {
 			testName:      "Test Unsuccessful: No books found",
 			expectedError: errors.NewBookNotFoundByTitle("test title"),
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
 				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByTitleBook)).WithArgs("test title").WillReturnRows(rows)
 			},
 			mockRepo: repo,
@@ -526,7 +679,7 @@ func TestBookDBRepository_SearchByTitle(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.SearchByTitle(test.title)
+			res, err := test.mockRepo.SearchByTitle(context.Background(), test.title)
 
 			if test.expectedError != nil {
 				assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
@@ -547,7 +700,8 @@ func TestBookDBRepository_UpdateBook(t *testing.T) {
 	updateBookMocks := []struct {
 		testName       string
 		input          *entity.Book
-		expectedOutput *entity.Book
+		version        uint64
+		expectedOutput *entity.VersionedBook
 		expectedError  error
 		mockFunc       func()
 		mockRepo       BookDBRepository
@@ -561,29 +715,85 @@ func TestBookDBRepository_UpdateBook(t *testing.T) {
 				Year:        2,
 				Description: "test description 2",
 			},
-			expectedOutput: &entity.Book{
-				ID:          3,
-				Title:       "test title 2",
-				Author:      "test author 2",
-				Year:        2,
-				Description: "test description 2",
+			version: 1,
+			expectedOutput: &entity.VersionedBook{
+				Book: entity.Book{
+					ID:          3,
+					Title:       "test title 2",
+					Author:      "test author 2",
+					Year:        2,
+					Description: "test description 2",
+				},
+				Version: 2,
 			},
 			expectedError: nil,
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryUpdateBook)).WithArgs(3, "test title 2", "test author 2", 2, "test description 2").WillReturnResult(sqlmock.NewResult(0, 1))
+				rows := sqlmock.NewRows([]string{"version"}).AddRow(2)
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 3, &entity.Book{ID: 3, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpdateBook)).WithArgs(3, "test title 2", "test author 2", 2, "test description 2", "", 1).WillReturnRows(rows)
+				mock.ExpectExec(regexp.QuoteMeta(QueryUnlinkBookAuthors)).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectLinkAuthors(mock, 3, "test author 2")
+				expectAudit(mock, 3, entity.BookActionUpdate)
+				mock.ExpectCommit()
 			},
 			mockRepo: repo,
 			id:       3,
 		},
 		{
-			testName:       "Test Unsuccessful: Invalid serial",
-			expectedError:  errors.NewBookInvalidSerial(),
+			testName: "Test Successful: Same author keeps the authors",
+			input:    &entity.Book{Title: "test title 2", Author: "test author", Year: 2},
+			expectedOutput: &entity.VersionedBook{
+				Book:    entity.Book{ID: 3, Title: "test title 2", Author: "test author", Year: 2},
+				Version: 3,
+			},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 3, &entity.Book{ID: 3, Title: "test title", Author: "test author", Year: 1})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpdateBook)).WithArgs(3, "test title 2", "test author", 2, "", "", 0).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+				expectAudit(mock, 3, entity.BookActionUpdate)
+				mock.ExpectCommit()
+			},
 			mockRepo: repo,
-			id:    0,
+			id:       3,
+		},
+		{
+			testName:      "Test Unsuccessful: Stale version",
+			input:         &entity.Book{Title: "test title 2", Author: "test author 2", Year: 2},
+			version:       1,
+			expectedError: errors.NewBookVersionMismatch(4),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 3, &entity.Book{ID: 3, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpdateBook)).WithArgs(3, "test title 2", "test author 2", 2, "", "", 1).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookVersion)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       3,
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			input:         &entity.Book{Title: "test title 2", Author: "test author 2", Year: 2},
+			expectedError: errors.NewBooksNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 5, nil)
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       5,
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			expectedError: errors.NewBookInvalidSerial(),
+			mockRepo:      repo,
+			id:            0,
 		},
 		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
 			testName:      "Test Unsuccessful: DB is closed",
-			input: &entity.Book{},
+			input:         &entity.Book{},
 			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
 			mockFunc: func() {
 				db.Close()
@@ -599,14 +809,115 @@ func TestBookDBRepository_UpdateBook(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.UpdateBook(test.id, test.input)
+			res, err := test.mockRepo.UpdateBook(context.Background(), test.id, test.version, test.input)
 
-			if test.expectedError != nil {
-				assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
-			}
-			if test.expectedOutput != nil {
-				assert.True(t, test.expectedOutput.Equal(*res))
+			assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookDBRepository_PatchBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	title := "test title 2"
+	year := 2
+	patchQuery := `UPDATE bookstore SET title=$2, year=$3, version=version+1 WHERE id=$1 AND deleted_at IS NULL AND ($4=0 OR version=$4) RETURNING id, title, author, year, description, isbn, version`
+	columns := []string{"id", "title", "author", "year", "description", "isbn", "version"}
+
+	patchBookMocks := []struct {
+		testName       string
+		patch          entity.BookPatch
+		version        uint64
+		expectedOutput *entity.VersionedBook
+		expectedError  error
+		mockFunc       func()
+		mockRepo       BookDBRepository
+		id             uint64
+	}{
+		{
+			testName: "Test Successful",
+			patch:    entity.BookPatch{Title: &title, Year: &year},
+			version:  1,
+			expectedOutput: &entity.VersionedBook{
+				Book: entity.Book{
+					ID:          3,
+					Title:       "test title 2",
+					Author:      "test author",
+					Year:        2,
+					Description: "test description",
+				},
+				Version: 2,
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows(columns).AddRow(3, "test title 2", "test author", 2, "test description", "", 2)
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 3, &entity.Book{ID: 3, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectQuery(regexp.QuoteMeta(patchQuery)).WithArgs(3, "test title 2", 2, 1).WillReturnRows(rows)
+				expectAudit(mock, 3, entity.BookActionPatch)
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+			id:       3,
+		},
+		{
+			testName:      "Test Unsuccessful: Stale version",
+			patch:         entity.BookPatch{Title: &title, Year: &year},
+			version:       1,
+			expectedError: errors.NewBookVersionMismatch(4),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 3, &entity.Book{ID: 3, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectQuery(regexp.QuoteMeta(patchQuery)).WithArgs(3, "test title 2", 2, 1).WillReturnRows(sqlmock.NewRows(columns))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookVersion)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       3,
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			patch:         entity.BookPatch{Title: &title, Year: &year},
+			expectedError: errors.NewBooksNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 5, nil)
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       5,
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			expectedError: errors.NewBookInvalidSerial(),
+			mockRepo:      repo,
+			id:            0,
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			patch:         entity.BookPatch{Title: &title},
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			mockRepo: repo,
+			id:       1,
+		},
+	}
+
+	for _, test := range patchBookMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
 			}
+
+			res, err := test.mockRepo.PatchBook(context.Background(), test.id, test.version, test.patch)
+
+			assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
 		})
 	}
 }
@@ -630,7 +941,11 @@ func TestBookDBRepository_DeleteBook(t *testing.T) {
 			expectedOutput: 1,
 			expectedError:  nil,
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 1, &entity.Book{ID: 1, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, 1, entity.BookActionDelete)
+				mock.ExpectCommit()
 			},
 			mockRepo: repo,
 			id:       1,
@@ -639,7 +954,9 @@ func TestBookDBRepository_DeleteBook(t *testing.T) {
 			testName:      "Test Unsuccessful: Book not found",
 			expectedError: errors.NewBooksNotFound(),
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 1, nil)
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 			id:       1,
@@ -648,7 +965,10 @@ func TestBookDBRepository_DeleteBook(t *testing.T) {
 			testName:      "Test Unsuccessful: Invalid rows affected",
 			expectedError: errors.NewBookCouldNotQuery("no RowsAffected available after DDL statement"),
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(1).WillReturnResult(sqlmock.NewErrorResult(goerrors.New("no RowsAffected available after DDL statement")))
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 1, &entity.Book{ID: 1, Title: "test title", Author: "test author", Year: 1, Description: "test description"})
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewErrorResult(goerrors.New("no RowsAffected available after DDL statement")))
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 			id:       1,
@@ -676,7 +996,7 @@ func TestBookDBRepository_DeleteBook(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.DeleteBook(test.id)
+			res, err := test.mockRepo.DeleteBook(context.Background(), test.id)
 			if test.expectedError != nil {
 				assert.Equal(t, test.expectedError, err, "Values are not equal:\nExpected: %+v\nActual: %+v", test.expectedError, err)
 			}
@@ -703,7 +1023,12 @@ func TestBookDBRepository_DeleteAllBooks(t *testing.T) {
 			expectedOutput: 4,
 			expectedError:  nil,
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooksAndAlter)).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(QueryAuditDeleteAllBooks)).
+					WithArgs(entity.BookActionDelete, common_request.AnonymousActor, "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectCommit()
 			},
 			mockRepo: repo,
 		},
@@ -712,7 +1037,12 @@ func TestBookDBRepository_DeleteAllBooks(t *testing.T) {
 			expectedOutput: 0,
 			expectedError:  errors.NewBookCouldNotQuery("no RowsAffected available after DDL statement"),
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooksAndAlter)).WillReturnResult(sqlmock.NewErrorResult(goerrors.New("no RowsAffected available after DDL statement")))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(QueryAuditDeleteAllBooks)).
+					WithArgs(entity.BookActionDelete, common_request.AnonymousActor, "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewErrorResult(goerrors.New("no RowsAffected available after DDL statement")))
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 		},
@@ -721,7 +1051,12 @@ func TestBookDBRepository_DeleteAllBooks(t *testing.T) {
 			expectedOutput: 0,
 			expectedError:  errors.NewBooksNotFound(),
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooksAndAlter)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(QueryAuditDeleteAllBooks)).
+					WithArgs(entity.BookActionDelete, common_request.AnonymousActor, "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
 			},
 			mockRepo: repo,
 		},
@@ -741,7 +1076,7 @@ func TestBookDBRepository_DeleteAllBooks(t *testing.T) {
 				test.mockFunc()
 			}
 
-			res, err := test.mockRepo.DeleteAllBooks()
+			res, err := test.mockRepo.DeleteAllBooks(context.Background())
 			if (err != nil) && (err != test.expectedError) {
 				t.Errorf("Unexpected error:\nExpected: %v\nActual: %v", test.expectedError, err)
 				return
@@ -751,3 +1086,922 @@ func TestBookDBRepository_DeleteAllBooks(t *testing.T) {
 		})
 	}
 }
+
+func TestBookDBRepository_GetTrash(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	deletedAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	getTrashMocks := []struct {
+		testName       string
+		expectedOutput []entity.DeletedBook
+		expectedTotal  int64
+		expectedError  error
+		mockFunc       func()
+		mockRepo       BookDBRepository
+	}{
+		{
+			testName: "Test Successful",
+			expectedOutput: []entity.DeletedBook{
+				{
+					Book:      entity.Book{ID: 3, Title: "test title 3", Author: "test author 3", Year: 3, Description: "test description 3"},
+					DeletedAt: deletedAt,
+				},
+			},
+			expectedTotal: 4,
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryCountTrash)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "deleted_at"}).
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "", deletedAt)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetTrash)).WithArgs(1, 2).WillReturnRows(rows)
+			},
+			mockRepo: repo,
+		},
+		{
+			testName:       "Test Successful: Empty trash",
+			expectedOutput: []entity.DeletedBook{},
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryCountTrash)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			mockRepo: repo,
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			mockRepo: repo,
+		},
+	}
+
+	for _, test := range getTrashMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
+			}
+
+			res, total, err := test.mockRepo.GetTrash(context.Background(), 1, 2)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+			assert.Equal(t, test.expectedTotal, total)
+		})
+	}
+}
+
+func TestBookDBRepository_RestoreBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	restoreBookMocks := []struct {
+		testName       string
+		expectedOutput *entity.VersionedBook
+		expectedError  error
+		mockFunc       func()
+		mockRepo       BookDBRepository
+		id             uint64
+	}{
+		{
+			testName: "Test Successful",
+			expectedOutput: &entity.VersionedBook{
+				Book:    entity.Book{ID: 3, Title: "test title 3", Author: "test author 3", Year: 3, Description: "test description 3"},
+				Version: 2,
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "", 2)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryRestoreBook)).WithArgs(3).WillReturnRows(rows)
+				expectAudit(mock, 3, entity.BookActionRestore)
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+			id:       3,
+		},
+		{
+			testName:      "Test Unsuccessful: Book not in the trash",
+			expectedError: errors.NewBooksNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryRestoreBook)).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetTrashedBookISBN)).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"isbn"}))
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       5,
+		},
+		{
+			testName:      "Test Unsuccessful: ISBN taken by another book",
+			expectedError: errors.NewBookISBNAlreadyExists("9780306406157"),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryRestoreBook)).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetTrashedBookISBN)).WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"isbn"}).AddRow("9780306406157"))
+				mock.ExpectRollback()
+			},
+			mockRepo: repo,
+			id:       4,
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			expectedError: errors.NewBookInvalidSerial(),
+			mockRepo:      repo,
+			id:            0,
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			mockRepo: repo,
+			id:       1,
+		},
+	}
+
+	for _, test := range restoreBookMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
+			}
+
+			res, err := test.mockRepo.RestoreBook(context.Background(), test.id)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookDBRepository_PurgeBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	deletedBefore := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	purgeBooksMocks := []struct {
+		testName       string
+		expectedOutput int64
+		expectedError  error
+		mockFunc       func()
+		mockRepo       BookDBRepository
+	}{
+		{
+			testName:       "Test Successful",
+			expectedOutput: 2,
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(QueryAuditPurgeBooks)).
+					WithArgs(entity.BookActionPurge, common_request.AnonymousActor, "", sqlmock.AnyArg(), deletedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(QueryPurgeBooks)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+		},
+		{
+			testName:       "Test Successful: Nothing to purge",
+			expectedOutput: 0,
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(QueryAuditPurgeBooks)).
+					WithArgs(entity.BookActionPurge, common_request.AnonymousActor, "", sqlmock.AnyArg(), deletedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(QueryPurgeBooks)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			mockRepo: repo,
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			mockRepo: repo,
+		},
+	}
+
+	for _, test := range purgeBooksMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
+			}
+
+			res, err := test.mockRepo.PurgeBooks(context.Background(), deletedBefore)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookDBRepository_GetAudit(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	createdAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	since := createdAt.Add(-time.Hour)
+	query := repository.BookAuditQuery{BookID: 3, Action: entity.BookActionUpdate, Since: &since, Limit: 2, Offset: 1}
+
+	countQuery := `SELECT COUNT(*) FROM book_audit WHERE book_id=$1 AND action=$2 AND created_at >= $3`
+	pageQuery := `SELECT id, book_id, action, old_value, new_value, actor, request_id, created_at FROM book_audit
+		WHERE book_id=$1 AND action=$2 AND created_at >= $3 ORDER BY created_at DESC, id DESC LIMIT $4 OFFSET $5`
+
+	getAuditMocks := []struct {
+		testName       string
+		expectedOutput []entity.BookChange
+		expectedTotal  int64
+		expectedError  error
+		mockFunc       func()
+		mockRepo       BookDBRepository
+	}{
+		{
+			testName: "Test Successful",
+			expectedOutput: []entity.BookChange{
+				{
+					ID:        7,
+					BookID:    3,
+					Action:    entity.BookActionUpdate,
+					OldValue:  &entity.Book{ID: 3, Title: "test title 3", Author: "test author 3", Year: 3},
+					NewValue:  &entity.Book{ID: 3, Title: "test title 3", Author: "test author 3", Year: 4},
+					Actor:     "librarian",
+					RequestID: "request-1",
+					CreatedAt: createdAt,
+				},
+			},
+			expectedTotal: 2,
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(countQuery)).WithArgs(3, entity.BookActionUpdate, since).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				rows := sqlmock.NewRows([]string{"id", "book_id", "action", "old_value", "new_value", "actor", "request_id", "created_at"}).
+					AddRow(7, 3, entity.BookActionUpdate,
+						`{"id": 3, "title": "test title 3", "author": "test author 3", "year": 3, "description": ""}`,
+						`{"id": 3, "title": "test title 3", "author": "test author 3", "year": 4, "description": ""}`,
+						"librarian", "request-1", createdAt)
+				mock.ExpectQuery(regexp.QuoteMeta(pageQuery)).WithArgs(3, entity.BookActionUpdate, since, 2, 1).WillReturnRows(rows)
+			},
+			mockRepo: repo,
+		},
+		{
+			testName:       "Test Successful: No records",
+			expectedOutput: []entity.BookChange{},
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(countQuery)).WithArgs(3, entity.BookActionUpdate, since).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			mockRepo: repo,
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+			mockRepo: repo,
+		},
+	}
+
+	for _, test := range getAuditMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			if test.mockFunc != nil {
+				test.mockFunc()
+			}
+
+			res, total, err := test.mockRepo.GetAudit(context.Background(), query)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+			assert.Equal(t, test.expectedTotal, total)
+		})
+	}
+}
+
+func TestBookDBRepository_SearchByKeyword(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	searchByKeywordMocks := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    "mars",
+			expectedOutput: []entity.Book{
+				{
+					ID:          1,
+					Title:       "The Martian Chronicles",
+					Author:      "Ray Bradbury",
+					Year:        1950,
+					Description: "test description",
+				},
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "The Martian Chronicles", "Ray Bradbury", 1950, "test description", "")
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordBook)).WithArgs("%mars%", 5).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Nothing found",
+			input:          "100%",
+			expectedOutput: []entity.Book{},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordBook)).WithArgs(`%100\%%`, 5).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: DB is closed",
+			input:         "mars",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range searchByKeywordMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, err := repo.SearchByKeyword(context.Background(), test.input, 5)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookDBRepository_FullTextSearch(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	fullTextSearchMocks := []struct {
+		testName       string
+		input          string
+		offset         int
+		expectedOutput []entity.BookMatch
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    "martian",
+			expectedOutput: []entity.BookMatch{
+				{
+					Book: entity.Book{
+						ID:          1,
+						Title:       "The Martian Chronicles",
+						Author:      "Ray Bradbury",
+						Year:        1950,
+						Description: "test description",
+					},
+					Rank:     0.6,
+					Headline: "The <b>Martian</b> Chronicles test description",
+				},
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "rank", "headline"}).
+					AddRow(1, "The Martian Chronicles", "Ray Bradbury", 1950, "test description", "", 0.6, "The <b>Martian</b> Chronicles test description")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryFullTextSearchBook)).WithArgs("martian", 5, 0).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Past the last match",
+			input:          "martian",
+			offset:         10,
+			expectedOutput: []entity.BookMatch{},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "rank", "headline"})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryFullTextSearchBook)).WithArgs("martian", 5, 10).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Nothing matches",
+			input:         "venusian",
+			expectedError: errors.NewBookNothingMatches("venusian"),
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "rank", "headline"})
+				mock.ExpectQuery(regexp.QuoteMeta(QueryFullTextSearchBook)).WithArgs("venusian", 5, 0).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: DB is closed",
+			input:         "martian",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range fullTextSearchMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, err := repo.FullTextSearch(context.Background(), test.input, 5, test.offset)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookDBRepository_SearchSimilar(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	searchSimilarMocks := []struct {
+		testName       string
+		field          string
+		value          string
+		expectedOutput []entity.BookCandidate
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful: Author",
+			field:    "author",
+			value:    "Ray Bradberry",
+			expectedOutput: []entity.BookCandidate{
+				{
+					Book: entity.Book{
+						ID:          1,
+						Title:       "The Martian Chronicles",
+						Author:      "Ray Bradbury",
+						Year:        1950,
+						Description: "test description",
+					},
+					Similarity: 0.625,
+				},
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "score"}).
+					AddRow(1, "The Martian Chronicles", "Ray Bradbury", 1950, "test description", "", 0.625)
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySimilarByAuthorBook)).WithArgs("Ray Bradberry", 0.3, 5).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Title, nothing similar",
+			field:          "title",
+			value:          "Solaris",
+			expectedOutput: []entity.BookCandidate{},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "score"})
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySimilarByTitleBook)).WithArgs("Solaris", 0.3, 5).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Unknown field",
+			field:         "description",
+			value:         "test",
+			expectedError: errors.NewBookUnexpectedError("books cannot be compared by description"),
+			mockFunc:      func() {},
+		},
+		{
+			testName:      "Test Unsuccessful: DB is closed",
+			field:         "author",
+			value:         "Ray Bradberry",
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range searchSimilarMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, err := repo.SearchSimilar(context.Background(), test.field, test.value, 0.3, 5)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookDBRepository_GetBooksPage(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	getBooksPageMocks := []struct {
+		testName       string
+		query          repository.BookListQuery
+		expectedOutput []entity.Book
+		expectedTotal  int64
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			query:    repository.BookListQuery{Limit: 2, Offset: 2},
+			expectedOutput: []entity.Book{
+				{
+					ID:          3,
+					Title:       "test title 3",
+					Author:      "test author 3",
+					Year:        3,
+					Description: "test description 3",
+				},
+				{
+					ID:          4,
+					Title:       "test title 4",
+					Author:      "test author 4",
+					Year:        4,
+					Description: "test description 4",
+				},
+			},
+			expectedTotal: 5,
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "").
+					AddRow(4, "test title 4", "test author 4", 4, "test description 4", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`)).WithArgs(2, 2).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Past the last page",
+			query:          repository.BookListQuery{Limit: 2, Offset: 10},
+			expectedOutput: []entity.Book{},
+			expectedTotal:  5,
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`)).WithArgs(2, 10).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Books not found",
+			query:         repository.BookListQuery{Limit: 2, Offset: 0},
+			expectedError: errors.NewBooksNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+		{
+			testName: "Test Successful: Every tag",
+			query:    repository.BookListQuery{Tags: []string{"fantasy", "classic"}, AllTags: true, Limit: 2},
+			expectedOutput: []entity.Book{
+				{
+					ID:          3,
+					Title:       "test title 3",
+					Author:      "test author 3",
+					Year:        3,
+					Description: "test description 3",
+				},
+			},
+			expectedTotal: 1,
+			mockFunc: func() {
+				tagged := `id IN (SELECT bt.book_id FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name IN ($1, $2) GROUP BY bt.book_id HAVING COUNT(*) = $3)`
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL AND `+tagged)).
+					WithArgs("fantasy", "classic", 2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND `+tagged+` ORDER BY id LIMIT $4 OFFSET $5`)).
+					WithArgs("fantasy", "classic", 2, 2, 0).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: DB is closed",
+			query:         repository.BookListQuery{Limit: 2, Offset: 0},
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range getBooksPageMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, total, err := repo.GetBooksPage(context.Background(), test.query)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+			assert.Equal(t, test.expectedTotal, total)
+		})
+	}
+}
+
+func TestBookDBRepository_GetBooksAfter(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	yearDesc := []repository.SortField{{Column: "year", Desc: true}}
+
+	getBooksAfterMocks := []struct {
+		testName       string
+		query          repository.BookListQuery
+		expectedOutput []entity.Book
+		expectedNext   *repository.Keyset
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful: First page",
+			query:    repository.BookListQuery{Sort: yearDesc, Limit: 1},
+			expectedOutput: []entity.Book{
+				{
+					ID:          4,
+					Title:       "test title 4",
+					Author:      "test author 4",
+					Year:        4,
+					Description: "test description 4",
+				},
+			},
+			expectedNext: &repository.Keyset{Values: []string{"4"}, ID: 4},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(4, "test title 4", "test author 4", 4, "test description 4", "").
+					AddRow(3, "test title 3", "test author 3", 3, "test description 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY year DESC, id LIMIT $1`)).WithArgs(2).WillReturnRows(rows)
+			},
+		},
+		{
+			testName: "Test Successful: Last page of author",
+			query: repository.BookListQuery{
+				Author: "test author",
+				Sort:   yearDesc,
+				Limit:  2,
+				After:  &repository.Keyset{Values: []string{"4"}, ID: 4},
+			},
+			expectedOutput: []entity.Book{
+				{
+					ID:          3,
+					Title:       "test title 3",
+					Author:      "test author",
+					Year:        3,
+					Description: "test description 3",
+				},
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "test title 3", "test author", 3, "test description 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=$1) AND ((year < $2) OR (year = $2 AND id > $3)) ORDER BY year DESC, id LIMIT $4`)).
+					WithArgs("test author", "4", 4, 3).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Author not found",
+			query:         repository.BookListQuery{Author: "test author", Limit: 2},
+			expectedError: errors.NewBookNotFoundByAuthor("test author"),
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=$1) ORDER BY id LIMIT $2`)).
+					WithArgs("test author", 3).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: DB is closed",
+			query:         repository.BookListQuery{Limit: 2},
+			expectedError: errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range getBooksAfterMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, next, err := repo.GetBooksAfter(context.Background(), test.query)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+			assert.Equal(t, test.expectedNext, next)
+		})
+	}
+}
+
+func TestBookDBRepository_QueryTimeout(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "test title", "test author", 1, "test description", "", 1)
+	mock.ExpectQuery(regexp.QuoteMeta(QueryGetBook)).WithArgs(1).WillDelayFor(time.Second).WillReturnRows(rows)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := repo.GetBook(ctx, 1)
+	assert.Equal(t, errors.NewBookQueryTimeout(), err)
+	assert.True(t, goerrors.Is(err, context.DeadlineExceeded))
+}
+
+func TestBookDBRepository_ExportBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	yearGTE := 2
+	stop := goerrors.New("stop")
+
+	exportBooksMocks := []struct {
+		testName       string
+		query          repository.BookListQuery
+		each           func(entity.Book) error
+		expectedOutput []entity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			query:    repository.BookListQuery{YearGTE: &yearGTE, Sort: []repository.SortField{{Column: "year", Desc: true}}},
+			expectedOutput: []entity.Book{
+				{ID: 3, Title: "test title 3", Author: "test author", Year: 3, Description: "test description 3"},
+				{ID: 2, Title: "test title 2", Author: "test author", Year: 2, Description: "test description 2"},
+			},
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "test title 3", "test author", 3, "test description 3", "").
+					AddRow(2, "test title 2", "test author", 2, "test description 2", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND year >= $1 ORDER BY year DESC, id`)).WithArgs(2).WillReturnRows(rows)
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Stopped by the callback",
+			each: func(entity.Book) error {
+				return stop
+			},
+			expectedOutput: []entity.Book{},
+			expectedError:  stop,
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author", 2, "test description 2", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id`)).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Rows interrupted",
+			expectedOutput: []entity.Book{{ID: 1, Title: "test title 1", Author: "test author", Year: 1, Description: "test description 1"}},
+			expectedError:  errors.NewBookCouldNotQuery("connection reset"),
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author", 2, "test description 2", "").
+					RowError(1, goerrors.New("connection reset"))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id`)).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Row cannot be scanned",
+			expectedOutput: []entity.Book{{ID: 1, Title: "test title 1", Author: "test author", Year: 1, Description: "test description 1"}},
+			expectedError: errors.NewBookCouldNotQuery(`sql: Scan error on column index 3, name "year": ` +
+				`converting driver.Value type string ("unknown") to a int: invalid syntax`),
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "test title 1", "test author", 1, "test description 1", "").
+					AddRow(2, "test title 2", "test author", "unknown", "test description 2", "").
+					AddRow(3, "test title 3", "test author", 3, "test description 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id`)).WillReturnRows(rows)
+			},
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:       "Test Unsuccessful: DB is closed",
+			expectedOutput: []entity.Book{},
+			expectedError:  errors.NewBookCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, test := range exportBooksMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			exported := []entity.Book{}
+			err := repo.ExportBooks(context.Background(), test.query, func(book entity.Book) error {
+				if test.each != nil {
+					if err := test.each(book); err != nil {
+						return err
+					}
+				}
+				exported = append(exported, book)
+				return nil
+			})
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, exported)
+		})
+	}
+}
+
+func TestBookDBRepository_TagBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	tagBookMocks := []struct {
+		testName       string
+		bookID         uint64
+		tags           []string
+		expectedOutput []string
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			bookID:         1,
+			tags:           []string{"fantasy", "classic"},
+			expectedOutput: []string{"classic", "fantasy", "war"},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 1, &entity.Book{ID: 1, Title: "test title", Author: "test author", Year: 1})
+				for _, tag := range []string{"fantasy", "classic"} {
+					mock.ExpectExec(regexp.QuoteMeta(QueryInsertTag)).WithArgs(tag).WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec(regexp.QuoteMeta(QueryTagBook)).WithArgs(1, tag).WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				rows := sqlmock.NewRows([]string{"name"}).AddRow("classic").AddRow("fantasy").AddRow("war")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookTags)).WithArgs(1).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			bookID:        2,
+			tags:          []string{"fantasy"},
+			expectedError: errors.NewBooksNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectBookForUpdate(mock, 2, nil)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			bookID:        0,
+			tags:          []string{"fantasy"},
+			expectedError: errors.NewBookInvalidSerial(),
+			mockFunc:      func() {},
+		},
+	}
+
+	for _, test := range tagBookMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			res, err := repo.TagBook(context.Background(), test.bookID, test.tags)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookDBRepository_GetBookTags(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewBookRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookTags)).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(nil))
+	tags, err := repo.GetBookTags(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, tags, "Book joined with no tag should have an empty list")
+
+	mock.ExpectQuery(regexp.QuoteMeta(QueryGetBookTags)).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	_, err = repo.GetBookTags(context.Background(), 2)
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
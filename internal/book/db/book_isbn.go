@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"log"
+)
+
+// withCanonicalISBN returns copy of book with its ISBN converted to ISBN-13 without hyphens, the form books are
+// stored and looked up in. ISBN which is not valid is kept as it is, validating books is up to the callers
+func withCanonicalISBN(book entity.Book) entity.Book {
+	if isbn, ok := validators.NormalizeISBN(book.ISBN); ok {
+		book.ISBN = isbn
+	}
+	return book
+}
+
+// patchWithCanonicalISBN works similar to withCanonicalISBN for the ISBN set by the patch
+func patchWithCanonicalISBN(patch entity.BookPatch) entity.BookPatch {
+	if patch.ISBN != nil {
+		if isbn, ok := validators.NormalizeISBN(*patch.ISBN); ok {
+			patch.ISBN = &isbn
+		}
+	}
+	return patch
+}
+
+// writeError works similar to queryError for a query writing a book, reporting ISBN of another book as a conflict
+func writeError(ctx context.Context, err error, isbn string) error {
+	if database.IsUniqueViolation(err) {
+		return errors.NewBookISBNAlreadyExists(isbn)
+	}
+	return queryError(ctx, err)
+}
+
+// GetBookByISBN returns the live book with the ISBN-13, as returned by validators.NormalizeISBN
+func (r *BookDBRepository) GetBookByISBN(ctx context.Context, isbn string) (*entity.VersionedBook, error) {
+	var book entity.VersionedBook
+
+	row := r.database.QueryRowContext(ctx, r.query(QueryGetBookByISBN), isbn)
+
+	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.Description, &book.ISBN, &book.Version)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Book isbn#%v not found", isbn)
+		return nil, errors.NewBookNotFoundByISBN(isbn)
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return &book, nil
+}
+
+// restoreError returns why the book with bookID was not restored: another book having its ISBN, or the book not
+// being in the trash
+func (r *BookDBRepository) restoreError(ctx context.Context, bookID uint64) error {
+	var isbn string
+
+	err := r.database.QueryRowContext(ctx, r.query(QueryGetTrashedBookISBN), bookID).Scan(&isbn)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Book id#%v not found in the trash", bookID)
+		return errors.NewBooksNotFound()
+	} else if err != nil {
+		log.Printf("Unable to restore book: %v", err)
+		return queryError(ctx, err)
+	}
+
+	log.Printf("Book isbn#%v already exists", isbn)
+	return errors.NewBookISBNAlreadyExists(isbn)
+}
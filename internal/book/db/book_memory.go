@@ -0,0 +1,928 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BookMemoryRepository keeps books in memory, so the API can run without a database. It reports the same errors as
+// BookDBRepository and is safe for concurrent use. Postgres specific searches are approximated the same way as on
+// SQLite: full-text search matches books containing every word of the query, similarity works like pg_trgm
+type BookMemoryRepository struct {
+	mu       sync.RWMutex
+	books    map[uint64]entity.Book
+	trash    map[uint64]entity.DeletedBook
+	versions map[uint64]uint64
+	nextID   uint64
+	audit    []entity.BookChange
+	tags     map[uint64]map[string]bool // Tags of live books and books in the trash
+}
+
+func NewBookMemoryRepo() *BookMemoryRepository {
+	return &BookMemoryRepository{
+		books:    make(map[uint64]entity.Book),
+		trash:    make(map[uint64]entity.DeletedBook),
+		versions: make(map[uint64]uint64),
+		nextID:   1,
+		audit:    []entity.BookChange{},
+		tags:     make(map[uint64]map[string]bool),
+	}
+}
+
+var (
+	_ repository.BookRepository = &BookMemoryRepository{}
+	_ repository.BookUnitOfWork = &BookMemoryRepository{}
+)
+
+// Atomically runs fn against a copy of the books, which replaces them only if fn succeeds. Rather than being isolated
+// from each other, every other call waits until fn is done
+func (r *BookMemoryRepository) Atomically(ctx context.Context, fn func(repository.BookRepository) error) error {
+	if err := ctx.Err(); err != nil {
+		return queryError(ctx, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scratch := &BookMemoryRepository{
+		books:    make(map[uint64]entity.Book, len(r.books)),
+		trash:    make(map[uint64]entity.DeletedBook, len(r.trash)),
+		versions: make(map[uint64]uint64, len(r.versions)),
+		nextID:   r.nextID,
+		audit:    r.audit[:len(r.audit):len(r.audit)],
+		tags:     make(map[uint64]map[string]bool, len(r.tags)),
+	}
+	for id, book := range r.books {
+		scratch.books[id] = book
+	}
+	for id, book := range r.trash {
+		scratch.trash[id] = book
+	}
+	for id, version := range r.versions {
+		scratch.versions[id] = version
+	}
+	for id, tags := range r.tags {
+		scratch.tags[id] = make(map[string]bool, len(tags))
+		for tag := range tags {
+			scratch.tags[id][tag] = true
+		}
+	}
+
+	if err := fn(scratch); err != nil {
+		return err
+	}
+
+	r.books, r.trash, r.versions, r.nextID, r.audit = scratch.books, scratch.trash, scratch.versions, scratch.nextID, scratch.audit
+	r.tags = scratch.tags
+
+	return nil
+}
+
+func (r *BookMemoryRepository) SaveBook(ctx context.Context, book *entity.Book) (*entity.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	returnBook := withCanonicalISBN(*book)
+	if r.isbnTaken(returnBook.ISBN, 0) {
+		log.Printf("Book isbn#%v already exists", returnBook.ISBN)
+		return nil, errors.NewBookISBNAlreadyExists(returnBook.ISBN)
+	}
+
+	returnBook.ID = r.nextID
+	r.nextID++
+
+	r.books[returnBook.ID] = returnBook
+	r.versions[returnBook.ID] = 1
+	r.recordChange(ctx, returnBook.ID, entity.BookActionCreate, nil, &returnBook)
+
+	return &returnBook, nil
+}
+
+func (r *BookMemoryRepository) GetBook(ctx context.Context, bookID uint64) (*entity.VersionedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	book, ok := r.books[bookID]
+	if !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	return &entity.VersionedBook{Book: book, Version: r.versions[bookID]}, nil
+}
+
+// GetBookByISBN returns the live book with the ISBN-13, as returned by validators.NormalizeISBN
+func (r *BookMemoryRepository) GetBookByISBN(ctx context.Context, isbn string) (*entity.VersionedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, book := range r.books {
+		if book.ISBN == isbn && isbn != "" {
+			return &entity.VersionedBook{Book: book, Version: r.versions[id]}, nil
+		}
+	}
+
+	log.Printf("Book isbn#%v not found", isbn)
+	return nil, errors.NewBookNotFoundByISBN(isbn)
+}
+
+func (r *BookMemoryRepository) GetAllBooks(ctx context.Context) ([]entity.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	books := r.matching(func(entity.Book) bool { return true })
+
+	if len(books) == 0 {
+		log.Printf("Could not get all the books\n")
+		return nil, errors.NewBooksNotFound()
+	}
+
+	return books, nil
+}
+
+// GetBooksPage returns books of the limit/offset window of query, and the total count of books matching it.
+// Window past the last book is not an error as long as there are books at all
+func (r *BookMemoryRepository) GetBooksPage(ctx context.Context, query repository.BookListQuery) ([]entity.Book, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	books := r.listing(query)
+
+	if len(books) == 0 {
+		log.Printf("Could not get the page of books\n")
+		return nil, 0, notFoundError(query)
+	}
+
+	return window(books, query.Limit, query.Offset), int64(len(books)), nil
+}
+
+// GetBooksAfter returns up to limit books which follow the keyset of query, and the keyset the next page starts
+// after. Next keyset is nil when there are no more books. Empty first page is reported as not found
+func (r *BookMemoryRepository) GetBooksAfter(ctx context.Context, query repository.BookListQuery) ([]entity.Book, *repository.Keyset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, queryError(ctx, err)
+	}
+
+	books := r.listing(query)
+
+	if query.After != nil {
+		idx := sort.Search(len(books), func(idx int) bool {
+			return compareKeysets(query.Sort, bookKeyset(books[idx], query.Sort), query.After) > 0
+		})
+		books = books[idx:]
+	}
+
+	if len(books) == 0 && query.After == nil {
+		log.Printf("Could not get the first page of books\n")
+		return nil, nil, notFoundError(query)
+	}
+
+	var next *repository.Keyset
+	if len(books) > query.Limit {
+		books = books[:query.Limit]
+		next = bookKeyset(books[len(books)-1], query.Sort)
+	}
+
+	return books, next, nil
+}
+
+// ExportBooks calls each for every book matching filters of query. Error of each stops the export and is returned
+// as is
+func (r *BookMemoryRepository) ExportBooks(ctx context.Context, query repository.BookListQuery, each func(entity.Book) error) error {
+	for _, book := range r.listing(query) {
+		if err := ctx.Err(); err != nil {
+			return queryError(ctx, err)
+		}
+
+		if err := each(book); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *BookMemoryRepository) SearchByAuthor(ctx context.Context, author string) ([]entity.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if author == "" {
+		log.Printf("Author field is empty")
+		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldAuthorEmpty})
+	}
+
+	books := r.matching(func(book entity.Book) bool { return book.Author == author })
+
+	if len(books) == 0 {
+		log.Printf("Could not get all the books by author: %v\n", author)
+		return books, errors.NewBookNotFoundByAuthor(author)
+	}
+
+	return books, nil
+}
+
+func (r *BookMemoryRepository) SearchByTitle(ctx context.Context, title string) (*entity.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if title == "" {
+		log.Printf("Title field is empty")
+		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldTitleEmpty})
+	}
+
+	books := r.matching(func(book entity.Book) bool { return book.Title == title })
+
+	if len(books) == 0 {
+		log.Printf("Book title#%v not found", title)
+		return nil, errors.NewBookNotFoundByTitle(title)
+	}
+
+	return &books[0], nil
+}
+
+// SearchByKeyword returns books which contain keyword in title, author or description. Unlike other searches it does
+// not treat empty result as an error, since it is used to merge results of several media
+func (r *BookMemoryRepository) SearchByKeyword(ctx context.Context, keyword string, limit int) ([]entity.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	keyword = strings.ToLower(keyword)
+
+	books := r.matching(func(book entity.Book) bool {
+		return containsFold(book.Title, keyword) || containsFold(book.Author, keyword) || containsFold(book.Description, keyword)
+	})
+
+	return window(books, limit, 0), nil
+}
+
+// FullTextSearch returns books containing every word of query, the most relevant first. Relevance is computed by
+// database.TextRank and matched words are highlighted by database.Headline
+func (r *BookMemoryRepository) FullTextSearch(ctx context.Context, query string, limit int, offset int) ([]entity.BookMatch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	matches := []entity.BookMatch{}
+	for _, book := range r.matching(func(entity.Book) bool { return true }) {
+		rank := database.TextRank(query, book.Title, book.Author, book.Description)
+		if rank > 0 {
+			matches = append(matches, entity.BookMatch{
+				Book:     book,
+				Rank:     rank,
+				Headline: database.Headline(book.Title+" "+book.Description, query),
+			})
+		}
+	}
+
+	if len(matches) == 0 && offset == 0 {
+		log.Printf("Could not find books matching %v\n", query)
+		return nil, errors.NewBookNothingMatches(query)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+
+	if offset > len(matches) {
+		offset = len(matches)
+	}
+	matches = matches[offset:]
+	if limit < len(matches) {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// SearchSimilar returns up to limit books whose field (author or title) is at least threshold similar to value, the
+// most similar first. Finding no candidates is not an error
+func (r *BookMemoryRepository) SearchSimilar(ctx context.Context, field string, value string, threshold float64, limit int) ([]entity.BookCandidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	var fieldOf func(entity.Book) string
+	switch field {
+	case "author":
+		fieldOf = func(book entity.Book) string { return book.Author }
+	case "title":
+		fieldOf = func(book entity.Book) string { return book.Title }
+	default:
+		log.Printf("Books cannot be compared by %v", field)
+		return nil, errors.NewBookUnexpectedError(fmt.Sprintf("books cannot be compared by %v", field))
+	}
+
+	candidates := []entity.BookCandidate{}
+	for _, book := range r.matching(func(entity.Book) bool { return true }) {
+		similarity := database.Similarity(value, fieldOf(book))
+		if float64(similarity) >= threshold {
+			candidates = append(candidates, entity.BookCandidate{Book: book, Similarity: similarity})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	if limit < len(candidates) {
+		candidates = candidates[:limit]
+	}
+
+	return candidates, nil
+}
+
+// UpdateBook replaces the book, provided its version is still the given one. Zero version replaces any version.
+// Returned book has the version after the update
+func (r *BookMemoryRepository) UpdateBook(ctx context.Context, bookID uint64, version uint64, book *entity.Book) (*entity.VersionedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oldBook, ok := r.books[bookID]
+	if !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	current := r.versions[bookID]
+	if version != 0 && version != current {
+		log.Printf("Book id#%v is at version %v, update rejected", bookID, current)
+		return nil, errors.NewBookVersionMismatch(current)
+	}
+
+	returnBook := entity.VersionedBook{Book: withCanonicalISBN(*book), Version: current + 1}
+	returnBook.ID = bookID
+
+	if r.isbnTaken(returnBook.ISBN, bookID) {
+		log.Printf("Book isbn#%v already exists", returnBook.ISBN)
+		return nil, errors.NewBookISBNAlreadyExists(returnBook.ISBN)
+	}
+
+	r.books[bookID] = returnBook.Book
+	r.versions[bookID] = returnBook.Version
+	r.recordChange(ctx, bookID, entity.BookActionUpdate, &oldBook, &returnBook.Book)
+
+	return &returnBook, nil
+}
+
+// PatchBook changes only the fields set in patch, provided the version of the book is still the given one. Zero
+// version patches any version. Returned book is the whole book after the update
+func (r *BookMemoryRepository) PatchBook(ctx context.Context, bookID uint64, version uint64, patch entity.BookPatch) (*entity.VersionedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[bookID]
+	if !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	current := r.versions[bookID]
+	if version != 0 && version != current {
+		log.Printf("Book id#%v is at version %v, patch rejected", bookID, current)
+		return nil, errors.NewBookVersionMismatch(current)
+	}
+
+	patched := entity.VersionedBook{Book: patchWithCanonicalISBN(patch).Apply(book), Version: current + 1}
+
+	if r.isbnTaken(patched.ISBN, bookID) {
+		log.Printf("Book isbn#%v already exists", patched.ISBN)
+		return nil, errors.NewBookISBNAlreadyExists(patched.ISBN)
+	}
+
+	r.books[bookID] = patched.Book
+	r.versions[bookID] = patched.Version
+	r.recordChange(ctx, bookID, entity.BookActionPatch, &book, &patched.Book)
+
+	return &patched, nil
+}
+
+// DeleteBook moves the book to the trash. Book already in the trash is not found
+func (r *BookMemoryRepository) DeleteBook(ctx context.Context, bookID uint64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[bookID]
+	if !ok {
+		return 0, errors.NewBooksNotFound()
+	}
+
+	delete(r.books, bookID)
+	r.trash[bookID] = entity.DeletedBook{Book: book, DeletedAt: time.Now().UTC()}
+	r.recordChange(ctx, bookID, entity.BookActionDelete, &book, nil)
+
+	log.Printf("Deleted rows: %v", 1)
+
+	return 1, nil
+}
+
+// DeleteAllBooks moves every book to the trash
+func (r *BookMemoryRepository) DeleteAllBooks(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rowsAffected := int64(len(r.books))
+
+	ids := make([]uint64, 0, len(r.books))
+	for id := range r.books {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	deletedAt := time.Now().UTC()
+	for _, id := range ids {
+		book := r.books[id]
+		r.trash[id] = entity.DeletedBook{Book: book, DeletedAt: deletedAt}
+		r.recordChange(ctx, id, entity.BookActionDelete, &book, nil)
+	}
+	r.books = make(map[uint64]entity.Book)
+
+	if rowsAffected == 0 {
+		return 0, errors.NewBooksNotFound()
+	}
+
+	log.Printf("Rows affected: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
+
+// GetTrash returns books of the limit/offset window of the trash, the latest deleted first, and the total count of
+// books in the trash. Empty trash is not an error
+func (r *BookMemoryRepository) GetTrash(ctx context.Context, limit int, offset int) ([]entity.DeletedBook, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := make([]entity.DeletedBook, 0, len(r.trash))
+	for _, book := range r.trash {
+		books = append(books, book)
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		if !books[i].DeletedAt.Equal(books[j].DeletedAt) {
+			return books[i].DeletedAt.After(books[j].DeletedAt)
+		}
+		return books[i].ID < books[j].ID
+	})
+
+	total := int64(len(books))
+
+	if offset > len(books) {
+		offset = len(books)
+	}
+	books = books[offset:]
+	if limit < len(books) {
+		books = books[:limit]
+	}
+
+	return books, total, nil
+}
+
+// RestoreBook takes the book out of the trash. Restoring counts as a change, so the version of the book is bumped
+func (r *BookMemoryRepository) RestoreBook(ctx context.Context, bookID uint64) (*entity.VersionedBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted, ok := r.trash[bookID]
+	if !ok {
+		log.Printf("Book id#%v not found in the trash", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+	if r.isbnTaken(deleted.ISBN, bookID) {
+		log.Printf("Book isbn#%v already exists", deleted.ISBN)
+		return nil, errors.NewBookISBNAlreadyExists(deleted.ISBN)
+	}
+
+	restored := entity.VersionedBook{Book: deleted.Book, Version: r.versions[bookID] + 1}
+
+	delete(r.trash, bookID)
+	r.books[bookID] = restored.Book
+	r.versions[bookID] = restored.Version
+	r.recordChange(ctx, bookID, entity.BookActionRestore, nil, &restored.Book)
+
+	return &restored, nil
+}
+
+// PurgeBooks removes for good the books deleted before the given time
+func (r *BookMemoryRepository) PurgeBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, queryError(ctx, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]uint64, 0, len(r.trash))
+	for id, book := range r.trash {
+		if book.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var rowsAffected int64
+	for _, id := range ids {
+		book := r.trash[id].Book
+		r.recordChange(ctx, id, entity.BookActionPurge, &book, nil)
+		delete(r.trash, id)
+		delete(r.versions, id)
+		delete(r.tags, id)
+		rowsAffected++
+	}
+
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	log.Printf("Purged rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
+
+// GetAudit returns audit records of the limit/offset window of query, the latest first, and the total count of
+// records matching it. Finding no records is not an error
+func (r *BookMemoryRepository) GetAudit(ctx context.Context, query repository.BookAuditQuery) ([]entity.BookChange, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := []entity.BookChange{}
+	for idx := len(r.audit) - 1; idx >= 0; idx-- {
+		change := r.audit[idx]
+		if (query.BookID == 0 || change.BookID == query.BookID) &&
+			(query.Action == "" || change.Action == query.Action) &&
+			(query.Actor == "" || change.Actor == query.Actor) &&
+			(query.RequestID == "" || change.RequestID == query.RequestID) &&
+			(query.Since == nil || !change.CreatedAt.Before(*query.Since)) &&
+			(query.Until == nil || change.CreatedAt.Before(*query.Until)) {
+			changes = append(changes, change)
+		}
+	}
+
+	total := int64(len(changes))
+
+	if query.Offset > len(changes) {
+		query.Offset = len(changes)
+	}
+	changes = changes[query.Offset:]
+	if query.Limit < len(changes) {
+		changes = changes[:query.Limit]
+	}
+
+	return changes, total, nil
+}
+
+// GetBookTags returns tags of the live book in alphabetical order. Book without tags has an empty list
+func (r *BookMemoryRepository) GetBookTags(ctx context.Context, bookID uint64) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.books[bookID]; !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	return r.bookTags(bookID), nil
+}
+
+// TagBook adds tags to the live book. Tags the book already has are left as they are. Returned are all tags of the
+// book after the change
+func (r *BookMemoryRepository) TagBook(ctx context.Context, bookID uint64, tags []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.books[bookID]; !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	if r.tags[bookID] == nil {
+		r.tags[bookID] = make(map[string]bool, len(tags))
+	}
+	for _, tag := range tags {
+		r.tags[bookID][tag] = true
+	}
+
+	return r.bookTags(bookID), nil
+}
+
+// UntagBook removes tags from the live book. Tags the book does not have are skipped. Returned are the tags left
+func (r *BookMemoryRepository) UntagBook(ctx context.Context, bookID uint64, tags []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.books[bookID]; !ok {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	for _, tag := range tags {
+		delete(r.tags[bookID], tag)
+	}
+
+	return r.bookTags(bookID), nil
+}
+
+// GetTagCounts returns tags of the limit/offset window, each with the number of live books having it, the most used
+// first, and the total count of such tags. Finding no tags is not an error
+func (r *BookMemoryRepository) GetTagCounts(ctx context.Context, limit int, offset int) ([]entity.TagCount, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, queryError(ctx, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := make(map[string]int64)
+	for id := range r.books {
+		for tag := range r.tags[id] {
+			books[tag]++
+		}
+	}
+
+	counts := make([]entity.TagCount, 0, len(books))
+	for tag, count := range books {
+		counts = append(counts, entity.TagCount{Tag: tag, Books: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Books != counts[j].Books {
+			return counts[i].Books > counts[j].Books
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+
+	total := int64(len(counts))
+
+	if offset > len(counts) {
+		offset = len(counts)
+	}
+	counts = counts[offset:]
+	if limit < len(counts) {
+		counts = counts[:limit]
+	}
+
+	return counts, total, nil
+}
+
+// bookTags returns tags of the book in alphabetical order. Callers hold the lock
+func (r *BookMemoryRepository) bookTags(bookID uint64) []string {
+	tags := make([]string, 0, len(r.tags[bookID]))
+	for tag := range r.tags[bookID] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// hasTags returns true if the book has any of tags, or every one of them if all is set. Any book matches no tags.
+// Callers hold the lock
+func (r *BookMemoryRepository) hasTags(bookID uint64, tags []string, all bool) bool {
+	if len(tags) == 0 {
+		return true
+	}
+
+	for _, tag := range tags {
+		has := r.tags[bookID][tag]
+		if has && !all {
+			return true
+		}
+		if !has && all {
+			return false
+		}
+	}
+
+	return all
+}
+
+// recordChange appends the audit record of a mutation of one book, made by the actor of ctx. Callers hold the lock
+func (r *BookMemoryRepository) recordChange(ctx context.Context, bookID uint64, action string, oldValue *entity.Book, newValue *entity.Book) {
+	r.audit = append(r.audit, entity.BookChange{
+		ID:        uint64(len(r.audit)) + 1,
+		BookID:    bookID,
+		Action:    action,
+		OldValue:  copyBook(oldValue),
+		NewValue:  copyBook(newValue),
+		Actor:     common_request.Actor(ctx),
+		RequestID: common_request.RequestID(ctx),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func copyBook(book *entity.Book) *entity.Book {
+	if book == nil {
+		return nil
+	}
+	copied := *book
+	return &copied
+}
+
+// isbnTaken returns true if a book other than the one with bookID has the ISBN. Like the unique index of
+// BookDBRepository it skips books in the trash, and books without ISBN never clash
+func (r *BookMemoryRepository) isbnTaken(isbn string, bookID uint64) bool {
+	if isbn == "" {
+		return false
+	}
+	for id, book := range r.books {
+		if id != bookID && book.ISBN == isbn {
+			return true
+		}
+	}
+	return false
+}
+
+// matching returns copies of books accepted by match, ordered by id
+func (r *BookMemoryRepository) matching(match func(entity.Book) bool) []entity.Book {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := []entity.Book{}
+	for _, book := range r.books {
+		if match(book) {
+			books = append(books, book)
+		}
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		return books[i].ID < books[j].ID
+	})
+
+	return books
+}
+
+// listing returns books matching filters of query in the order of its sort
+func (r *BookMemoryRepository) listing(query repository.BookListQuery) []entity.Book {
+	books := r.matching(func(book entity.Book) bool {
+		return (query.Author == "" || book.Author == query.Author) &&
+			(query.TitleContains == "" || containsFold(book.Title, strings.ToLower(query.TitleContains))) &&
+			(query.YearGTE == nil || book.Year >= *query.YearGTE) &&
+			(query.YearLTE == nil || book.Year <= *query.YearLTE) &&
+			r.hasTags(book.ID, query.Tags, query.AllTags)
+	})
+
+	sort.SliceStable(books, func(i, j int) bool {
+		return compareKeysets(query.Sort, bookKeyset(books[i], query.Sort), bookKeyset(books[j], query.Sort)) < 0
+	})
+
+	return books
+}
+
+// window returns limit/offset window of books
+func window(books []entity.Book, limit int, offset int) []entity.Book {
+	if offset > len(books) {
+		offset = len(books)
+	}
+	books = books[offset:]
+
+	if limit < len(books) {
+		books = books[:limit]
+	}
+
+	return books
+}
+
+// compareKeysets orders positions of books in a listing sorted by sort. Numeric columns are compared as numbers
+func compareKeysets(sort []repository.SortField, lhs *repository.Keyset, rhs *repository.Keyset) int {
+	for idx, field := range sort {
+		var res int
+
+		if field.Column == "id" || field.Column == "year" {
+			lhsNumber, _ := strconv.ParseInt(lhs.Values[idx], 10, 64)
+			rhsNumber, _ := strconv.ParseInt(rhs.Values[idx], 10, 64)
+			res = compareInts(lhsNumber, rhsNumber)
+		} else {
+			res = strings.Compare(lhs.Values[idx], rhs.Values[idx])
+		}
+
+		if field.Desc {
+			res = -res
+		}
+		if res != 0 {
+			return res
+		}
+	}
+
+	return compareInts(int64(lhs.ID), int64(rhs.ID))
+}
+
+func compareInts(lhs int64, rhs int64) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	}
+	return 0
+}
+
+// containsFold returns true if s contains lowerSubstr, ignoring case of s
+func containsFold(s string, lowerSubstr string) bool {
+	return strings.Contains(strings.ToLower(s), lowerSubstr)
+}
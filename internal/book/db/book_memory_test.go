@@ -0,0 +1,550 @@
+package db
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+var memoryBooks = []entity.Book{
+	{Title: "The Martian Chronicles", Author: "Ray Bradbury", Year: 1950, Description: "Mars is colonised"},
+	{Title: "Fahrenheit 451", Author: "Ray Bradbury", Year: 1953, Description: "Books are burned"},
+	{Title: "Solaris", Author: "Stanislaw Lem", Year: 1961, Description: "A living ocean"},
+}
+
+// newMemoryRepo returns repository holding memoryBooks with ids 1, 2 and 3
+func newMemoryRepo(t *testing.T) *BookMemoryRepository {
+	repo := NewBookMemoryRepo()
+	for idx := range memoryBooks {
+		if _, err := repo.SaveBook(context.Background(), &memoryBooks[idx]); err != nil {
+			t.Fatalf("Could not save the book: %v", err)
+		}
+	}
+	return repo
+}
+
+// withIDs returns memoryBooks with given ids, in the given order
+func withIDs(ids ...uint64) []entity.Book {
+	books := []entity.Book{}
+	for _, id := range ids {
+		book := memoryBooks[id-1]
+		book.ID = id
+		books = append(books, book)
+	}
+	return books
+}
+
+func TestBookMemoryRepository_GetBook(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	getBookTests := []struct {
+		testName       string
+		input          uint64
+		expectedOutput *entity.VersionedBook
+		expectedError  error
+	}{
+		{
+			testName:       "Test Successful",
+			input:          2,
+			expectedOutput: &entity.VersionedBook{Book: withIDs(2)[0], Version: 1},
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			input:         4,
+			expectedError: errors.NewBooksNotFound(),
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			input:         0,
+			expectedError: errors.NewBookInvalidSerial(),
+		},
+	}
+
+	for _, test := range getBookTests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := repo.GetBook(context.Background(), test.input)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookMemoryRepository_SearchByAuthor(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	searchByAuthorTests := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Book
+		expectedError  error
+	}{
+		{
+			testName:       "Test Successful",
+			input:          "Ray Bradbury",
+			expectedOutput: withIDs(1, 2),
+		},
+		{
+			testName:       "Test Unsuccessful: Author not found",
+			input:          "Ray Bradberry",
+			expectedOutput: []entity.Book{},
+			expectedError:  errors.NewBookNotFoundByAuthor("Ray Bradberry"),
+		},
+		{
+			testName:      "Test Unsuccessful: Empty author",
+			expectedError: errors.NewBookValidatorError([]ct.FieldError{validators.FieldAuthorEmpty}),
+		},
+	}
+
+	for _, test := range searchByAuthorTests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := repo.SearchByAuthor(context.Background(), test.input)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestBookMemoryRepository_GetBooksPage(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	yearGTE := 1951
+
+	getBooksPageTests := []struct {
+		testName       string
+		query          repository.BookListQuery
+		expectedOutput []entity.Book
+		expectedTotal  int64
+		expectedError  error
+	}{
+		{
+			testName:       "Test Successful: Sorted",
+			query:          repository.BookListQuery{Sort: []repository.SortField{{Column: "year", Desc: true}}, Limit: 2},
+			expectedOutput: withIDs(3, 2),
+			expectedTotal:  3,
+		},
+		{
+			testName:       "Test Successful: Filtered",
+			query:          repository.BookListQuery{TitleContains: "FAHR", YearGTE: &yearGTE, Limit: 2},
+			expectedOutput: withIDs(2),
+			expectedTotal:  1,
+		},
+		{
+			testName:       "Test Successful: Past the last page",
+			query:          repository.BookListQuery{Limit: 2, Offset: 10},
+			expectedOutput: []entity.Book{},
+			expectedTotal:  3,
+		},
+		{
+			testName:      "Test Unsuccessful: Author not found",
+			query:         repository.BookListQuery{Author: "Ray Bradberry", Limit: 2},
+			expectedError: errors.NewBookNotFoundByAuthor("Ray Bradberry"),
+		},
+	}
+
+	for _, test := range getBooksPageTests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, total, err := repo.GetBooksPage(context.Background(), test.query)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOutput, res)
+			assert.Equal(t, test.expectedTotal, total)
+		})
+	}
+}
+
+func TestBookMemoryRepository_GetBooksAfter(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	authorDesc := []repository.SortField{{Column: "author", Desc: true}}
+
+	query := repository.BookListQuery{Sort: authorDesc, Limit: 2}
+
+	first, next, err := repo.GetBooksAfter(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(3, 1), first)
+	assert.Equal(t, &repository.Keyset{Values: []string{"Ray Bradbury"}, ID: 1}, next)
+
+	query.After = next
+
+	second, next, err := repo.GetBooksAfter(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2), second)
+	assert.Nil(t, next)
+}
+
+func TestBookMemoryRepository_FullTextSearch(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	res, err := repo.FullTextSearch(context.Background(), "mars martian", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.BookMatch{
+		{
+			Book:     withIDs(1)[0],
+			Rank:     1.2,
+			Headline: "The <b>Martian</b> Chronicles <b>Mars</b> is colonised",
+		},
+	}, res)
+
+	_, err = repo.FullTextSearch(context.Background(), "venus", 10, 0)
+	assert.Equal(t, errors.NewBookNothingMatches("venus"), err)
+}
+
+func TestBookMemoryRepository_SearchSimilar(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	res, err := repo.SearchSimilar(context.Background(), "author", "Ray Bradberry", 0.3, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, withIDs(1)[0], res[0].Book)
+		assert.InDelta(t, 10.0/17, res[0].Similarity, 0.001)
+	}
+
+	res, err = repo.SearchSimilar(context.Background(), "title", "Ray Bradberry", 0.3, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = repo.SearchSimilar(context.Background(), "description", "Mars", 0.3, 1)
+	assert.Equal(t, errors.NewBookUnexpectedError("books cannot be compared by description"), err)
+}
+
+func TestBookMemoryRepository_UpdateBook(t *testing.T) {
+	repo := newMemoryRepo(t)
+	edited := entity.Book{Title: "Solaris", Author: "Stanislaw Lem", Year: 1961, Description: "A thinking ocean"}
+
+	updated, err := repo.UpdateBook(context.Background(), 3, 1, &edited)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), updated.Version)
+
+	_, err = repo.UpdateBook(context.Background(), 3, 1, &edited)
+	assert.Equal(t, errors.NewBookVersionMismatch(2), err, "Update based on a stale version should be rejected")
+
+	updated, err = repo.UpdateBook(context.Background(), 3, 0, &edited)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), updated.Version, "Zero version should match any")
+
+	book, err := repo.GetBook(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "A thinking ocean", book.Description)
+	assert.Equal(t, uint64(3), book.Version)
+
+	_, err = repo.UpdateBook(context.Background(), 4, 0, &edited)
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+}
+
+func TestBookMemoryRepository_PatchBook(t *testing.T) {
+	repo := newMemoryRepo(t)
+	description := "A thinking ocean"
+
+	patched, err := repo.PatchBook(context.Background(), 3, 1, entity.BookPatch{Description: &description})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), patched.Version)
+	assert.Equal(t, "Solaris", patched.Title, "Fields out of the patch should be left as they are")
+	assert.Equal(t, description, patched.Description)
+
+	_, err = repo.PatchBook(context.Background(), 3, 1, entity.BookPatch{Description: &description})
+	assert.Equal(t, errors.NewBookVersionMismatch(2), err, "Patch based on a stale version should be rejected")
+
+	_, err = repo.PatchBook(context.Background(), 4, 0, entity.BookPatch{Description: &description})
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+}
+
+func TestBookMemoryRepository_DeleteAllBooks(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	deleted, err := repo.DeleteAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	_, err = repo.DeleteAllBooks(context.Background())
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	_, total, err := repo.GetTrash(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total, "Deleted books should be kept in the trash")
+
+	purged, err := repo.PurgeBooks(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestBookMemoryRepository_Trash(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	_, err := repo.DeleteBook(context.Background(), 2)
+	assert.NoError(t, err)
+
+	_, err = repo.GetBook(context.Background(), 2)
+	assert.Equal(t, errors.NewBooksNotFound(), err, "Deleted book should not be found")
+
+	books, err := repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1, 3), books)
+
+	_, err = repo.DeleteBook(context.Background(), 2)
+	assert.Equal(t, errors.NewBooksNotFound(), err, "Book should not be deleted twice")
+
+	trash, total, err := repo.GetTrash(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, withIDs(2)[0], trash[0].Book)
+	}
+
+	restored, err := repo.RestoreBook(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2)[0], restored.Book)
+	assert.Equal(t, uint64(2), restored.Version, "Restoring should bump the version")
+
+	_, err = repo.RestoreBook(context.Background(), 2)
+	assert.Equal(t, errors.NewBooksNotFound(), err, "Live book is not in the trash")
+
+	_, err = repo.DeleteBook(context.Background(), 3)
+	assert.NoError(t, err)
+
+	purged, err := repo.PurgeBooks(context.Background(), trash[0].DeletedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "Books deleted after the time should be kept")
+
+	purged, err = repo.PurgeBooks(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.RestoreBook(context.Background(), 3)
+	assert.Equal(t, errors.NewBooksNotFound(), err, "Purged book cannot be restored")
+}
+
+func TestBookMemoryRepository_Audit(t *testing.T) {
+	repo := newMemoryRepo(t)
+	ctx := common_request.WithActor(common_request.WithRequestID(context.Background(), "request-1"), "librarian")
+
+	title := "Fahrenheit 451: The Temperature at Which Book Paper Catches Fire"
+	_, err := repo.PatchBook(ctx, 2, 0, entity.BookPatch{Title: &title})
+	assert.NoError(t, err)
+
+	_, err = repo.DeleteBook(ctx, 2)
+	assert.NoError(t, err)
+
+	err = repo.Atomically(ctx, func(tx repository.BookRepository) error {
+		if _, err := tx.RestoreBook(ctx, 2); err != nil {
+			return err
+		}
+		return goerrors.New("failure")
+	})
+	assert.Error(t, err)
+
+	patched := withIDs(2)[0]
+	patched.Title = title
+
+	history, total, err := repo.GetAudit(context.Background(), repository.BookAuditQuery{BookID: 2, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total, "Rolled back changes should not be recorded")
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, entity.BookActionDelete, history[0].Action)
+		assert.Equal(t, &patched, history[0].OldValue)
+		assert.Nil(t, history[0].NewValue)
+
+		assert.Equal(t, entity.BookActionPatch, history[1].Action)
+		assert.Equal(t, withIDs(2)[0], *history[1].OldValue)
+		assert.Equal(t, &patched, history[1].NewValue)
+		assert.Equal(t, "librarian", history[1].Actor)
+		assert.Equal(t, "request-1", history[1].RequestID)
+
+		assert.Equal(t, entity.BookActionCreate, history[2].Action)
+		assert.Nil(t, history[2].OldValue)
+		assert.Equal(t, common_request.AnonymousActor, history[2].Actor)
+	}
+
+	changes, total, err := repo.GetAudit(context.Background(), repository.BookAuditQuery{Action: entity.BookActionCreate, Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, uint64(1), changes[0].BookID)
+	}
+}
+
+func TestBookMemoryRepository_Concurrent(t *testing.T) {
+	repo := NewBookMemoryRepo()
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 50; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved, err := repo.SaveBook(context.Background(), &memoryBooks[0])
+			assert.NoError(t, err)
+			_, err = repo.GetBook(context.Background(), saved.ID)
+			assert.NoError(t, err)
+			_, _, err = repo.GetBooksPage(context.Background(), repository.BookListQuery{Limit: 10})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	books, err := repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 50)
+}
+
+func TestBookMemoryRepository_ContextDone(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := repo.GetBook(expired, 1)
+	assert.Equal(t, errors.NewBookQueryTimeout(), err)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.SaveBook(cancelled, &memoryBooks[0])
+	assert.Equal(t, errors.NewBookCouldNotQuery(context.Canceled.Error()), err)
+
+	books, err := repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 3, "Cancelled save should not store the book")
+}
+
+func TestBookMemoryRepository_Atomically(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	err := repo.Atomically(context.Background(), func(tx repository.BookRepository) error {
+		if _, err := tx.DeleteBook(context.Background(), 1); err != nil {
+			return err
+		}
+		if _, err := tx.SaveBook(context.Background(), &memoryBooks[0]); err != nil {
+			return err
+		}
+		_, err := tx.DeleteBook(context.Background(), 10)
+		return err
+	})
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	books, err := repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1, 2, 3), books, "Rolled back calls should leave no trace")
+
+	err = repo.Atomically(context.Background(), func(tx repository.BookRepository) error {
+		_, err := tx.DeleteBook(context.Background(), 1)
+		return err
+	})
+	assert.NoError(t, err)
+
+	saved, err := repo.SaveBook(context.Background(), &memoryBooks[0])
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), saved.ID)
+
+	books, err = repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, books, 3)
+}
+
+func TestBookMemoryRepository_ExportBooks(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	exported := []entity.Book{}
+	err := repo.ExportBooks(context.Background(), repository.BookListQuery{Author: "Ray Bradbury", Sort: []repository.SortField{{Column: "year", Desc: true}}}, func(book entity.Book) error {
+		exported = append(exported, book)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2, 1), exported)
+
+	stop := goerrors.New("stop")
+	err = repo.ExportBooks(context.Background(), repository.BookListQuery{}, func(entity.Book) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+}
+
+func TestBookMemoryRepository_ISBN(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	saved, err := repo.SaveBook(context.Background(), &entity.Book{Title: "Eden", Author: "Stanislaw Lem", Year: 1959, ISBN: "0-306-40615-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "9780306406157", saved.ISBN, "ISBN-10 should be stored as ISBN-13")
+
+	book, err := repo.GetBookByISBN(context.Background(), "9780306406157")
+	assert.NoError(t, err)
+	assert.Equal(t, saved.ID, book.ID)
+
+	_, err = repo.SaveBook(context.Background(), &entity.Book{Title: "Eden 2", Author: "Stanislaw Lem", Year: 1959, ISBN: "978-0-306-40615-7"})
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err)
+
+	isbn := "978-0-306-40615-7"
+	_, err = repo.PatchBook(context.Background(), 3, 0, entity.BookPatch{ISBN: &isbn})
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err)
+
+	_, err = repo.DeleteBook(context.Background(), saved.ID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetBookByISBN(context.Background(), "9780306406157")
+	assert.Equal(t, errors.NewBookNotFoundByISBN("9780306406157"), err, "Books in the trash should not be found")
+
+	_, err = repo.UpdateBook(context.Background(), 3, 0, &entity.Book{Title: "Solaris", Author: "Stanislaw Lem", Year: 1961, ISBN: isbn})
+	assert.NoError(t, err, "ISBN of a book in the trash should be free")
+
+	_, err = repo.RestoreBook(context.Background(), saved.ID)
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err, "Book should not be restored while its ISBN is taken")
+
+	_, err = repo.GetBookByISBN(context.Background(), "")
+	assert.Equal(t, errors.NewBookNotFoundByISBN(""), err, "Books without ISBN should not be found")
+}
+
+func TestBookMemoryRepository_Tags(t *testing.T) {
+	repo := newMemoryRepo(t)
+
+	tags, err := repo.TagBook(context.Background(), 1, []string{"science fiction", "classic"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"classic", "science fiction"}, tags)
+
+	_, err = repo.TagBook(context.Background(), 2, []string{"classic", "dystopia"})
+	assert.NoError(t, err)
+	_, err = repo.TagBook(context.Background(), 3, []string{"science fiction"})
+	assert.NoError(t, err)
+
+	books, _, err := repo.GetBooksPage(context.Background(), repository.BookListQuery{Tags: []string{"dystopia", "science fiction"}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1, 2, 3), books, "Books having any of the tags should be listed")
+
+	books, _, err = repo.GetBooksPage(context.Background(), repository.BookListQuery{Tags: []string{"classic", "science fiction"}, AllTags: true, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1), books, "Only books having every tag should be listed")
+
+	counts, total, err := repo.GetTagCounts(context.Background(), 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []entity.TagCount{{Tag: "classic", Books: 2}, {Tag: "science fiction", Books: 2}}, counts)
+
+	tags, err = repo.UntagBook(context.Background(), 1, []string{"classic", "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"science fiction"}, tags)
+
+	_, err = repo.DeleteBook(context.Background(), 3)
+	assert.NoError(t, err)
+
+	counts, _, err = repo.GetTagCounts(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.TagCount{{Tag: "classic", Books: 1}, {Tag: "dystopia", Books: 1}, {Tag: "science fiction", Books: 1}}, counts, "Books in the trash should not be counted")
+
+	_, err = repo.TagBook(context.Background(), 3, []string{"classic"})
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	_, err = repo.RestoreBook(context.Background(), 3)
+	assert.NoError(t, err)
+
+	tags, err = repo.GetBookTags(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"science fiction"}, tags, "Restored book should keep its tags")
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"strconv"
+	"strings"
+)
+
+const (
+	querySelectBooks = `SELECT id, title, author, year, description, isbn FROM bookstore`
+	queryCountBooks  = `SELECT COUNT(*) FROM bookstore`
+)
+
+// sortColumns whitelists columns listing can be ordered by. Keys are the names clients use
+var sortColumns = map[string]string{
+	"id":     "id",
+	"title":  "title",
+	"author": "author",
+	"year":   "year",
+}
+
+// IsSortable returns true if books can be ordered by column
+func IsSortable(column string) bool {
+	_, ok := sortColumns[column]
+	return ok
+}
+
+// listQueryBuilder accumulates WHERE conditions of a listing along with their positional arguments. Placeholders are
+// written for postgres, BookDBRepository rebinds them for the dialect
+type listQueryBuilder struct {
+	dialect    database.Dialect
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds value to the arguments and returns its placeholder
+func (b *listQueryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// filter adds conditions for every filter set in query. Values are always passed as arguments, never inlined. Books
+// in the trash are never listed
+func (b *listQueryBuilder) filter(query repository.BookListQuery) {
+	b.conditions = append(b.conditions, "deleted_at IS NULL")
+	if query.Author != "" {
+		b.conditions = append(b.conditions, b.authored(query.Author))
+	}
+	if query.TitleContains != "" {
+		b.conditions = append(b.conditions, b.dialect.ContainsCondition("title", b.arg(database.ContainsPattern(query.TitleContains))))
+	}
+	if query.YearGTE != nil {
+		b.conditions = append(b.conditions, "year >= "+b.arg(*query.YearGTE))
+	}
+	if query.YearLTE != nil {
+		b.conditions = append(b.conditions, "year <= "+b.arg(*query.YearLTE))
+	}
+	if len(query.Tags) > 0 {
+		b.conditions = append(b.conditions, "id IN ("+b.tagged(query.Tags, query.AllTags)+")")
+	}
+}
+
+// tagged returns subquery selecting ids of books having any of tags, or every one of them if all is set. Tags have
+// to be distinct for the count of matched tags to tell whether the book has them all
+func (b *listQueryBuilder) tagged(tags []string, all bool) string {
+	placeholders := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		placeholders = append(placeholders, b.arg(tag))
+	}
+
+	subquery := "SELECT bt.book_id FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name IN (" +
+		strings.Join(placeholders, ", ") + ")"
+	if all {
+		subquery += " GROUP BY bt.book_id HAVING COUNT(*) = " + b.arg(len(tags))
+	}
+
+	return subquery
+}
+
+// authored returns condition matching books of author. On postgres books are found through the authors they are
+// linked to, like BookDBRepository.SearchByAuthor finds them
+func (b *listQueryBuilder) authored(author string) string {
+	if b.dialect != database.Postgres {
+		return "author=" + b.arg(author)
+	}
+	return "id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=" +
+		b.arg(authorKey(b.dialect, author)) + ")"
+}
+
+// keyset adds condition which skips every book up to and including the one at after. For sort (a, b) it is
+// (a > $1) OR (a = $1 AND b > $2) OR (a = $1 AND b = $2 AND id > $3), with < for descending columns
+func (b *listQueryBuilder) keyset(sort []repository.SortField, after *repository.Keyset) {
+	if after == nil {
+		return
+	}
+
+	var alternatives []string
+	var equal []string
+
+	for idx, field := range sort {
+		column := sortColumns[field.Column]
+		placeholder := b.arg(after.Values[idx])
+
+		op := " > "
+		if field.Desc {
+			op = " < "
+		}
+
+		alternative := append([]string{}, equal...)
+		alternative = append(alternative, column+op+placeholder)
+
+		alternatives = append(alternatives, "("+strings.Join(alternative, " AND ")+")")
+		equal = append(equal, column+" = "+placeholder)
+	}
+
+	alternatives = append(alternatives, "("+strings.Join(append(equal, "id > "+b.arg(after.ID)), " AND ")+")")
+
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+}
+
+func (b *listQueryBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// orderBy returns ORDER BY clause for sort with id as the final tie breaker
+func orderBy(sort []repository.SortField) string {
+	var columns []string
+	for _, field := range sort {
+		column := sortColumns[field.Column]
+		if field.Desc {
+			column += " DESC"
+		}
+		columns = append(columns, column)
+	}
+	columns = append(columns, "id")
+
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
+
+// buildCountQuery returns query counting every book matching filters of query
+func buildCountQuery(dialect database.Dialect, query repository.BookListQuery) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.filter(query)
+
+	return queryCountBooks + b.where(), b.args
+}
+
+// buildPageQuery returns query selecting books of the limit/offset window
+func buildPageQuery(dialect database.Dialect, query repository.BookListQuery) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.filter(query)
+
+	where := b.where()
+	limit := b.arg(query.Limit)
+	offset := b.arg(query.Offset)
+
+	return fmt.Sprintf("%s%s%s LIMIT %s OFFSET %s", querySelectBooks, where, orderBy(query.Sort), limit, offset), b.args
+}
+
+// buildKeysetQuery returns query selecting up to limit books after the keyset of query
+func buildKeysetQuery(dialect database.Dialect, query repository.BookListQuery, limit int) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.filter(query)
+	b.keyset(query.Sort, query.After)
+
+	where := b.where()
+
+	return fmt.Sprintf("%s%s%s LIMIT %s", querySelectBooks, where, orderBy(query.Sort), b.arg(limit)), b.args
+}
+
+// buildExportQuery returns query selecting every book matching filters of query, in its order
+func buildExportQuery(dialect database.Dialect, query repository.BookListQuery) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.filter(query)
+
+	return querySelectBooks + b.where() + orderBy(query.Sort), b.args
+}
+
+// buildPatchQuery returns query changing only the columns set in patch of the book with bookID, provided its version
+// is still the given one. The query returns the whole book after the update
+func buildPatchQuery(dialect database.Dialect, bookID uint64, version uint64, patch entity.BookPatch) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	id := b.arg(bookID)
+
+	var set []string
+	if patch.Title != nil {
+		set = append(set, "title="+b.arg(*patch.Title))
+	}
+	if patch.Author != nil {
+		set = append(set, "author="+b.arg(*patch.Author))
+	}
+	if patch.Year != nil {
+		set = append(set, "year="+b.arg(*patch.Year))
+	}
+	if patch.Description != nil {
+		set = append(set, "description="+b.arg(*patch.Description))
+	}
+	if patch.ISBN != nil {
+		set = append(set, "isbn="+b.arg(*patch.ISBN))
+	}
+	set = append(set, "version=version+1")
+
+	versionArg := b.arg(version)
+
+	return fmt.Sprintf("UPDATE bookstore SET %s WHERE id=%s AND deleted_at IS NULL AND (%s=0 OR version=%s) RETURNING id, title, author, year, description, isbn, version",
+		strings.Join(set, ", "), id, versionArg, versionArg), b.args
+}
+
+// bookKeyset returns the position of book in a listing ordered by sort
+func bookKeyset(book entity.Book, sort []repository.SortField) *repository.Keyset {
+	keyset := &repository.Keyset{ID: book.ID}
+
+	for _, field := range sort {
+		var value string
+		switch field.Column {
+		case "id":
+			value = strconv.FormatUint(book.ID, 10)
+		case "title":
+			value = book.Title
+		case "author":
+			value = book.Author
+		case "year":
+			value = strconv.Itoa(book.Year)
+		}
+		keyset.Values = append(keyset.Values, value)
+	}
+
+	return keyset
+}
+
+const (
+	querySelectAudit = `SELECT id, book_id, action, old_value, new_value, actor, request_id, created_at FROM book_audit`
+	queryCountAudit  = `SELECT COUNT(*) FROM book_audit`
+)
+
+// auditFilter adds conditions for every filter set in query
+func (b *listQueryBuilder) auditFilter(query repository.BookAuditQuery) {
+	if query.BookID != 0 {
+		b.conditions = append(b.conditions, "book_id="+b.arg(query.BookID))
+	}
+	if query.Action != "" {
+		b.conditions = append(b.conditions, "action="+b.arg(query.Action))
+	}
+	if query.Actor != "" {
+		b.conditions = append(b.conditions, "actor="+b.arg(query.Actor))
+	}
+	if query.RequestID != "" {
+		b.conditions = append(b.conditions, "request_id="+b.arg(query.RequestID))
+	}
+	if query.Since != nil {
+		b.conditions = append(b.conditions, "created_at >= "+b.arg(query.Since.UTC()))
+	}
+	if query.Until != nil {
+		b.conditions = append(b.conditions, "created_at < "+b.arg(query.Until.UTC()))
+	}
+}
+
+// buildAuditCountQuery returns query counting every audit record matching filters of query
+func buildAuditCountQuery(dialect database.Dialect, query repository.BookAuditQuery) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.auditFilter(query)
+
+	return queryCountAudit + b.where(), b.args
+}
+
+// buildAuditPageQuery returns query selecting audit records of the limit/offset window, the latest first
+func buildAuditPageQuery(dialect database.Dialect, query repository.BookAuditQuery) (string, []interface{}) {
+	b := listQueryBuilder{dialect: dialect}
+	b.auditFilter(query)
+
+	where := b.where()
+	limit := b.arg(query.Limit)
+	offset := b.arg(query.Offset)
+
+	return fmt.Sprintf("%s%s ORDER BY created_at DESC, id DESC LIMIT %s OFFSET %s", querySelectAudit, where, limit, offset), b.args
+}
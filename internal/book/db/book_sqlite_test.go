@@ -0,0 +1,277 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	goerrors "errors"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSQLiteDB returns a fresh SQLite file holding memoryBooks with ids 1, 2 and 3
+func newSQLiteDB(t *testing.T) *sql.DB {
+	db := database.CreateSQLitePool(filepath.Join(t.TempDir(), "books.db"), 1, 1, time.Minute)
+	t.Cleanup(func() { db.Close() })
+
+	repo := NewBookRepo(db)
+	for idx := range memoryBooks {
+		saved, err := repo.SaveBook(context.Background(), &memoryBooks[idx])
+		if err != nil {
+			t.Fatalf("Could not save the book: %v", err)
+		}
+		assert.Equal(t, uint64(idx+1), saved.ID)
+	}
+
+	return db
+}
+
+// newSQLiteRepo returns repository backed by newSQLiteDB
+func newSQLiteRepo(t *testing.T) BookDBRepository {
+	return NewBookRepo(newSQLiteDB(t))
+}
+
+func TestBookDBRepository_SQLite(t *testing.T) {
+	repo := newSQLiteRepo(t)
+
+	book, err := repo.GetBook(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.VersionedBook{Book: withIDs(2)[0], Version: 1}, book)
+
+	updated, err := repo.UpdateBook(context.Background(), 2, 1, &book.Book)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), updated.Version)
+
+	_, err = repo.UpdateBook(context.Background(), 2, 1, &book.Book)
+	assert.Equal(t, errors.NewBookVersionMismatch(2), err)
+
+	_, err = repo.GetBook(context.Background(), 4)
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	books, err := repo.SearchByAuthor(context.Background(), "Ray Bradbury")
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1, 2), books)
+
+	yearGTE := 1951
+	books, total, err := repo.GetBooksPage(context.Background(), repository.BookListQuery{TitleContains: "fahr", YearGTE: &yearGTE, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, withIDs(2), books)
+
+	yearDesc := []repository.SortField{{Column: "year", Desc: true}}
+	books, next, err := repo.GetBooksAfter(context.Background(), repository.BookListQuery{Sort: yearDesc, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(3, 2), books)
+
+	books, next, err = repo.GetBooksAfter(context.Background(), repository.BookListQuery{Sort: yearDesc, Limit: 2, After: next})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1), books)
+	assert.Nil(t, next)
+
+	books, err = repo.SearchByKeyword(context.Background(), "OCEAN", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(3), books)
+
+	matches, err := repo.FullTextSearch(context.Background(), "mars martian", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, withIDs(1)[0], matches[0].Book)
+		assert.Equal(t, "The <b>Martian</b> Chronicles <b>Mars</b> is colonised", matches[0].Headline)
+	}
+
+	candidates, err := repo.SearchSimilar(context.Background(), "author", "Stanislav Lem", 0.3, 5)
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 1) {
+		assert.Equal(t, withIDs(3)[0], candidates[0].Book)
+	}
+
+	_, err = repo.DeleteBook(context.Background(), 2)
+	assert.NoError(t, err)
+
+	trash, total, err := repo.GetTrash(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, withIDs(2)[0], trash[0].Book)
+		assert.WithinDuration(t, time.Now(), trash[0].DeletedAt, time.Minute)
+	}
+
+	restored, err := repo.RestoreBook(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2)[0], restored.Book)
+
+	deleted, err := repo.DeleteAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	_, err = repo.GetAllBooks(context.Background())
+	assert.Equal(t, errors.NewBooksNotFound(), err, "Deleted books should not be listed")
+
+	purged, err := repo.PurgeBooks(context.Background(), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "Books deleted within the hour should be kept")
+
+	purged, err = repo.PurgeBooks(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestBookDBUnitOfWork_SQLite(t *testing.T) {
+	db := newSQLiteDB(t)
+	repo := NewBookRepo(db)
+	uow := NewBookUnitOfWork(db)
+
+	failure := goerrors.New("failure")
+	err := uow.Atomically(context.Background(), func(tx repository.BookRepository) error {
+		if _, err := tx.DeleteBook(context.Background(), 1); err != nil {
+			return err
+		}
+		if _, err := tx.SaveBook(context.Background(), &entity.Book{Title: "Eden", Author: "Stanislaw Lem", Year: 1959}); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.Equal(t, failure, err)
+
+	books, err := repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(1, 2, 3), books, "Rolled back calls should leave no trace")
+
+	err = uow.Atomically(context.Background(), func(tx repository.BookRepository) error {
+		_, err := tx.DeleteBook(context.Background(), 1)
+		return err
+	})
+	assert.NoError(t, err)
+
+	books, err = repo.GetAllBooks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2, 3), books)
+}
+
+func TestBookDBRepository_SQLiteAudit(t *testing.T) {
+	repo := newSQLiteRepo(t)
+	ctx := common_request.WithActor(common_request.WithRequestID(context.Background(), "request-1"), "librarian")
+
+	book := withIDs(2)[0]
+	book.Year = 1954
+	_, err := repo.UpdateBook(ctx, 2, 0, &book)
+	assert.NoError(t, err)
+
+	_, err = repo.DeleteAllBooks(ctx)
+	assert.NoError(t, err)
+
+	_, err = repo.PurgeBooks(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+
+	history, total, err := repo.GetAudit(context.Background(), repository.BookAuditQuery{BookID: 2, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	if assert.Len(t, history, 4) {
+		assert.Equal(t, entity.BookActionPurge, history[0].Action)
+		assert.Equal(t, &book, history[0].OldValue, "Bulk changes should record the whole book")
+		assert.Nil(t, history[0].NewValue)
+
+		assert.Equal(t, entity.BookActionUpdate, history[2].Action)
+		assert.Equal(t, withIDs(2)[0], *history[2].OldValue)
+		assert.Equal(t, &book, history[2].NewValue)
+		assert.Equal(t, "librarian", history[2].Actor)
+		assert.Equal(t, "request-1", history[2].RequestID)
+
+		assert.Equal(t, entity.BookActionCreate, history[3].Action)
+		assert.Equal(t, common_request.AnonymousActor, history[3].Actor)
+	}
+
+	changes, total, err := repo.GetAudit(context.Background(), repository.BookAuditQuery{Action: entity.BookActionDelete, Actor: "librarian", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, changes, 2)
+
+	since := time.Now().Add(time.Hour)
+	changes, total, err = repo.GetAudit(context.Background(), repository.BookAuditQuery{Since: &since, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Equal(t, []entity.BookChange{}, changes)
+}
+
+func TestBookDBRepository_SQLiteISBN(t *testing.T) {
+	repo := newSQLiteRepo(t)
+
+	saved, err := repo.SaveBook(context.Background(), &entity.Book{Title: "Eden", Author: "Stanislaw Lem", Year: 1959, ISBN: "0-306-40615-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "9780306406157", saved.ISBN)
+
+	book, err := repo.GetBookByISBN(context.Background(), "9780306406157")
+	assert.NoError(t, err)
+	assert.Equal(t, saved.ID, book.ID)
+
+	_, err = repo.SaveBook(context.Background(), &entity.Book{Title: "Eden 2", Author: "Stanislaw Lem", Year: 1959, ISBN: "978-0-306-40615-7"})
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err)
+
+	isbn := "978-0-306-40615-7"
+	_, err = repo.PatchBook(context.Background(), 3, 0, entity.BookPatch{ISBN: &isbn})
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err)
+
+	_, err = repo.GetBookByISBN(context.Background(), "9780804429573")
+	assert.Equal(t, errors.NewBookNotFoundByISBN("9780804429573"), err)
+
+	_, err = repo.DeleteBook(context.Background(), saved.ID)
+	assert.NoError(t, err)
+
+	_, err = repo.PatchBook(context.Background(), 3, 0, entity.BookPatch{ISBN: &isbn})
+	assert.NoError(t, err, "ISBN of a book in the trash should be free")
+
+	_, err = repo.RestoreBook(context.Background(), saved.ID)
+	assert.Equal(t, errors.NewBookISBNAlreadyExists("9780306406157"), err, "Book should not be restored while its ISBN is taken")
+}
+
+func TestBookDBRepository_SQLiteTags(t *testing.T) {
+	repo := newSQLiteRepo(t)
+
+	tags, err := repo.TagBook(context.Background(), 1, []string{"science fiction", "classic"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"classic", "science fiction"}, tags)
+
+	tags, err = repo.TagBook(context.Background(), 2, []string{"classic", "dystopia", "classic"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"classic", "dystopia"}, tags)
+
+	books, total, err := repo.GetBooksPage(context.Background(), repository.BookListQuery{Tags: []string{"dystopia", "science fiction"}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, withIDs(1, 2), books)
+
+	books, _, err = repo.GetBooksAfter(context.Background(), repository.BookListQuery{Tags: []string{"classic", "dystopia"}, AllTags: true, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(2), books)
+
+	counts, total, err := repo.GetTagCounts(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []entity.TagCount{{Tag: "classic", Books: 2}, {Tag: "dystopia", Books: 1}, {Tag: "science fiction", Books: 1}}, counts)
+
+	tags, err = repo.UntagBook(context.Background(), 2, []string{"classic"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dystopia"}, tags)
+
+	tags, err = repo.GetBookTags(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, tags)
+
+	_, err = repo.GetBookTags(context.Background(), 4)
+	assert.Equal(t, errors.NewBooksNotFound(), err)
+
+	_, err = repo.DeleteBook(context.Background(), 1)
+	assert.NoError(t, err)
+	_, err = repo.PurgeBooks(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+
+	counts, _, err = repo.GetTagCounts(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.TagCount{{Tag: "dystopia", Books: 1}}, counts, "Tags of purged books should be gone")
+}
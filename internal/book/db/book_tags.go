@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"log"
+)
+
+const (
+	QueryGetBookTags = `SELECT t.name FROM bookstore b LEFT JOIN book_tags bt ON bt.book_id = b.id
+		LEFT JOIN tags t ON t.id = bt.tag_id WHERE b.id=$1 AND b.deleted_at IS NULL ORDER BY t.name`
+	QueryInsertTag = `INSERT INTO tags (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
+	QueryTagBook   = `INSERT INTO book_tags (book_id, tag_id) VALUES ($1, (SELECT id FROM tags WHERE name=$2))
+		ON CONFLICT DO NOTHING`
+	QueryUntagBook = `DELETE FROM book_tags WHERE book_id=$1 AND tag_id IN (SELECT id FROM tags WHERE name=$2)`
+	QueryCountTags = `SELECT COUNT(DISTINCT bt.tag_id) FROM book_tags bt JOIN bookstore b ON b.id = bt.book_id
+		WHERE b.deleted_at IS NULL`
+	QueryGetTagCounts = `SELECT t.name, COUNT(*) AS books FROM tags t JOIN book_tags bt ON bt.tag_id = t.id
+		JOIN bookstore b ON b.id = bt.book_id WHERE b.deleted_at IS NULL
+		GROUP BY t.name ORDER BY books DESC, t.name LIMIT $1 OFFSET $2`
+)
+
+// GetBookTags returns tags of the live book in alphabetical order. Book without tags has an empty list
+func (r *BookDBRepository) GetBookTags(ctx context.Context, bookID uint64) ([]string, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	rows, err := r.database.QueryContext(ctx, r.query(QueryGetBookTags), bookID)
+	if err != nil {
+		log.Printf("Could not get tags of book id#%v: %v", bookID, err)
+		return nil, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	// The book joined with no tag gives a single row of NULL, so no rows at all means there is no book
+	var found bool
+	tags := []string{}
+
+	for rows.Next() {
+		var tag sql.NullString
+		if err = rows.Scan(&tag); err != nil {
+			log.Printf("Unable to scan the tag: %v", err)
+			return nil, errors.NewBookBadScanOptions(err.Error())
+		}
+
+		found = true
+		if tag.Valid {
+			tags = append(tags, tag.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read tags of book id#%v: %v", bookID, err)
+		return nil, queryError(ctx, err)
+	}
+
+	if !found {
+		log.Printf("Book id#%v not found", bookID)
+		return nil, errors.NewBooksNotFound()
+	}
+
+	return tags, nil
+}
+
+// TagBook adds tags to the live book, creating the ones which do not exist yet. Tags the book already has are left
+// as they are. Returned are all tags of the book after the change
+func (r *BookDBRepository) TagBook(ctx context.Context, bookID uint64, tags []string) ([]string, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	var bookTags []string
+
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		if _, err := repo.bookForUpdate(ctx, bookID); err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			if _, err := repo.database.ExecContext(ctx, repo.query(QueryInsertTag), tag); err != nil {
+				log.Printf("Unable to save tag %v: %v", tag, err)
+				return queryError(ctx, err)
+			}
+			if _, err := repo.database.ExecContext(ctx, repo.query(QueryTagBook), bookID, tag); err != nil {
+				log.Printf("Unable to tag book id#%v with %v: %v", bookID, tag, err)
+				return queryError(ctx, err)
+			}
+		}
+
+		var err error
+		bookTags, err = repo.GetBookTags(ctx, bookID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bookTags, nil
+}
+
+// UntagBook removes tags from the live book. Tags the book does not have are skipped. Returned are the tags left
+func (r *BookDBRepository) UntagBook(ctx context.Context, bookID uint64, tags []string) ([]string, error) {
+	if bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewBookInvalidSerial()
+	}
+
+	var bookTags []string
+
+	err := r.atomically(ctx, func(repo *BookDBRepository) error {
+		if _, err := repo.bookForUpdate(ctx, bookID); err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			if _, err := repo.database.ExecContext(ctx, repo.query(QueryUntagBook), bookID, tag); err != nil {
+				log.Printf("Unable to untag book id#%v from %v: %v", bookID, tag, err)
+				return queryError(ctx, err)
+			}
+		}
+
+		var err error
+		bookTags, err = repo.GetBookTags(ctx, bookID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bookTags, nil
+}
+
+// GetTagCounts returns tags of the limit/offset window, each with the number of live books having it, the most used
+// first, and the total count of such tags. Tags of books in the trash only are left out. Finding no tags is not an
+// error
+func (r *BookDBRepository) GetTagCounts(ctx context.Context, limit int, offset int) ([]entity.TagCount, int64, error) {
+	var total int64
+
+	if err := r.database.QueryRowContext(ctx, r.query(QueryCountTags)).Scan(&total); err != nil {
+		log.Printf("Could not count the tags: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	counts := []entity.TagCount{}
+	if total == 0 {
+		return counts, 0, nil
+	}
+
+	rows, err := r.database.QueryContext(ctx, r.query(QueryGetTagCounts), limit, offset)
+	if err != nil {
+		log.Printf("Could not get the tags: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var count entity.TagCount
+		if err = rows.Scan(&count.Tag, &count.Books); err != nil {
+			log.Printf("Unable to scan the tag: %v", err)
+			return nil, 0, errors.NewBookBadScanOptions(err.Error())
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the tags: %v", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return counts, total, nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"log"
+)
+
+// BookDBUnitOfWork runs repository calls in a database transaction
+type BookDBUnitOfWork struct {
+	database *sql.DB
+	dialect  database.Dialect
+}
+
+func NewBookUnitOfWork(db *sql.DB) BookDBUnitOfWork {
+	return BookDBUnitOfWork{database: db, dialect: database.DialectOf(db)}
+}
+
+var _ repository.BookUnitOfWork = &BookDBUnitOfWork{}
+
+func (u *BookDBUnitOfWork) Atomically(ctx context.Context, fn func(repository.BookRepository) error) error {
+	tx, err := u.database.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Could not begin the transaction: %v", err)
+		return queryError(ctx, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback(tx)
+			panic(p)
+		}
+	}()
+
+	if err = fn(&BookDBRepository{database: tx, dialect: u.dialect}); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Could not commit the transaction: %v", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+// rollback rolls tx back. Failure is only logged, since the error which caused the rollback is more telling
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Printf("Could not roll back the transaction: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestBookDBUnitOfWork_Atomically(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	uow := NewBookUnitOfWork(db)
+	book := entity.Book{Title: "test title", Author: "test author", Year: 1, Description: "test description"}
+	failure := goerrors.New("failure")
+
+	atomicallyMocks := []struct {
+		testName      string
+		mockFunc      func()
+		fn            func(repository.BookRepository) error
+		expectedError error
+	}{
+		{
+			testName: "Test Successful: Committed",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectLinkAuthors(mock, 1, "test author")
+				expectAudit(mock, 1, entity.BookActionCreate)
+				expectBookForUpdate(mock, 2, &entity.Book{ID: 2, Title: "test title", Author: "test author", Year: 1})
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteBook)).WithArgs(2, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, 2, entity.BookActionDelete)
+				mock.ExpectCommit()
+			},
+			fn: func(repo repository.BookRepository) error {
+				if _, err := repo.SaveBook(context.Background(), &book); err != nil {
+					return err
+				}
+				_, err := repo.DeleteBook(context.Background(), 2)
+				return err
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Rolled back on error",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveBook)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectLinkAuthors(mock, 1, "test author")
+				expectAudit(mock, 1, entity.BookActionCreate)
+				expectBookForUpdate(mock, 2, nil)
+				mock.ExpectRollback()
+			},
+			fn: func(repo repository.BookRepository) error {
+				if _, err := repo.SaveBook(context.Background(), &book); err != nil {
+					return err
+				}
+				_, err := repo.DeleteBook(context.Background(), 2)
+				return err
+			},
+			expectedError: errors.NewBooksNotFound(),
+		},
+		{
+			testName: "Test Unsuccessful: Could not begin",
+			mockFunc: func() {
+				mock.ExpectBegin().WillReturnError(failure)
+			},
+			fn: func(repo repository.BookRepository) error {
+				t.Error("Function should not be called without a transaction")
+				return nil
+			},
+			expectedError: errors.NewBookCouldNotQuery("failure"),
+		},
+		{
+			testName: "Test Unsuccessful: Could not commit",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(failure)
+			},
+			fn: func(repo repository.BookRepository) error {
+				return nil
+			},
+			expectedError: errors.NewBookCouldNotQuery("failure"),
+		},
+	}
+
+	for _, test := range atomicallyMocks {
+		t.Run(test.testName, func(t *testing.T) {
+			test.mockFunc()
+
+			err := uow.Atomically(context.Background(), test.fn)
+			assert.Equal(t, test.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBookDBUnitOfWork_Panic(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	uow := NewBookUnitOfWork(db)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assert.PanicsWithValue(t, "failure", func() {
+		_ = uow.Atomically(context.Background(), func(repository.BookRepository) error {
+			panic("failure")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
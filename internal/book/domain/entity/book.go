@@ -1,11 +1,97 @@
 package entity
 
+import "time"
+
 type Book struct {
 	ID          uint64 `json:"id,omitempty" binding:"omitempty,numeric,validID"`
 	Title       string `json:"title" binding:"required"`
 	Author      string `json:"author" binding:"required"`
 	Year        int    `json:"year" binding:"required,validYear"`
 	Description string `json:"description,omitempty"`
+	ISBN        string `json:"isbn,omitempty" binding:"omitempty,validISBN"` // ISBN-13 once stored, empty if unknown
+}
+
+// BookMatch is a book found by full-text search. Rank is relevance of the book to the query, Headline is a snippet
+// of the book with matched words wrapped in <b></b>
+type BookMatch struct {
+	Book
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+// BookCandidate is a book found by similarity search. Similarity is between 0 and 1, where 1 is an exact match
+type BookCandidate struct {
+	Book
+	Similarity float32 `json:"similarity"`
+}
+
+// VersionedBook is a book along with the version of its row. Version starts at 1 and grows with every update
+type VersionedBook struct {
+	Book
+	Version uint64 `json:"version"`
+}
+
+// DeletedBook is a book in the trash, kept until it is restored or purged
+type DeletedBook struct {
+	Book
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Actions recorded in the audit log of books
+const (
+	BookActionCreate  = "create"
+	BookActionUpdate  = "update"
+	BookActionPatch   = "patch"
+	BookActionDelete  = "delete"
+	BookActionRestore = "restore"
+	BookActionPurge   = "purge"
+)
+
+// BookChange is a record of the audit log: a mutation of a book, with the book before and after it. Old value is nil
+// for a created book, new value is nil for a deleted or purged one
+type BookChange struct {
+	ID        uint64    `json:"id"`
+	BookID    uint64    `json:"book_id"`
+	Action    string    `json:"action"`
+	OldValue  *Book     `json:"old_value"`
+	NewValue  *Book     `json:"new_value"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BookPatch holds the fields changed by a partial update. Nil fields are left as they are
+type BookPatch struct {
+	Title       *string
+	Author      *string
+	Year        *int
+	Description *string
+	ISBN        *string
+}
+
+// IsEmpty returns true if the patch changes no field
+func (p BookPatch) IsEmpty() bool {
+	return p.Title == nil && p.Author == nil && p.Year == nil && p.Description == nil && p.ISBN == nil
+}
+
+// Apply returns copy of book with the fields of the patch changed
+func (p BookPatch) Apply(book Book) Book {
+	if p.Title != nil {
+		book.Title = *p.Title
+	}
+	if p.Author != nil {
+		book.Author = *p.Author
+	}
+	if p.Year != nil {
+		book.Year = *p.Year
+	}
+	if p.Description != nil {
+		book.Description = *p.Description
+	}
+	if p.ISBN != nil {
+		book.ISBN = *p.ISBN
+	}
+	return book
 }
 
 // Equal returns true if all fields in receiver are same as in parameter
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TagCount is a tag along with the number of live books having it, which is what a tag cloud is built of
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Books int64  `json:"books"`
+}
+
+var tagSeparators = regexp.MustCompile(`\s+`)
+
+// NormalizeTag returns the form tags are stored and matched in: the lower case tag with runs of spaces collapsed into
+// single spaces, so "Science  Fiction" and "science fiction" are one tag. Genres are tags as well
+func NormalizeTag(tag string) string {
+	return strings.TrimSpace(tagSeparators.ReplaceAllString(strings.ToLower(tag), " "))
+}
@@ -350,3 +350,52 @@ func TestBook_IsValid(t *testing.T) {
 		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Book expected to be invalid, but found valid: %v", tc)
 	}
 }
+
+func TestBook_ISBN(t *testing.T) {
+	testCasesNormalized := map[string]string{
+		"978-0-306-40615-7": "9780306406157",
+		"9780306406157":     "9780306406157",
+		"0-306-40615-2":     "9780306406157",
+		"0 8044 2957 x":     "9780804429573",
+	}
+	testCasesInvalid := []string{
+		"978-0-306-40615-8",
+		"0-306-40615-3",
+		"030640615",
+		"97803064061570",
+		"X306406152",
+		"978030640615X",
+	}
+
+	for isbn, expected := range testCasesNormalized {
+		normalized, ok := validators.NormalizeISBN(isbn)
+		assert.True(t, ok, "ISBN expected to be valid, but found invalid: %v", isbn)
+		assert.Equal(t, expected, normalized)
+
+		book := Book{Title: "1", Author: "1", Year: 1, ISBN: isbn}
+		assert.True(t, binding.Validator.ValidateStruct(book) == nil, "Book expected to be valid, but found invalid: %v", book)
+	}
+	for _, isbn := range testCasesInvalid {
+		_, ok := validators.NormalizeISBN(isbn)
+		assert.False(t, ok, "ISBN expected to be invalid, but found valid: %v", isbn)
+
+		book := Book{Title: "1", Author: "1", Year: 1, ISBN: isbn}
+		assert.True(t, binding.Validator.ValidateStruct(book) != nil, "Book expected to be invalid, but found valid: %v", book)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	testCases := []struct {
+		tag         string
+		expectedTag string
+	}{
+		{tag: "Fantasy", expectedTag: "fantasy"},
+		{tag: "  Science \t Fiction ", expectedTag: "science fiction"},
+		{tag: "sci-fi", expectedTag: "sci-fi"},
+		{tag: " ", expectedTag: ""},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedTag, NormalizeTag(tc.tag), "Unexpected tag of %q", tc.tag)
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import "time"
+
+// SortField orders a listing by Column, in descending order if Desc is set
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset is the position of the last book of a page: values of its sort columns and its id
+type Keyset struct {
+	Values []string
+	ID     uint64
+}
+
+// BookListQuery describes the requested part of the catalogue. Books are always ordered by Sort and then by id,
+// so the order is total and pages never overlap
+type BookListQuery struct {
+	Author        string   // Exact author, empty means any
+	TitleContains string   // Case insensitive part of the title, empty means any
+	YearGTE       *int     // Inclusive lower bound of the year, nil means unbounded
+	YearLTE       *int     // Inclusive upper bound of the year, nil means unbounded
+	Tags          []string // Distinct normalized tags, the book has to have any of them. Empty means any book
+	AllTags       bool     // The book has to have every tag of Tags instead
+	Sort          []SortField
+	Limit         int
+	Offset        int     // Used by offset pagination only
+	After         *Keyset // Used by keyset pagination only, nil means the first page
+}
+
+// BookAuditQuery describes the requested part of the audit log. Records are ordered from the latest to the earliest
+type BookAuditQuery struct {
+	BookID    uint64     // Book the records are about, 0 means any
+	Action    string     // One of entity.BookAction..., empty means any
+	Actor     string     // Exact actor, empty means any
+	RequestID string     // Exact request id, empty means any
+	Since     *time.Time // Inclusive lower bound of the record time, nil means unbounded
+	Until     *time.Time // Exclusive upper bound of the record time, nil means unbounded
+	Limit     int
+	Offset    int
+}
@@ -1,14 +1,38 @@
 package repository
 
-import "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"time"
+)
 
+// BookRepository stores books. Every method gives up as soon as the context is done, so a query of a gone client
+// or over its deadline does not keep running. Deleted books go to the trash, where only trash methods can see them.
+// Every mutation is recorded in the audit log along with the actor and the request id the context carries. Tags are
+// not a part of the book, so tagging neither bumps its version nor is audited
 type BookRepository interface {
-	SaveBook(*entity.Book) (*entity.Book, error)
-	GetBook(uint64) (*entity.Book, error)
-	GetAllBooks() ([]entity.Book, error)
-	SearchByAuthor(string) ([]entity.Book, error) // An author can have multiple books
-	SearchByTitle(string) (*entity.Book, error)
-	UpdateBook(uint64, *entity.Book) (*entity.Book, error)
-	DeleteBook(uint64) (int64, error)
-	DeleteAllBooks() (int64, error)
+	SaveBook(context.Context, *entity.Book) (*entity.Book, error)
+	GetBook(context.Context, uint64) (*entity.VersionedBook, error)
+	GetBookByISBN(context.Context, string) (*entity.VersionedBook, error) // ISBN-13 without hyphens, the form books are stored with
+	GetAllBooks(context.Context) ([]entity.Book, error)
+	GetBooksPage(context.Context, BookListQuery) ([]entity.Book, int64, error)    // Books in the limit/offset window and their total count
+	GetBooksAfter(context.Context, BookListQuery) ([]entity.Book, *Keyset, error) // Books after the keyset and the keyset of the next page
+	ExportBooks(context.Context, BookListQuery, func(entity.Book) error) error    // Calls the function for every book matching filters, one by one
+	SearchByAuthor(context.Context, string) ([]entity.Book, error)                // An author can have multiple books
+	SearchByTitle(context.Context, string) (*entity.Book, error)
+	SearchByKeyword(context.Context, string, int) ([]entity.Book, error)                         // Up to limit books with keyword in title, author or description
+	FullTextSearch(context.Context, string, int, int) ([]entity.BookMatch, error)                // Ranked limit/offset window of books matching query
+	SearchSimilar(context.Context, string, string, float64, int) ([]entity.BookCandidate, error) // Up to limit books with field similar to value
+	UpdateBook(context.Context, uint64, uint64, *entity.Book) (*entity.VersionedBook, error)     // Replaces the book if its version is still the given one, 0 matches any
+	PatchBook(context.Context, uint64, uint64, entity.BookPatch) (*entity.VersionedBook, error)  // Changes only the patched fields, with the same version check
+	DeleteBook(context.Context, uint64) (int64, error)                                           // Moves the book to the trash
+	DeleteAllBooks(context.Context) (int64, error)                                               // Moves every book to the trash
+	GetTrash(context.Context, int, int) ([]entity.DeletedBook, int64, error)                     // Limit/offset window of the trash, the latest deleted first, and its total count
+	RestoreBook(context.Context, uint64) (*entity.VersionedBook, error)                          // Takes the book out of the trash
+	PurgeBooks(context.Context, time.Time) (int64, error)                                        // Removes for good books deleted before the time
+	GetAudit(context.Context, BookAuditQuery) ([]entity.BookChange, int64, error)                // Audit records matching query, the latest first, and their total count
+	GetBookTags(context.Context, uint64) ([]string, error)                                       // Tags of the book in alphabetical order
+	TagBook(context.Context, uint64, []string) ([]string, error)                                 // Adds normalized tags to the book and returns all its tags
+	UntagBook(context.Context, uint64, []string) ([]string, error)                               // Removes normalized tags from the book and returns the tags left
+	GetTagCounts(context.Context, int, int) ([]entity.TagCount, int64, error)                    // Limit/offset window of tags of live books, the most used first, and their total count
 }
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// BookUnitOfWork groups several repository calls into one transaction, so they take effect all together or not at all
+type BookUnitOfWork interface {
+	// Atomically calls fn with a repository whose calls belong to a single transaction. The transaction is committed
+	// when fn returns nil and rolled back when fn returns an error or panics. The error of fn is returned as is
+	Atomically(ctx context.Context, fn func(BookRepository) error) error
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var bookActions = map[string]bool{
+	entity.BookActionCreate:  true,
+	entity.BookActionUpdate:  true,
+	entity.BookActionPatch:   true,
+	entity.BookActionDelete:  true,
+	entity.BookActionRestore: true,
+	entity.BookActionPurge:   true,
+}
+
+// parseTime reads optional RFC 3339 query parameter. Nil is returned if the parameter is absent
+func parseTime(c *gin.Context, param string, fieldError ct.FieldError) (*time.Time, []ct.FieldError) {
+	value, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, []ct.FieldError{fieldError}
+	}
+
+	return &parsed, nil
+}
+
+// parseAuditQuery reads filters of the audit log
+func parseAuditQuery(c *gin.Context) (repository.BookAuditQuery, []ct.FieldError) {
+	var fieldErrors []ct.FieldError
+
+	query := repository.BookAuditQuery{
+		Action:    c.Query("action"),
+		Actor:     c.Query("actor"),
+		RequestID: c.Query("request_id"),
+	}
+
+	if value, ok := c.GetQuery("book_id"); ok {
+		bookID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || bookID == 0 {
+			fieldErrors = append(fieldErrors, validators.FieldBookIDInvalid)
+		}
+		query.BookID = bookID
+	}
+
+	if query.Action != "" && !bookActions[query.Action] {
+		fieldErrors = append(fieldErrors, validators.FieldActionInvalid)
+	}
+
+	since, timeErrors := parseTime(c, "since", validators.FieldSinceInvalid)
+	fieldErrors = append(fieldErrors, timeErrors...)
+
+	until, timeErrors := parseTime(c, "until", validators.FieldUntilInvalid)
+	fieldErrors = append(fieldErrors, timeErrors...)
+
+	if since != nil && until != nil && !until.After(*since) {
+		fieldErrors = append(fieldErrors, validators.FieldTimeRangeInvalid)
+	}
+
+	query.Since = since
+	query.Until = until
+
+	return query, fieldErrors
+}
+
+// GetBookHistory responds with a page of changes of one book, the latest first. History outlives the book, so it
+// can be read after the book is purged
+func (b *BookService) GetBookHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	query, fieldErrors := parseAuditQuery(c)
+	query.BookID = uint64(id)
+
+	b.respondAudit(c, query, fieldErrors)
+}
+
+// GetAudit responds with a page of changes of every book, the latest first, filtered by book_id, action, actor,
+// request_id and since/until time range
+func (b *BookService) GetAudit(c *gin.Context) {
+	query, fieldErrors := parseAuditQuery(c)
+
+	b.respondAudit(c, query, fieldErrors)
+}
+
+// respondAudit responds with the requested page of audit records matching query. Finding no records is not an error
+func (b *BookService) respondAudit(c *gin.Context, query repository.BookAuditQuery, fieldErrors []ct.FieldError) {
+	page, pageErrors := common_pagination.ParsePage(c)
+	fieldErrors = append(fieldErrors, pageErrors...)
+
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	query.Limit = page.Limit()
+	query.Offset = page.Offset()
+
+	ctx, cancel := operationContext(c, opList)
+	defer cancel()
+
+	changes, total, err := b.repo.GetAudit(ctx, query)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, changes, common_pagination.NewPageMeta(c, page, total))
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// Operations which may be given own query timeout under database.timeouts in config
+const (
+	opRead   = "read"
+	opWrite  = "write"
+	opList   = "list"
+	opSearch = "search"
+	opImport = "import"
+	opExport = "export"
+	opPurge  = "purge"
+)
+
+// DefaultQueryTimeout is used when neither timeout of the operation nor database.timeouts.default is configured
+const DefaultQueryTimeout = 5 * time.Second
+
+// queryTimeout returns configured timeout of the operation. Zero or negative timeout disables the deadline
+func queryTimeout(op string) time.Duration {
+	if key := "database.timeouts." + op; viper.IsSet(key) {
+		return viper.GetDuration(key)
+	}
+	if viper.IsSet("database.timeouts.default") {
+		return viper.GetDuration("database.timeouts.default")
+	}
+	return DefaultQueryTimeout
+}
+
+// operationContext derives the context of a repository call from the request, so the query is cancelled when the
+// client goes away or the operation runs out of time
+func operationContext(c *gin.Context, op string) (context.Context, context.CancelFunc) {
+	timeout := queryTimeout(op)
+	if timeout <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), timeout)
+}
@@ -1,25 +1,31 @@
 package controllers
 
 import (
-	"database/sql"
-	bookDB "github.com/foxfurry/simple-rest/internal/book/db"
 	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
 	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
 	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
 	"github.com/gin-gonic/gin"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
+// BookService serves the book routes. Single repository calls go through repo, while calls which have to take effect
+// together are grouped by uow
 type BookService struct {
-	dbRepo bookDB.BookDBRepository
+	repo repository.BookRepository
+	uow  repository.BookUnitOfWork
 }
 
-func NewBookService(db *sql.DB) BookService {
+func NewBookService(repo repository.BookRepository, uow repository.BookUnitOfWork) BookService {
 	return BookService{
-		dbRepo: bookDB.NewBookRepo(db),
+		repo: repo,
+		uow:  uow,
 	}
 }
 
@@ -36,7 +42,10 @@ func (b *BookService) SaveBook(c *gin.Context) {
 		}
 	}
 
-	saveBook, err := b.dbRepo.SaveBook(&book)
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	saveBook, err := b.repo.SaveBook(ctx, &book)
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
@@ -54,49 +63,163 @@ func (b *BookService) GetBook(c *gin.Context) {
 		return
 	}
 
-	getBook, err := b.dbRepo.GetBook(uint64(id))
+	ctx, cancel := operationContext(c, opRead)
+	defer cancel()
+
+	getBook, err := b.repo.GetBook(ctx, uint64(id))
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
 	}
 
-	common_response.Respond(c, http.StatusOK, getBook, nil)
+	c.Header("ETag", bookETag(getBook.Version))
+	common_response.Respond(c, http.StatusOK, getBook.Book, nil)
 }
 
+// GetAllBooks responds with a single page of books, so the whole catalogue is never loaded at once. Passing cursor
+// parameter switches from page numbers to keyset pagination
 func (b *BookService) GetAllBooks(c *gin.Context) {
-	allBooks, err := b.dbRepo.GetAllBooks()
+	query, fieldErrors := parseListQuery(c)
+
+	if common_pagination.CursorRequested(c) {
+		if fieldErrors != nil {
+			errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+			return
+		}
+		b.respondBooksAfter(c, query)
+		return
+	}
+
+	page, pageErrors := common_pagination.ParsePage(c)
+	fieldErrors = append(fieldErrors, pageErrors...)
+
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	query.Limit = page.Limit()
+	query.Offset = page.Offset()
+
+	ctx, cancel := operationContext(c, opList)
+	defer cancel()
+
+	pageBooks, total, err := b.repo.GetBooksPage(ctx, query)
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
 	}
 
-	common_response.Respond(c, http.StatusOK, allBooks, nil)
+	common_response.RespondWithMeta(c, http.StatusOK, pageBooks, common_pagination.NewPageMeta(c, page, total))
 }
 
+// SearchByAuthor responds with all the books of the author, or with a keyset paginated page of them if cursor
+// parameter is passed. With fuzzy=true it responds with books of similarly named authors instead
 func (b *BookService) SearchByAuthor(c *gin.Context) {
 	author := c.Param("author")
 
-	booksByAuthor, err := b.dbRepo.SearchByAuthor(author)
+	if fuzzyRequested(c) && author != "" {
+		b.respondSimilar(c, "author", author)
+		return
+	}
+
+	if common_pagination.CursorRequested(c) && author != "" {
+		query, fieldErrors := parseListQuery(c)
+		if fieldErrors != nil {
+			errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+			return
+		}
+
+		query.Author = author
+		b.respondBooksAfter(c, query)
+		return
+	}
+
+	ctx, cancel := operationContext(c, opSearch)
+	defer cancel()
+
+	booksByAuthor, err := b.repo.SearchByAuthor(ctx, author)
 	if err != nil {
-		errors.HandleBookError(c, err)
+		errors.HandleBookError(c, b.suggest(ctx, err, "author", author))
 		return
 	}
 
 	common_response.Respond(c, http.StatusOK, booksByAuthor, nil)
 }
 
+// SearchByTitle responds with the book of exact title. With fuzzy=true it responds with books of similar titles
 func (b *BookService) SearchByTitle(c *gin.Context) {
 	title := c.Param("title")
 
-	bookByTitle, err := b.dbRepo.SearchByTitle(title)
+	if fuzzyRequested(c) && title != "" {
+		b.respondSimilar(c, "title", title)
+		return
+	}
+
+	ctx, cancel := operationContext(c, opSearch)
+	defer cancel()
+
+	bookByTitle, err := b.repo.SearchByTitle(ctx, title)
 	if err != nil {
-		errors.HandleBookError(c, err)
+		errors.HandleBookError(c, b.suggest(ctx, err, "title", title))
 		return
 	}
 
 	common_response.Respond(c, http.StatusOK, bookByTitle, nil)
 }
 
+// GetBookByISBN responds with the book of the ISBN. ISBN-10 and ISBN-13 both find it, with or without hyphens
+func (b *BookService) GetBookByISBN(c *gin.Context) {
+	isbn, ok := validators.NormalizeISBN(c.Param("isbn"))
+	if !ok {
+		errors.HandleBookError(c, errors.NewBookValidatorError([]common_translators.FieldError{validators.FieldISBNParamInvalid}))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opRead)
+	defer cancel()
+
+	getBook, err := b.repo.GetBookByISBN(ctx, isbn)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	c.Header("ETag", bookETag(getBook.Version))
+	common_response.Respond(c, http.StatusOK, getBook.Book, nil)
+}
+
+// FullTextSearch responds with a page of books matching q parameter, ranked by relevance
+func (b *BookService) FullTextSearch(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+
+	var fieldErrors []common_translators.FieldError
+	if query == "" {
+		fieldErrors = append(fieldErrors, validators.FieldQueryEmpty)
+	}
+
+	page, pageErrors := common_pagination.ParsePage(c)
+	fieldErrors = append(fieldErrors, pageErrors...)
+
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opSearch)
+	defer cancel()
+
+	matches, err := b.repo.FullTextSearch(ctx, query, page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, matches, nil)
+}
+
+// UpdateBook replaces the book. If-Match header must carry the ETag of the book the client edited, so a write based
+// on a stale copy is rejected instead of silently overwriting someone else's
 func (b *BookService) UpdateBook(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -106,6 +229,12 @@ func (b *BookService) UpdateBook(c *gin.Context) {
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
 	var book entity.Book
 
 	if err = c.ShouldBindJSON(&book); err != nil {
@@ -118,13 +247,17 @@ func (b *BookService) UpdateBook(c *gin.Context) {
 		}
 	}
 
-	updatedBook, err := b.dbRepo.UpdateBook(uint64(id), &book)
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	updatedBook, err := b.repo.UpdateBook(ctx, uint64(id), version, &book)
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
 	}
 
-	common_response.Respond(c, http.StatusOK, updatedBook, nil)
+	c.Header("ETag", bookETag(updatedBook.Version))
+	common_response.Respond(c, http.StatusOK, updatedBook.Book, nil)
 }
 
 func (b *BookService) DeleteBook(c *gin.Context) {
@@ -136,7 +269,10 @@ func (b *BookService) DeleteBook(c *gin.Context) {
 		return
 	}
 
-	_, err = b.dbRepo.DeleteBook(uint64(id))
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	_, err = b.repo.DeleteBook(ctx, uint64(id))
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
@@ -146,7 +282,10 @@ func (b *BookService) DeleteBook(c *gin.Context) {
 }
 
 func (b *BookService) DeleteAllBooks(c *gin.Context) {
-	deletedRows, err := b.dbRepo.DeleteAllBooks()
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	deletedRows, err := b.repo.DeleteAllBooks(ctx)
 	if err != nil {
 		errors.HandleBookError(c, err)
 		return
@@ -2,15 +2,21 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"github.com/DATA-DOG/go-sqlmock"
 	bookdb "github.com/foxfurry/simple-rest/internal/book/db"
 	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/errors"
 	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
 	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"log"
@@ -18,11 +24,13 @@ import (
 	"net/http/httptest"
 	"regexp"
 	"testing"
+	"time"
 )
 
 type expectedErrors struct {
-	Msg    string                          `json:"msg,omitempty"`
-	Fields []common_translators.FieldError `json:"fields,omitempty"`
+	Msg        string                          `json:"msg,omitempty"`
+	Fields     []common_translators.FieldError `json:"fields,omitempty"`
+	Suggestion string                          `json:"did_you_mean,omitempty"`
 }
 
 type singleResponse struct {
@@ -36,12 +44,13 @@ type rowsResponse struct {
 }
 
 type arrayResponse struct {
-	Data  []entity.Book  `json:"data"`
-	Error expectedErrors `json:"error"`
+	Data  []entity.Book               `json:"data"`
+	Error expectedErrors              `json:"error"`
+	Meta  *common_pagination.PageMeta `json:"meta"`
 }
 
 func (e expectedErrors) isEmpty() bool {
-	return e.Msg == "" && e.Fields == nil
+	return e.Msg == "" && e.Fields == nil && e.Suggestion == ""
 }
 
 func init() {
@@ -57,11 +66,58 @@ func newMock() (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// fakeUnitOfWork runs fn with repo directly and counts how the units ended, so atomic operations can be tested
+// without expecting BEGIN and COMMIT on the mock
+type fakeUnitOfWork struct {
+	repo       repository.BookRepository
+	committed  int
+	rolledBack int
+}
+
+func (u *fakeUnitOfWork) Atomically(ctx context.Context, fn func(repository.BookRepository) error) error {
+	if err := fn(u.repo); err != nil {
+		u.rolledBack++
+		return err
+	}
+	u.committed++
+	return nil
+}
+
+func newBookService(db *sql.DB) BookService {
+	repo := bookdb.NewBookRepo(db)
+	return NewBookService(&repo, &fakeUnitOfWork{repo: &repo})
+}
+
+// expectLocked expects book bookID to be found and locked before it is changed, or not to be found
+func expectLocked(mock sqlmock.Sqlmock, bookID uint64, found bool) {
+	rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+	if found {
+		rows.AddRow(bookID, "Test", "Test", 1, "Test", "")
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBookForUpdate)).WithArgs(bookID).WillReturnRows(rows)
+}
+
+// expectAudit expects the audit record of a change of book bookID
+func expectAudit(mock sqlmock.Sqlmock, bookID uint64, action string) {
+	mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryInsertBookAudit)).
+		WithArgs(bookID, action, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectLinkAuthors expects authors with names to be saved and linked to book bookID in the given order
+func expectLinkAuthors(mock sqlmock.Sqlmock, bookID uint64, names ...string) {
+	for idx, name := range names {
+		mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryUpsertBookAuthor)).WithArgs(name, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(idx + 1))
+		mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryLinkBookAuthor)).WithArgs(bookID, idx+1, idx).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
 func TestBookService_SaveBook(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	saveUrl := "/book"
 	saveMethod := "POST"
@@ -82,7 +138,11 @@ func TestBookService_SaveBook(t *testing.T) {
 			testName: "Test Successful",
 			mockFunc: func() {
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
-				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySaveBook)).WithArgs("Test 1", "Test 1", 1, "Test 1").WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySaveBook)).WithArgs("Test 1", "Test 1", 1, "Test 1", "").WillReturnRows(rows)
+				expectLinkAuthors(mock, 1, "Test 1")
+				expectAudit(mock, 1, entity.BookActionCreate)
+				mock.ExpectCommit()
 			},
 			service: repo,
 			requestBody: &entity.Book{
@@ -217,7 +277,7 @@ func TestBookService_GetBook(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	getMethod := "GET"
 	getURL := "/book"
@@ -237,7 +297,7 @@ func TestBookService_GetBook(t *testing.T) {
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).AddRow(1, "Test 1", "Test 1", 1, "Test 1")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "Test 1", "Test 1", 1, "Test 1", "", 3)
 				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillReturnRows(rows)
 			},
 			service: repo,
@@ -258,6 +318,7 @@ func TestBookService_GetBook(t *testing.T) {
 			},
 			expectedHeader: map[string]string{
 				"Content-Type": "application/json; charset=utf-8",
+				"ETag":         `"3"`,
 			},
 		},
 		{
@@ -295,7 +356,7 @@ func TestBookService_GetBook(t *testing.T) {
 		{
 			testName: "Test Unsuccessful: Book not found",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"})
 				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(666).WillReturnRows(rows)
 			},
 			service: repo,
@@ -384,11 +445,147 @@ func TestBookService_GetBook(t *testing.T) {
 	}
 }
 
+func TestBookService_GetBookByISBN(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := newBookService(db)
+
+	getBookServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		isbn           string
+		expectedStatus int
+		expectedBody   *entity.Book
+		expectedError  expectedErrors
+		expectedHeader map[string]string
+	}{
+		{
+			testName: "Test Successful: ISBN-10 with hyphens",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "Test 1", "Test 1", 1, "Test 1", "9780306406157", 2)
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBookByISBN)).WithArgs("9780306406157").WillReturnRows(rows)
+			},
+			isbn:           "0-306-40615-2",
+			expectedStatus: http.StatusOK,
+			expectedBody: &entity.Book{
+				Title:       "Test 1",
+				Author:      "Test 1",
+				Year:        1,
+				Description: "Test 1",
+				ISBN:        "9780306406157",
+			},
+			expectedHeader: map[string]string{
+				"ETag": `"2"`,
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid check digit",
+			isbn:           "978-0-306-40615-8",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldISBNParamInvalid},
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Book not found",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"})
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBookByISBN)).WithArgs("9780306406157").WillReturnRows(rows)
+			},
+			isbn:           "9780306406157",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookNotFoundByISBN("9780306406157").Error(),
+			},
+		},
+	}
+
+	for _, tc := range getBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/book/isbn/"+tc.isbn, nil)
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{{Key: "isbn", Value: tc.isbn}}
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			service.GetBookByISBN(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			if tc.expectedBody != nil {
+				assert.True(t, tc.expectedBody.EqualNoID(*resultBody.Data), "Values are not equal:\nExpected: %+v\nActual: %+v", tc.expectedBody, resultBody.Data)
+			} else if resultBody.Data != nil {
+				t.Errorf("Expected result body to be nil, found %+v", resultBody.Data)
+			}
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+
+			for header, expected := range tc.expectedHeader {
+				assert.Equal(t, expected, w.Header().Get(header))
+			}
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookService_QueryTimeout(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	viper.Set("database.timeouts.read", "10ms")
+	defer viper.Set("database.timeouts.read", nil)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).AddRow(1, "Test 1", "Test 1", 1, "Test 1", "", 1)
+	mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillDelayFor(time.Second).WillReturnRows(rows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/book", nil)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+	service := newBookService(db)
+	service.GetBook(c)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	resultBody := singleResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+		t.Errorf("Unable to unmarshal the body")
+	}
+	assert.Nil(t, resultBody.Data)
+	assert.Equal(t, expectedErrors{Msg: errors.NewBookQueryTimeout().Error()}, resultBody.Error)
+}
+
+func TestQueryTimeout(t *testing.T) {
+	defer viper.Set("database.timeouts.default", nil)
+	defer viper.Set("database.timeouts.write", nil)
+
+	assert.Equal(t, DefaultQueryTimeout, queryTimeout(opWrite))
+
+	viper.Set("database.timeouts.default", "2s")
+	assert.Equal(t, 2*time.Second, queryTimeout(opWrite))
+
+	viper.Set("database.timeouts.write", "500ms")
+	assert.Equal(t, 500*time.Millisecond, queryTimeout(opWrite))
+	assert.Equal(t, 2*time.Second, queryTimeout(opRead))
+}
+
 func TestBookService_GetAllBooks(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	getAllMethod := "GET"
 	getAllURL := "/book"
@@ -402,16 +599,18 @@ func TestBookService_GetAllBooks(t *testing.T) {
 		expectedStatus    int
 		expectedBodyArray []entity.Book
 		expectedError     expectedErrors
+		expectedMeta      *common_pagination.PageMeta
 		expectedHeader    map[string]string
 	}{
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "Test 1", "Test 1", 1, "Test 1").
-					AddRow(2, "Test 2", "Test 2", 2, "Test 2").
-					AddRow(3, "Test 3", "Test 3", 3, "Test 3")
-				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetAll)).WillReturnRows(rows)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "Test 1", "Test 1", 1, "Test 1", "").
+					AddRow(2, "Test 2", "Test 2", 2, "Test 2", "").
+					AddRow(3, "Test 3", "Test 3", 3, "Test 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`)).WithArgs(common_pagination.DefaultPerPage, 0).WillReturnRows(rows)
 			},
 			service:        repo,
 			url:            getAllURL,
@@ -437,6 +636,11 @@ func TestBookService_GetAllBooks(t *testing.T) {
 					Description: "Test 3",
 				},
 			},
+			expectedMeta: &common_pagination.PageMeta{
+				Page:    1,
+				PerPage: common_pagination.DefaultPerPage,
+				Total:   3,
+			},
 			expectedHeader: map[string]string{
 				"Content-Type": "application/json; charset=utf-8",
 			},
@@ -444,8 +648,7 @@ func TestBookService_GetAllBooks(t *testing.T) {
 		{
 			testName: "Test Unsuccessful: Book(s) not found",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
-				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetAll)).WillReturnRows(rows)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 			},
 			service:           repo,
 			url:               getAllURL,
@@ -459,6 +662,123 @@ func TestBookService_GetAllBooks(t *testing.T) {
 				"Content-Type": "application/json; charset=utf-8",
 			},
 		},
+		{
+			testName: "Test Successful: Middle page",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL`)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "Test 3", "Test 3", 3, "Test 3", "").
+					AddRow(4, "Test 4", "Test 4", 4, "Test 4", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`)).WithArgs(2, 2).WillReturnRows(rows)
+			},
+			service:        repo,
+			url:            getAllURL + "?page=2&per_page=2",
+			method:         getAllMethod,
+			expectedStatus: http.StatusOK,
+			expectedBodyArray: []entity.Book{
+				{
+					Title:       "Test 3",
+					Author:      "Test 3",
+					Year:        3,
+					Description: "Test 3",
+				},
+				{
+					Title:       "Test 4",
+					Author:      "Test 4",
+					Year:        4,
+					Description: "Test 4",
+				},
+			},
+			expectedMeta: &common_pagination.PageMeta{
+				Page:    2,
+				PerPage: 2,
+				Total:   5,
+				Next:    getAllURL + "?page=3&per_page=2",
+				Prev:    getAllURL + "?page=1&per_page=2",
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Successful: Filtered and sorted",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM bookstore WHERE deleted_at IS NULL AND id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=$1) AND title ILIKE $2 AND year >= $3 AND year <= $4`)).
+					WithArgs("test", "%50\\%%", 1900, 2000).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "Test 50%", "Test", 1950, "Test 1", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=$1) AND title ILIKE $2 AND year >= $3 AND year <= $4 ORDER BY year DESC, title, id LIMIT $5 OFFSET $6`)).
+					WithArgs("test", "%50\\%%", 1900, 2000, common_pagination.DefaultPerPage, 0).WillReturnRows(rows)
+			},
+			service:        repo,
+			url:            getAllURL + "?author=Test&title_contains=50%25&year_gte=1900&year_lte=2000&sort=-year,title",
+			method:         getAllMethod,
+			expectedStatus: http.StatusOK,
+			expectedBodyArray: []entity.Book{
+				{
+					Title:       "Test 50%",
+					Author:      "Test",
+					Year:        1950,
+					Description: "Test 1",
+				},
+			},
+			expectedMeta: &common_pagination.PageMeta{
+				Page:    1,
+				PerPage: common_pagination.DefaultPerPage,
+				Total:   1,
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid filters",
+			service:        repo,
+			url:            getAllURL + "?year_gte=abc&year_lte=2000&sort=year,-year,description",
+			method:         getAllMethod,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldYearGTEInvalid,
+					validators.NewFieldSortRepeated("year"),
+					validators.NewFieldSortInvalid("description"),
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty year range",
+			service:        repo,
+			url:            getAllURL + "?year_gte=2000&year_lte=1900",
+			method:         getAllMethod,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldYearRangeInvalid,
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid page",
+			service:        repo,
+			url:            getAllURL + "?page=0&per_page=1000",
+			method:         getAllMethod,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					common_pagination.FieldPageInvalid,
+					common_pagination.FieldPerPageInvalid,
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
 		{
 			testName: "Test Unsuccessful: DB is closed",
 			mockFunc: func() {
@@ -512,6 +832,7 @@ func TestBookService_GetAllBooks(t *testing.T) {
 			} else if resultBody.Data != nil {
 				t.Errorf("Expected result body to be nil, found %+v", resultBody.Data)
 			}
+			assert.Equal(t, tc.expectedMeta, resultBody.Meta)
 			if !tc.expectedError.isEmpty() {
 				assert.Equal(t, tc.expectedError, resultBody.Error, "Values are not equal:\nExpected: %+v\nActual: %+v", tc.expectedError, resultBody.Error)
 			} else if !resultBody.Error.isEmpty() {
@@ -521,123 +842,421 @@ func TestBookService_GetAllBooks(t *testing.T) {
 	}
 }
 
-func TestBookService_SearchByAuthor(t *testing.T) {
+func TestBookService_GetAllBooksCursor(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
-	searchAuthorMethod := "GET"
-	searchAuthorURL := "/book/author"
+	getAllURL := "/book"
+	nextCursor := common_pagination.EncodeCursor(bookCursor{Sort: "-year", Values: []string{"2"}, ID: 2})
 
-	searchByAuthorServiceMocks := []struct {
+	getAllCursorMocks := []struct {
 		testName          string
 		mockFunc          func()
-		service           BookService
-		method            string
-		params            []gin.Param
 		url               string
 		expectedStatus    int
 		expectedBodyArray []entity.Book
 		expectedError     expectedErrors
-		expectedHeader    map[string]string
+		expectedMeta      *common_pagination.CursorMeta
 	}{
 		{
-			testName: "Test Successful",
+			testName: "Test Successful: First page",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "Test 1", "Test", 1, "Test 1").
-					AddRow(2, "Test 2", "Test", 2, "Test 2").
-					AddRow(3, "Test 3", "Test", 3, "Test 3")
-				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByAuthorBook)).WithArgs("Test").WillReturnRows(rows)
-			},
-			service: repo,
-			url:     searchAuthorURL,
-			method:  searchAuthorMethod,
-			params: []gin.Param{
-				{
-					Key:   "author",
-					Value: "Test",
-				},
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(3, "Test 3", "Test 3", 3, "Test 3", "").
+					AddRow(2, "Test 2", "Test 2", 2, "Test 2", "").
+					AddRow(1, "Test 1", "Test 1", 1, "Test 1", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL ORDER BY year DESC, id LIMIT $1`)).WithArgs(3).WillReturnRows(rows)
 			},
+			url:            getAllURL + "?cursor=&per_page=2&sort=-year",
 			expectedStatus: http.StatusOK,
 			expectedBodyArray: []entity.Book{
 				{
-					Title:       "Test 1",
-					Author:      "Test",
-					Year:        1,
-					Description: "Test 1",
+					Title:       "Test 3",
+					Author:      "Test 3",
+					Year:        3,
+					Description: "Test 3",
 				},
 				{
 					Title:       "Test 2",
-					Author:      "Test",
+					Author:      "Test 2",
 					Year:        2,
 					Description: "Test 2",
 				},
-				{
-					Title:       "Test 3",
-					Author:      "Test",
-					Year:        3,
-					Description: "Test 3",
-				},
 			},
-			expectedHeader: map[string]string{
-				"Content-Type": "application/json; charset=utf-8",
+			expectedMeta: &common_pagination.CursorMeta{
+				PerPage:    2,
+				NextCursor: nextCursor,
+				Next:       getAllURL + "?cursor=" + nextCursor + "&per_page=2&sort=-year",
 			},
 		},
 		{
-			testName: "Test Unsuccessful: Book(s) not found",
+			testName: "Test Successful: Last page",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
-				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByAuthorBook)).WithArgs("Test").WillReturnRows(rows)
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "Test 1", "Test 1", 1, "Test 1", "")
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND ((year < $1) OR (year = $1 AND id > $2)) ORDER BY year DESC, id LIMIT $3`)).
+					WithArgs("2", 2, 3).WillReturnRows(rows)
 			},
-			service: repo,
-			url:     searchAuthorURL,
-			method:  searchAuthorMethod,
-			params: []gin.Param{
+			url:            getAllURL + "?cursor=" + nextCursor + "&per_page=2",
+			expectedStatus: http.StatusOK,
+			expectedBodyArray: []entity.Book{
 				{
-					Key:   "author",
-					Value: "Test",
+					Title:       "Test 1",
+					Author:      "Test 1",
+					Year:        1,
+					Description: "Test 1",
 				},
 			},
-			expectedStatus: http.StatusNotFound,
-			expectedError: expectedErrors{
-				Msg: errors.NewBookNotFoundByAuthor("Test").Error(),
-			},
-			expectedHeader: map[string]string{
-				"Content-Type": "application/json; charset=utf-8",
+			expectedMeta: &common_pagination.CursorMeta{
+				PerPage: 2,
 			},
 		},
 		{
-			testName:       "Test Unsuccessful: Invalid author",
-			service:        repo,
-			url:            searchAuthorURL,
-			method:         searchAuthorMethod,
+			testName:       "Test Unsuccessful: Cursor of another order",
+			url:            getAllURL + "?cursor=" + nextCursor + "&sort=title",
 			expectedStatus: http.StatusBadRequest,
 			expectedError: expectedErrors{
 				Fields: []common_translators.FieldError{
-					validators.FieldAuthorEmpty,
+					common_pagination.FieldCursorInvalid,
 				},
 			},
-			expectedHeader: map[string]string{
-				"Content-Type": "application/json; charset=utf-8",
-			},
 		},
 		{
-			testName: "Test Unsuccessful: DB is closed",
-			service:  repo,
-			mockFunc: func() {
-				db.Close()
-			},
-			url:    searchAuthorURL,
-			method: searchAuthorMethod,
-			params: []gin.Param{
-				{
-					Key:   "author",
-					Value: "Test 1",
+			testName:       "Test Unsuccessful: Invalid sort",
+			url:            getAllURL + "?cursor=&sort=description",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.NewFieldSortInvalid("description"),
 				},
 			},
-			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range getAllCursorMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			repo.GetAllBooks(c)
+
+			resultBody := &struct {
+				Data  []entity.Book                 `json:"data"`
+				Error expectedErrors                `json:"error"`
+				Meta  *common_pagination.CursorMeta `json:"meta"`
+			}{}
+
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedBodyArray != nil {
+				assert.True(t, entity.BookArrayEqualNoID(tc.expectedBodyArray, resultBody.Data), "Values are not equal:\nExpected: %+v\nActual: %+v", tc.expectedBodyArray, resultBody.Data)
+			}
+			assert.Equal(t, tc.expectedMeta, resultBody.Meta)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestBookService_FullTextSearch(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	searchURL := "/book/search"
+
+	fullTextSearchMocks := []struct {
+		testName       string
+		mockFunc       func()
+		url            string
+		expectedStatus int
+		expectedBody   []entity.BookMatch
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "rank", "headline"}).
+					AddRow(1, "Test 1", "Test 1", 1, "Test 1", "", 0.5, "<b>Test</b> 1")
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryFullTextSearchBook)).WithArgs("test", 2, 2).WillReturnRows(rows)
+			},
+			url:            searchURL + "?q=+test+&page=2&per_page=2",
+			expectedStatus: http.StatusOK,
+			expectedBody: []entity.BookMatch{
+				{
+					Book: entity.Book{
+						ID:          1,
+						Title:       "Test 1",
+						Author:      "Test 1",
+						Year:        1,
+						Description: "Test 1",
+					},
+					Rank:     0.5,
+					Headline: "<b>Test</b> 1",
+				},
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Nothing matches",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "rank", "headline"})
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryFullTextSearchBook)).WithArgs("test", common_pagination.DefaultPerPage, 0).WillReturnRows(rows)
+			},
+			url:            searchURL + "?q=test",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookNothingMatches("test").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty query",
+			url:            searchURL + "?q=+",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldQueryEmpty,
+				},
+			},
+		},
+	}
+
+	for _, tc := range fullTextSearchMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			repo.FullTextSearch(c)
+
+			resultBody := &struct {
+				Data  []entity.BookMatch `json:"data"`
+				Error expectedErrors     `json:"error"`
+			}{}
+
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestBookService_SearchByAuthor(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	searchAuthorMethod := "GET"
+	searchAuthorURL := "/book/author"
+
+	searchByAuthorServiceMocks := []struct {
+		testName          string
+		mockFunc          func()
+		service           BookService
+		method            string
+		params            []gin.Param
+		url               string
+		expectedStatus    int
+		expectedBodyArray []entity.Book
+		expectedError     expectedErrors
+		expectedHeader    map[string]string
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "Test 1", "Test", 1, "Test 1", "").
+					AddRow(2, "Test 2", "Test", 2, "Test 2", "").
+					AddRow(3, "Test 3", "Test", 3, "Test 3", "")
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByAuthorBook)).WithArgs("test").WillReturnRows(rows)
+			},
+			service: repo,
+			url:     searchAuthorURL,
+			method:  searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Test",
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBodyArray: []entity.Book{
+				{
+					Title:       "Test 1",
+					Author:      "Test",
+					Year:        1,
+					Description: "Test 1",
+				},
+				{
+					Title:       "Test 2",
+					Author:      "Test",
+					Year:        2,
+					Description: "Test 2",
+				},
+				{
+					Title:       "Test 3",
+					Author:      "Test",
+					Year:        3,
+					Description: "Test 3",
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Book(s) not found",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByAuthorBook)).WithArgs("test").WillReturnRows(rows)
+				candidates := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "score"})
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySimilarByAuthorBook)).WithArgs("Test", DefaultSimilarityThreshold, 1).WillReturnRows(candidates)
+			},
+			service: repo,
+			url:     searchAuthorURL,
+			method:  searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Test",
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookNotFoundByAuthor("Test").Error(),
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Book(s) not found, did you mean",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByAuthorBook)).WithArgs("tset").WillReturnRows(rows)
+				candidates := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "score"}).
+					AddRow(1, "Test 1", "Test", 1, "Test 1", "", 0.4)
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySimilarByAuthorBook)).WithArgs("Tset", DefaultSimilarityThreshold, 1).WillReturnRows(candidates)
+			},
+			service: repo,
+			url:     searchAuthorURL,
+			method:  searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Tset",
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg:        errors.NewBookNotFoundByAuthor("Tset").Error(),
+				Suggestion: "Test",
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Successful: Fuzzy",
+			mockFunc: func() {
+				candidates := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "score"}).
+					AddRow(1, "Test 1", "Test", 1, "Test 1", "", 0.4)
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySimilarByAuthorBook)).WithArgs("Tset", 0.25, 20).WillReturnRows(candidates)
+			},
+			service: repo,
+			url:     searchAuthorURL + "?fuzzy=true&threshold=0.25",
+			method:  searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Tset",
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBodyArray: []entity.Book{
+				{
+					Title:       "Test 1",
+					Author:      "Test",
+					Year:        1,
+					Description: "Test 1",
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Fuzzy with invalid threshold",
+			service:  repo,
+			url:      searchAuthorURL + "?fuzzy=true&threshold=2",
+			method:   searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Tset",
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldThresholdInvalid,
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid author",
+			service:        repo,
+			url:            searchAuthorURL,
+			method:         searchAuthorMethod,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldAuthorEmpty,
+				},
+			},
+			expectedHeader: map[string]string{
+				"Content-Type": "application/json; charset=utf-8",
+			},
+		},
+		{
+			testName: "Test Unsuccessful: DB is closed",
+			service:  repo,
+			mockFunc: func() {
+				db.Close()
+			},
+			url:    searchAuthorURL,
+			method: searchAuthorMethod,
+			params: []gin.Param{
+				{
+					Key:   "author",
+					Value: "Test 1",
+				},
+			},
+			expectedStatus: http.StatusInternalServerError,
 			expectedError: expectedErrors{
 				Msg: errors.NewBookCouldNotQuery("sql: database is closed").Error(),
 			},
@@ -695,7 +1314,7 @@ func TestBookService_SearchByTitle(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	searchTitleMethod := "GET"
 	searchTitleURL := "/book/title"
@@ -715,8 +1334,8 @@ func TestBookService_SearchByTitle(t *testing.T) {
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"}).
-					AddRow(1, "Test 1", "Test 1", 1, "Test 1")
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+					AddRow(1, "Test 1", "Test 1", 1, "Test 1", "")
 				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByTitleBook)).WithArgs("Test 1").WillReturnRows(rows)
 			},
 			service: repo,
@@ -742,7 +1361,7 @@ func TestBookService_SearchByTitle(t *testing.T) {
 		{
 			testName: "Test Unsuccessful: Book not found",
 			mockFunc: func() {
-				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description"})
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
 				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySearchByTitleBook)).WithArgs("Test 1").WillReturnRows(rows)
 			},
 			service: repo,
@@ -853,7 +1472,7 @@ func TestBookService_UpdateBook(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	updateMethod := "PUT"
 	updateURL := "/book"
@@ -863,6 +1482,7 @@ func TestBookService_UpdateBook(t *testing.T) {
 		mockFunc       func()
 		service        BookService
 		requestBody    *entity.Book
+		ifMatch        string
 		method         string
 		params         []gin.Param
 		url            string
@@ -874,8 +1494,16 @@ func TestBookService_UpdateBook(t *testing.T) {
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryUpdateBook)).WithArgs(1, "Test 2", "Test 2", 2, "Test 2").WillReturnResult(sqlmock.NewResult(0, 1))
-			},
+				rows := sqlmock.NewRows([]string{"version"}).AddRow(2)
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryUpdateBook)).WithArgs(1, "Test 2", "Test 2", 2, "Test 2", "", 1).WillReturnRows(rows)
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryUnlinkBookAuthors)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectLinkAuthors(mock, 1, "Test 2")
+				expectAudit(mock, 1, entity.BookActionUpdate)
+				mock.ExpectCommit()
+			},
+			ifMatch: `"1"`,
 			requestBody: &entity.Book{
 				Title:       "Test 2",
 				Author:      "Test 2",
@@ -900,10 +1528,12 @@ func TestBookService_UpdateBook(t *testing.T) {
 			},
 			expectedHeader: map[string]string{
 				"Content-Type": "application/json; charset=utf-8",
+				"ETag":         `"2"`,
 			},
 		},
 		{
 			testName:    "Test Unsuccessful: Invalid serial",
+			ifMatch:     `"1"`,
 			requestBody: &entity.Book{
 				Title:       "Test 2",
 				Author:      "Test 2",
@@ -942,6 +1572,7 @@ func TestBookService_UpdateBook(t *testing.T) {
 		},
 		{
 			testName:    "Test Unsuccessful: Invalid request body",
+			ifMatch:     `"1"`,
 			requestBody: &entity.Book{
 				Author:      "Test 2",
 				Description: "Test 2",
@@ -968,6 +1599,7 @@ func TestBookService_UpdateBook(t *testing.T) {
 		},
 		{
 			testName: "Test Unsuccessful: Empty request body",
+			ifMatch:  `"1"`,
 			service:  repo,
 			url:      updateURL,
 			method:   updateMethod,
@@ -985,19 +1617,97 @@ func TestBookService_UpdateBook(t *testing.T) {
 				"Content-Type": "application/json; charset=utf-8",
 			},
 		},
-	}
-
-	for _, tc := range UpdateBookServiceMocks {
-		t.Run(tc.testName, func(t *testing.T) {
-			w := httptest.NewRecorder()
-
-			var jsonRequest []byte = nil
+		{
+			testName: "Test Unsuccessful: Stale version",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryUpdateBook)).WithArgs(1, "Test 2", "Test 2", 2, "Test 2", "", 1).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBookVersion)).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			requestBody: &entity.Book{
+				Title:       "Test 2",
+				Author:      "Test 2",
+				Year:        2,
+				Description: "Test 2",
+			},
+			ifMatch: `"1"`,
+			service: repo,
+			url:     updateURL,
+			method:  updateMethod,
+			params: []gin.Param{
+				{
+					Key:   "id",
+					Value: "1",
+				},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookVersionMismatch(2).Error(),
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Weak ETag",
+			requestBody: &entity.Book{
+				Title:       "Test 2",
+				Author:      "Test 2",
+				Year:        2,
+				Description: "Test 2",
+			},
+			ifMatch: `W/"1"`,
+			service: repo,
+			url:     updateURL,
+			method:  updateMethod,
+			params: []gin.Param{
+				{
+					Key:   "id",
+					Value: "1",
+				},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookVersionMismatch(0).Error(),
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Missing If-Match",
+			requestBody: &entity.Book{
+				Title:       "Test 2",
+				Author:      "Test 2",
+				Year:        2,
+				Description: "Test 2",
+			},
+			service: repo,
+			url:     updateURL,
+			method:  updateMethod,
+			params: []gin.Param{
+				{
+					Key:   "id",
+					Value: "1",
+				},
+			},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookVersionRequired().Error(),
+			},
+		},
+	}
+
+	for _, tc := range UpdateBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			var jsonRequest []byte = nil
 
 			if tc.requestBody != nil {
 				jsonRequest, _ = json.Marshal(tc.requestBody)
 			}
 
 			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewReader(jsonRequest))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 			c, _ := gin.CreateTestContext(w)
 			c.Request = req
 			c.Params = tc.params
@@ -1039,11 +1749,249 @@ func TestBookService_UpdateBook(t *testing.T) {
 	}
 }
 
+func TestBookService_PatchBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	bookColumns := []string{"id", "title", "author", "year", "description", "isbn", "version"}
+	patchTitleQuery := `UPDATE bookstore SET title=$2, version=version+1 WHERE id=$1 AND deleted_at IS NULL AND ($3=0 OR version=$3) RETURNING id, title, author, year, description, isbn, version`
+	patchDescriptionQuery := `UPDATE bookstore SET description=$2, version=version+1 WHERE id=$1 AND deleted_at IS NULL AND ($3=0 OR version=$3) RETURNING id, title, author, year, description, isbn, version`
+	current := func() *sqlmock.Rows {
+		return sqlmock.NewRows(bookColumns).AddRow(1, "Test", "Test", 1, "Test", "", 1)
+	}
+
+	patchBookServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		contentType    string
+		ifMatch        string
+		requestBody    string
+		id             string
+		expectedStatus int
+		expectedBody   *entity.Book
+		expectedError  expectedErrors
+		expectedHeader map[string]string
+	}{
+		{
+			testName: "Test Successful: Merge patch",
+			mockFunc: func() {
+				rows := sqlmock.NewRows(bookColumns).AddRow(1, "Test 2", "Test", 1, "Test", "", 2)
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectQuery(regexp.QuoteMeta(patchTitleQuery)).WithArgs(1, "Test 2", 1).WillReturnRows(rows)
+				expectAudit(mock, 1, entity.BookActionPatch)
+				mock.ExpectCommit()
+			},
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `{"title": "Test 2"}`,
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Book{Title: "Test 2", Author: "Test", Year: 1, Description: "Test"},
+			expectedHeader: map[string]string{"ETag": `"2"`},
+		},
+		{
+			testName: "Test Successful: Merge patch removing a field of any version",
+			mockFunc: func() {
+				rows := sqlmock.NewRows(bookColumns).AddRow(1, "Test", "Test", 1, "", "", 2)
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectQuery(regexp.QuoteMeta(patchDescriptionQuery)).WithArgs(1, "", 0).WillReturnRows(rows)
+				expectAudit(mock, 1, entity.BookActionPatch)
+				mock.ExpectCommit()
+			},
+			contentType:    "application/json",
+			ifMatch:        "*",
+			requestBody:    `{"description": null}`,
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Book{Title: "Test", Author: "Test", Year: 1},
+			expectedHeader: map[string]string{"ETag": `"2"`},
+		},
+		{
+			testName: "Test Successful: Empty merge patch",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillReturnRows(current())
+			},
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `{}`,
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Book{Title: "Test", Author: "Test", Year: 1, Description: "Test"},
+			expectedHeader: map[string]string{"ETag": `"1"`},
+		},
+		{
+			testName: "Test Successful: JSON Patch",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillReturnRows(current())
+				rows := sqlmock.NewRows(bookColumns).AddRow(1, "Test 2", "Test", 1, "Test", "", 2)
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectQuery(regexp.QuoteMeta(patchTitleQuery)).WithArgs(1, "Test 2", 1).WillReturnRows(rows)
+				expectAudit(mock, 1, entity.BookActionPatch)
+				mock.ExpectCommit()
+			},
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `[{"op": "test", "path": "/title", "value": "Test"}, {"op": "replace", "path": "/title", "value": "Test 2"}]`,
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Book{Title: "Test 2", Author: "Test", Year: 1, Description: "Test"},
+			expectedHeader: map[string]string{"ETag": `"2"`},
+		},
+		{
+			testName: "Test Unsuccessful: JSON Patch test failed",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillReturnRows(current())
+			},
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `[{"op": "test", "path": "/year", "value": 2}, {"op": "replace", "path": "/title", "value": "Test 2"}]`,
+			id:             "1",
+			expectedStatus: http.StatusConflict,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookPatchTestFailed("/year").Error(),
+			},
+		},
+		{
+			testName: "Test Unsuccessful: JSON Patch operation invalid",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetBook)).WithArgs(1).WillReturnRows(current())
+			},
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `[{"op": "replace", "path": "/id", "value": 2}]`,
+			id:             "1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.NewFieldPatchOperationInvalid(0, `path "/id" does not point at a field which can be patched`),
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Merge patch of unknown field",
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `{"id": 2, "year": "1999"}`,
+			id:             "1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.NewFieldNotPatchable("id"),
+					validators.FieldYearNotInteger,
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Touched field invalid",
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			requestBody:    `{"title": ""}`,
+			id:             "1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldTitleEmpty,
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Unsupported content type",
+			contentType:    "text/plain",
+			requestBody:    `title=Test 2`,
+			id:             "1",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookUnsupportedPatch("text/plain").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty request body",
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			id:             "1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookEmptyBody().Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: No If-Match",
+			contentType:    "application/merge-patch+json",
+			requestBody:    `{"title": "Test 2"}`,
+			id:             "1",
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookVersionRequired().Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			contentType:    "application/merge-patch+json",
+			requestBody:    `{"title": "Test 2"}`,
+			id:             "one",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range patchBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest("PATCH", "/book", bytes.NewReader([]byte(tc.requestBody)))
+			req.Header.Set("Content-Type", tc.contentType)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{{Key: "id", Value: tc.id}}
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			repo.PatchBook(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			if tc.expectedBody != nil {
+				assert.True(t, tc.expectedBody.EqualNoID(*resultBody.Data), "Values are not equal:\nExpected: %+v\nActual: %+v", tc.expectedBody, resultBody.Data)
+			} else if resultBody.Data != nil {
+				t.Errorf("Expected result body to be nil, found %+v", resultBody.Data)
+			}
+			if !tc.expectedError.isEmpty() {
+				assert.Equal(t, tc.expectedError, resultBody.Error)
+			} else if !resultBody.Error.isEmpty() {
+				t.Errorf("Expected error to be nil, found %+v", resultBody.Error)
+			}
+
+			for header, expected := range tc.expectedHeader {
+				assert.Equal(t, expected, w.Header().Get(header))
+			}
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookService_DeleteBook(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	deleteMethod := "DELETE"
 	deleteUrl := "/book"
@@ -1062,7 +2010,11 @@ func TestBookService_DeleteBook(t *testing.T) {
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteBook)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				expectLocked(mock, 1, true)
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteBook)).WithArgs(1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, 1, entity.BookActionDelete)
+				mock.ExpectCommit()
 			},
 			service: repo,
 			url:     deleteUrl,
@@ -1081,7 +2033,9 @@ func TestBookService_DeleteBook(t *testing.T) {
 		{
 			testName: "Test Unsuccessful: Book(s) not found",
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteBook)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				expectLocked(mock, 1, false)
+				mock.ExpectRollback()
 			},
 			service: repo,
 			url:     deleteUrl,
@@ -1162,7 +2116,7 @@ func TestBookService_DeleteAllBooks(t *testing.T) {
 	db, mock := newMock()
 	defer db.Close()
 
-	repo := NewBookService(db)
+	repo := newBookService(db)
 
 	deleteAllMethod := "DELETE"
 	deleteAllURL := "/book"
@@ -1181,7 +2135,10 @@ func TestBookService_DeleteAllBooks(t *testing.T) {
 		{
 			testName: "Test Successful",
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteAllBooksAndAlter)).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryAuditDeleteAllBooks)).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteAllBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectCommit()
 			},
 			service:        repo,
 			url:            deleteAllURL,
@@ -1195,7 +2152,10 @@ func TestBookService_DeleteAllBooks(t *testing.T) {
 		{
 			testName: "Test Unsuccessful: Book(s) not found",
 			mockFunc: func() {
-				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteAllBooksAndAlter)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryAuditDeleteAllBooks)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryDeleteAllBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
 			},
 			service:        repo,
 			url:            deleteAllURL,
@@ -1266,3 +2226,831 @@ func TestBookService_DeleteAllBooks(t *testing.T) {
 		})
 	}
 }
+
+type trashResponse struct {
+	Data  []entity.DeletedBook        `json:"data"`
+	Error expectedErrors              `json:"error"`
+	Meta  *common_pagination.PageMeta `json:"meta"`
+}
+
+func TestBookService_GetTrash(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	deletedAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryCountTrash)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "deleted_at"}).
+		AddRow(2, "Test 2", "Test 2", 2, "Test 2", "", deletedAt)
+	mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetTrash)).WithArgs(2, 2).WillReturnRows(rows)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/book/trash?page=2&per_page=2", nil)
+
+	repo.GetTrash(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resultBody trashResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+		t.Fatalf("Unable to unmarshal the body: %v", err)
+	}
+
+	expected := []entity.DeletedBook{{Book: entity.Book{ID: 2, Title: "Test 2", Author: "Test 2", Year: 2, Description: "Test 2"}, DeletedAt: deletedAt}}
+	assert.Equal(t, expected, resultBody.Data)
+	if assert.NotNil(t, resultBody.Meta) {
+		assert.Equal(t, int64(3), resultBody.Meta.Total)
+		assert.Empty(t, resultBody.Meta.Next)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type auditResponse struct {
+	Data  []entity.BookChange         `json:"data"`
+	Error expectedErrors              `json:"error"`
+	Meta  *common_pagination.PageMeta `json:"meta"`
+}
+
+func TestBookService_GetAudit(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	createdAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	countQuery := `SELECT COUNT(*) FROM book_audit WHERE action=$1 AND actor=$2 AND created_at >= $3 AND created_at < $4`
+	pageQuery := `SELECT id, book_id, action, old_value, new_value, actor, request_id, created_at FROM book_audit
+		WHERE action=$1 AND actor=$2 AND created_at >= $3 AND created_at < $4 ORDER BY created_at DESC, id DESC LIMIT $5 OFFSET $6`
+
+	getAuditServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		url            string
+		expectedStatus int
+		expectedData   []entity.BookChange
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(countQuery)).WithArgs(entity.BookActionDelete, "librarian", createdAt.Add(-time.Hour), createdAt.Add(time.Hour)).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				rows := sqlmock.NewRows([]string{"id", "book_id", "action", "old_value", "new_value", "actor", "request_id", "created_at"}).
+					AddRow(4, 2, entity.BookActionDelete, `{"id": 2, "title": "Test", "author": "Test", "year": 1, "description": ""}`, nil, "librarian", "", createdAt)
+				mock.ExpectQuery(regexp.QuoteMeta(pageQuery)).WithArgs(entity.BookActionDelete, "librarian", createdAt.Add(-time.Hour), createdAt.Add(time.Hour), 20, 0).
+					WillReturnRows(rows)
+			},
+			url:            "/audit?action=delete&actor=librarian&since=2021-10-01T11:00:00Z&until=2021-10-01T13:00:00Z",
+			expectedStatus: http.StatusOK,
+			expectedData: []entity.BookChange{
+				{
+					ID:        4,
+					BookID:    2,
+					Action:    entity.BookActionDelete,
+					OldValue:  &entity.Book{ID: 2, Title: "Test", Author: "Test", Year: 1},
+					Actor:     "librarian",
+					CreatedAt: createdAt,
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid filters",
+			url:            "/audit?book_id=0&action=rename&since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldBookIDInvalid,
+					validators.FieldActionInvalid,
+					validators.FieldSinceInvalid,
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty time range",
+			url:            "/audit?since=2021-10-01T12:00:00Z&until=2021-10-01T12:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldTimeRangeInvalid,
+				},
+			},
+		},
+	}
+
+	for _, tc := range getAuditServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", tc.url, nil)
+
+			repo.GetAudit(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			var resultBody auditResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Fatalf("Unable to unmarshal the body: %v", err)
+			}
+
+			assert.Equal(t, tc.expectedData, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBookService_GetBookHistory(t *testing.T) {
+	repo := bookdb.NewBookMemoryRepo()
+	service := NewBookService(repo, repo)
+
+	router := gin.New()
+	router.Use(common_request.Middleware())
+	router.POST("/book/", service.SaveBook)
+	router.DELETE("/book/:id", service.DeleteBook)
+	router.GET("/book/:id/history", service.GetBookHistory)
+
+	request := func(method string, url string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		req.Header.Set(common_request.HeaderActor, "librarian")
+		req.Header.Set(common_request.HeaderRequestID, method+" "+url)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("POST", "/book/", `{"title": "Test", "author": "Test", "year": 1}`).Code)
+	assert.Equal(t, http.StatusOK, request("POST", "/book/", `{"title": "Test 2", "author": "Test 2", "year": 2}`).Code)
+
+	w := request("DELETE", "/book/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "DELETE /book/1", w.Header().Get(common_request.HeaderRequestID), "Request id should be sent back")
+
+	w = request("GET", "/book/1/history?per_page=1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resultBody auditResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+		t.Fatalf("Unable to unmarshal the body: %v", err)
+	}
+
+	if assert.Len(t, resultBody.Data, 1) {
+		change := resultBody.Data[0]
+		assert.Equal(t, uint64(1), change.BookID)
+		assert.Equal(t, entity.BookActionDelete, change.Action)
+		assert.Equal(t, &entity.Book{ID: 1, Title: "Test", Author: "Test", Year: 1}, change.OldValue)
+		assert.Nil(t, change.NewValue)
+		assert.Equal(t, "librarian", change.Actor)
+		assert.Equal(t, "DELETE /book/1", change.RequestID)
+	}
+	if assert.NotNil(t, resultBody.Meta) {
+		assert.Equal(t, int64(2), resultBody.Meta.Total, "History should only hold changes of the book")
+		assert.NotEmpty(t, resultBody.Meta.Next)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/book/zero/history", "").Code)
+}
+
+func TestBookService_RestoreBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	restoreBookServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		id             string
+		expectedStatus int
+		expectedBody   *entity.Book
+		expectedError  expectedErrors
+		expectedHeader map[string]string
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn", "version"}).
+					AddRow(1, "Test", "Test", 1, "Test", "", 3)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryRestoreBook)).WithArgs(1).WillReturnRows(rows)
+				expectAudit(mock, 1, entity.BookActionRestore)
+				mock.ExpectCommit()
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Book{Title: "Test", Author: "Test", Year: 1, Description: "Test"},
+			expectedHeader: map[string]string{"ETag": `"3"`},
+		},
+		{
+			testName: "Test Unsuccessful: Book not in the trash",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryRestoreBook)).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetTrashedBookISBN)).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"isbn"}))
+				mock.ExpectRollback()
+			},
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewBooksNotFound().Error(),
+			},
+		},
+		{
+			testName: "Test Unsuccessful: ISBN taken by another book",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryRestoreBook)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QueryGetTrashedBookISBN)).WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"isbn"}).AddRow("9780306406157"))
+				mock.ExpectRollback()
+			},
+			id:             "3",
+			expectedStatus: http.StatusConflict,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookISBNAlreadyExists("9780306406157").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			id:             "one",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range restoreBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("POST", "/book/"+tc.id+"/restore", nil)
+			c.Params = []gin.Param{{Key: "id", Value: tc.id}}
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			repo.RestoreBook(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			if tc.expectedBody != nil {
+				assert.True(t, tc.expectedBody.EqualNoID(*resultBody.Data), "Values are not equal:\nExpected: %+v\nActual: %+v", tc.expectedBody, resultBody.Data)
+			} else if resultBody.Data != nil {
+				t.Errorf("Expected result body to be nil, found %+v", resultBody.Data)
+			}
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+
+			for header, expected := range tc.expectedHeader {
+				assert.Equal(t, expected, w.Header().Get(header))
+			}
+		})
+	}
+}
+
+func TestBookService_PurgeTrash(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := newBookService(db)
+
+	purgeTrashServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		url            string
+		expectedStatus int
+		expectedData   int
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryAuditPurgeBooks)).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(bookdb.QueryPurgeBooks)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			url:            "/book/trash?older_than=720h",
+			expectedStatus: http.StatusOK,
+			expectedData:   2,
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid older_than",
+			url:            "/book/trash?older_than=-1h",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldOlderThanInvalid},
+			},
+		},
+	}
+
+	for _, tc := range purgeTrashServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("DELETE", tc.url, nil)
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			repo.PurgeTrash(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := rowsResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedData, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type importResponse struct {
+	Data  *ImportReport `json:"data"`
+	Error struct {
+		expectedErrors
+		Rows []errors.ImportRowError `json:"rows,omitempty"`
+	} `json:"error"`
+}
+
+func TestBookService_ImportBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := bookdb.NewBookRepo(db)
+	uow := &fakeUnitOfWork{repo: &repo}
+	service := NewBookService(&repo, uow)
+
+	importURL := "/book/import"
+	csvBody := "Title,Author,Year,Description\n" +
+		"The Martian Chronicles,Ray Bradbury,1950,Mars is colonised\n" +
+		"Fahrenheit 451,Ray Bradbury,nineteen fifty-three,\n" +
+		",Stanislaw Lem,1961,A living ocean\n" +
+		"Solaris,Stanislaw Lem,1961,\n"
+
+	expectSave := func(id int, title string, author string, year int, description string) {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(id)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySaveBook)).WithArgs(title, author, year, description, "").WillReturnRows(rows)
+		expectLinkAuthors(mock, uint64(id), author)
+		expectAudit(mock, uint64(id), entity.BookActionCreate)
+		mock.ExpectCommit()
+	}
+
+	importBookServiceMocks := []struct {
+		testName          string
+		mockFunc          func()
+		url               string
+		contentType       string
+		body              string
+		expectedStatus    int
+		expectedReport    *ImportReport
+		expectedError     expectedErrors
+		expectedRows      []errors.ImportRowError
+		expectedCommitted int
+	}{
+		{
+			testName: "Test Successful: CSV, rejected rows skipped",
+			mockFunc: func() {
+				expectSave(1, "The Martian Chronicles", "Ray Bradbury", 1950, "Mars is colonised")
+				expectSave(2, "Solaris", "Stanislaw Lem", 1961, "")
+			},
+			url:            importURL,
+			contentType:    "text/csv",
+			body:           csvBody,
+			expectedStatus: http.StatusOK,
+			expectedReport: &ImportReport{
+				Imported: 2,
+				Accepted: []ImportedRow{
+					{Row: 1, Book: entity.Book{ID: 1, Title: "The Martian Chronicles", Author: "Ray Bradbury", Year: 1950, Description: "Mars is colonised"}},
+					{Row: 4, Book: entity.Book{ID: 2, Title: "Solaris", Author: "Stanislaw Lem", Year: 1961}},
+				},
+				Rejected: []errors.ImportRowError{
+					{Row: 2, Fields: []common_translators.FieldError{validators.FieldYearNotInteger}},
+					{Row: 3, Fields: []common_translators.FieldError{validators.FieldTitleEmpty}},
+				},
+			},
+		},
+		{
+			testName: "Test Successful: NDJSON by format parameter",
+			mockFunc: func() {
+				expectSave(3, "Solaris", "Stanislaw Lem", 1961, "A living ocean")
+			},
+			url:  importURL + "?format=ndjson",
+			body: `{"title": "Solaris", "author": "Stanislaw Lem", "year": 1961, "description": "A living ocean"}` + "\n\n" +
+				`{"title": "Eden", "author": "Stanislaw Lem", "year": 3000}` + "\n" +
+				`{"title": "Eden"` + "\n",
+			expectedStatus: http.StatusOK,
+			expectedReport: &ImportReport{
+				Imported: 1,
+				Accepted: []ImportedRow{
+					{Row: 1, Book: entity.Book{ID: 3, Title: "Solaris", Author: "Stanislaw Lem", Year: 1961, Description: "A living ocean"}},
+				},
+				Rejected: []errors.ImportRowError{
+					{Row: 2, Fields: []common_translators.FieldError{validators.FieldYearInvalid}},
+					{Row: 3, Fields: []common_translators.FieldError{validators.NewFieldRowMalformed("unexpected end of JSON input")}},
+				},
+			},
+		},
+		{
+			testName: "Test Successful: Atomic",
+			mockFunc: func() {
+				expectSave(4, "Solaris", "Stanislaw Lem", 1961, "")
+			},
+			url:            importURL + "?atomic=true",
+			contentType:    "application/x-ndjson",
+			body:           `{"title": "Solaris", "author": "Stanislaw Lem", "year": 1961}`,
+			expectedStatus: http.StatusOK,
+			expectedReport: &ImportReport{
+				Imported: 1,
+				Accepted: []ImportedRow{
+					{Row: 1, Book: entity.Book{ID: 4, Title: "Solaris", Author: "Stanislaw Lem", Year: 1961}},
+				},
+				Rejected: []errors.ImportRowError{},
+			},
+			expectedCommitted: 1,
+		},
+		{
+			testName:       "Test Unsuccessful: Atomic with rejected rows",
+			url:            importURL + "?atomic=true",
+			contentType:    "text/csv",
+			body:           csvBody,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookImportRejected(make([]errors.ImportRowError, 2)).Error(),
+			},
+			expectedRows: []errors.ImportRowError{
+				{Row: 2, Fields: []common_translators.FieldError{validators.FieldYearNotInteger}},
+				{Row: 3, Fields: []common_translators.FieldError{validators.FieldTitleEmpty}},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Unknown format",
+			url:            importURL + "?atomic=maybe",
+			contentType:    "application/json",
+			body:           csvBody,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldFormatInvalid, validators.FieldAtomicInvalid},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Missing columns",
+			url:            importURL,
+			contentType:    "text/csv",
+			body:           "title,description\nSolaris,A living ocean\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.NewFieldColumnMissing("author"), validators.NewFieldColumnMissing("year")},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty import",
+			url:            importURL,
+			contentType:    "text/csv",
+			body:           "title,author,year\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldImportEmpty},
+			},
+		},
+		{
+			testName: "Test Successful: Row refused by the database is rejected",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySaveBook)).
+					WithArgs("The Hobbit", "J.R.R. Tolkien", 1937, "", "9780261102217").
+					WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
+				expectSave(5, "Solaris", "Stanislaw Lem", 1961, "")
+			},
+			url:         importURL,
+			contentType: "application/x-ndjson",
+			body: `{"title": "The Hobbit", "author": "J.R.R. Tolkien", "year": 1937, "isbn": "9780261102217"}` + "\n" +
+				`{"title": "Solaris", "author": "Stanislaw Lem", "year": 1961}`,
+			expectedStatus: http.StatusOK,
+			expectedReport: &ImportReport{
+				Imported: 1,
+				Accepted: []ImportedRow{
+					{Row: 2, Book: entity.Book{ID: 5, Title: "Solaris", Author: "Stanislaw Lem", Year: 1961}},
+				},
+				Rejected: []errors.ImportRowError{
+					{Row: 1, Fields: []common_translators.FieldError{validators.NewFieldRowNotSaved(errors.NewBookISBNAlreadyExists("9780261102217").Error())}},
+				},
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Failing query stops the import, keeping the report",
+			mockFunc: func() {
+				expectSave(6, "Solaris", "Stanislaw Lem", 1961, "")
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(bookdb.QuerySaveBook)).
+					WithArgs("Eden", "Stanislaw Lem", 1959, "", "").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			url:         importURL,
+			contentType: "application/x-ndjson",
+			body: `{"title": "Solaris", "author": "Stanislaw Lem", "year": 1961}` + "\n" +
+				`{"title": "Eden", "author": "Stanislaw Lem", "year": 1959}` + "\n" +
+				`{"title": "Fiasco", "author": "Stanislaw Lem", "year": 1986}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedReport: &ImportReport{
+				Imported: 1,
+				Accepted: []ImportedRow{
+					{Row: 1, Book: entity.Book{ID: 6, Title: "Solaris", Author: "Stanislaw Lem", Year: 1961}},
+				},
+				Rejected: []errors.ImportRowError{},
+			},
+			expectedError: expectedErrors{
+				Msg: errors.NewBookCouldNotQuery(sql.ErrConnDone.Error()).Error(),
+			},
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName: "Test Unsuccessful: Atomic, DB is closed",
+			mockFunc: func() {
+				db.Close()
+			},
+			url:            importURL + "?atomic=true",
+			contentType:    "application/x-ndjson",
+			body:           `{"title": "Solaris", "author": "Stanislaw Lem", "year": 1961}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookCouldNotQuery("sql: database is closed").Error(),
+			},
+		},
+	}
+
+	for _, tc := range importBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			uow.committed, uow.rolledBack = 0, 0
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.url, bytes.NewBufferString(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			service.ImportBooks(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := importResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedReport, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error.expectedErrors)
+			assert.Equal(t, tc.expectedRows, resultBody.Error.Rows)
+			assert.Equal(t, tc.expectedCommitted, uow.committed)
+			if tc.expectedStatus == http.StatusInternalServerError && tc.expectedReport == nil {
+				assert.Equal(t, 1, uow.rolledBack)
+			}
+		})
+	}
+}
+
+func TestBookService_ExportBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := newBookService(db)
+
+	exportQuery := `SELECT id, title, author, year, description, isbn FROM bookstore WHERE deleted_at IS NULL AND id IN (SELECT ba.book_id FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE a.name_key=$1) ORDER BY id`
+	exportRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"}).
+			AddRow(1, "The Martian Chronicles", "Ray Bradbury", 1950, "Mars, colonised", "").
+			AddRow(2, "Fahrenheit 451", "Ray Bradbury", 1953, "", "")
+	}
+
+	exportBookServiceMocks := []struct {
+		testName            string
+		mockFunc            func()
+		url                 string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		expectedError       expectedErrors
+	}{
+		{
+			testName: "Test Successful: CSV",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(exportQuery)).WithArgs("ray bradbury").WillReturnRows(exportRows())
+			},
+			url:                 "/book/export?format=csv&author=Ray%20Bradbury",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "id,title,author,year,description,isbn\n" +
+				"1,The Martian Chronicles,Ray Bradbury,1950,\"Mars, colonised\",\n" +
+				"2,Fahrenheit 451,Ray Bradbury,1953,,\n",
+		},
+		{
+			testName: "Test Successful: NDJSON",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(exportQuery)).WithArgs("ray bradbury").WillReturnRows(exportRows())
+			},
+			url:                 "/book/export?format=ndjson&author=Ray%20Bradbury",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: `{"id":1,"title":"The Martian Chronicles","author":"Ray Bradbury","year":1950,"description":"Mars, colonised"}` + "\n" +
+				`{"id":2,"title":"Fahrenheit 451","author":"Ray Bradbury","year":1953}` + "\n",
+		},
+		{
+			testName: "Test Successful: JSON",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(exportQuery)).WithArgs("ray bradbury").WillReturnRows(exportRows())
+			},
+			url:                 "/book/export?author=Ray%20Bradbury",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody: "[\n" +
+				`{"id":1,"title":"The Martian Chronicles","author":"Ray Bradbury","year":1950,"description":"Mars, colonised"},` + "\n" +
+				`{"id":2,"title":"Fahrenheit 451","author":"Ray Bradbury","year":1953}` + "\n]\n",
+		},
+		{
+			testName: "Test Successful: Nothing to export",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "author", "year", "description", "isbn"})
+				mock.ExpectQuery(regexp.QuoteMeta(exportQuery)).WithArgs("nobody").WillReturnRows(rows)
+			},
+			url:                 "/book/export?format=json&author=Nobody",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        "[]\n",
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid parameters",
+			url:            "/book/export?format=xml&year_gte=old",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldYearGTEInvalid, validators.FieldExportFormatInvalid},
+			},
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName: "Test Unsuccessful: DB is closed",
+			mockFunc: func() {
+				db.Close()
+			},
+			url:            "/book/export?format=csv",
+			expectedStatus: http.StatusInternalServerError,
+			expectedError: expectedErrors{
+				Msg: errors.NewBookCouldNotQuery("sql: database is closed").Error(),
+			},
+		},
+	}
+
+	for _, tc := range exportBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			service.ExportBooks(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedError.isEmpty() {
+				assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+				return
+			}
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestBookService_Tags(t *testing.T) {
+	repo := bookdb.NewBookMemoryRepo()
+	service := NewBookService(repo, repo)
+
+	router := gin.New()
+	router.POST("/book/", service.SaveBook)
+	router.GET("/book/", service.GetAllBooks)
+	router.GET("/book/tags", service.GetTagCounts)
+	router.GET("/book/:id/tags", service.GetBookTags)
+	router.POST("/book/:id/tags", service.TagBook)
+	router.DELETE("/book/:id/tags", service.UntagBook)
+
+	request := func(method string, url string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type tagsResponse struct {
+		Data  []string       `json:"data"`
+		Error expectedErrors `json:"error"`
+	}
+	decode := func(w *httptest.ResponseRecorder, body interface{}) {
+		if err := json.Unmarshal(w.Body.Bytes(), body); err != nil {
+			t.Fatalf("Unable to unmarshal the body: %v", err)
+		}
+	}
+
+	assert.Equal(t, http.StatusOK, request("POST", "/book/", `{"title": "Test", "author": "Test", "year": 1}`).Code)
+	assert.Equal(t, http.StatusOK, request("POST", "/book/", `{"title": "Test 2", "author": "Test 2", "year": 2}`).Code)
+
+	w := request("POST", "/book/1/tags", `["Fantasy", "  Science  Fiction", "fantasy"]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tags tagsResponse
+	decode(w, &tags)
+	assert.Equal(t, []string{"fantasy", "science fiction"}, tags.Data, "Tags should be normalized and stored once")
+
+	assert.Equal(t, http.StatusOK, request("POST", "/book/2/tags", `["fantasy"]`).Code)
+
+	w = request("GET", "/book/?tag=FANTASY&tag=science%20fiction&tag_match=all", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var books arrayResponse
+	decode(w, &books)
+	if assert.Len(t, books.Data, 1) {
+		assert.Equal(t, uint64(1), books.Data[0].ID)
+	}
+
+	w = request("GET", "/book/?tag=science%20fiction&tag=fantasy", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	books = arrayResponse{}
+	decode(w, &books)
+	assert.Len(t, books.Data, 2, "Books having any of the tags should be listed by default")
+
+	w = request("GET", "/book/tags", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var counts struct {
+		Data []entity.TagCount           `json:"data"`
+		Meta *common_pagination.PageMeta `json:"meta"`
+	}
+	decode(w, &counts)
+	assert.Equal(t, []entity.TagCount{{Tag: "fantasy", Books: 2}, {Tag: "science fiction", Books: 1}}, counts.Data)
+	if assert.NotNil(t, counts.Meta) {
+		assert.Equal(t, int64(2), counts.Meta.Total)
+	}
+
+	w = request("DELETE", "/book/1/tags", `["fantasy"]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	tags = tagsResponse{}
+	decode(w, &tags)
+	assert.Equal(t, []string{"science fiction"}, tags.Data)
+
+	w = request("GET", "/book/2/tags", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	tags = tagsResponse{}
+	decode(w, &tags)
+	assert.Equal(t, []string{"fantasy"}, tags.Data)
+
+	w = request("POST", "/book/1/tags", `["fantasy", " "]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	tags = tagsResponse{}
+	decode(w, &tags)
+	assert.Equal(t, []common_translators.FieldError{validators.NewFieldTagEmpty(1)}, tags.Error.Fields)
+
+	w = request("POST", "/book/1/tags", `[]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	tags = tagsResponse{}
+	decode(w, &tags)
+	assert.Equal(t, []common_translators.FieldError{validators.FieldTagsEmpty}, tags.Error.Fields)
+
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/book/1/tags", `{"tag": "fantasy"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/book/1/tags", "").Code)
+	assert.Equal(t, http.StatusNotFound, request("POST", "/book/3/tags", `["fantasy"]`).Code)
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/book/zero/tags", "").Code)
+
+	w = request("GET", "/book/?tag=fantasy&tag_match=some", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	books = arrayResponse{}
+	decode(w, &books)
+	assert.Equal(t, []common_translators.FieldError{validators.FieldTagMatchInvalid}, books.Error.Fields)
+}
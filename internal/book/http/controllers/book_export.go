@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// exportFlushEvery is the number of books written between flushes of the response
+const exportFlushEvery = 100
+
+// bookEncoder writes books of an export one at a time. Encoders may buffer a few books, flush writes them out
+type bookEncoder interface {
+	contentType() string
+	begin() error
+	encode(entity.Book) error
+	flush() error
+	end() error
+}
+
+// newBookEncoder returns encoder of the export format writing to w, or nil for unknown format
+func newBookEncoder(format string, w io.Writer) bookEncoder {
+	switch format {
+	case formatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w)}
+	case formatNDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}
+	case formatJSON:
+		return &jsonEncoder{w: w}
+	}
+	return nil
+}
+
+// csvEncoder writes a header with the columns import expects, followed by a record per book
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *csvEncoder) begin() error {
+	return e.writer.Write([]string{"id", "title", "author", "year", "description", "isbn"})
+}
+
+func (e *csvEncoder) encode(book entity.Book) error {
+	record := []string{strconv.FormatUint(book.ID, 10), book.Title, book.Author, strconv.Itoa(book.Year), book.Description, book.ISBN}
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) end() error {
+	return e.flush()
+}
+
+// ndjsonEncoder writes a JSON book per line
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *ndjsonEncoder) begin() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) encode(book entity.Book) error {
+	return e.encoder.Encode(book)
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) end() error {
+	return nil
+}
+
+// jsonEncoder writes a JSON array of books, a book per line. The array is not closed if the export is interrupted,
+// so a truncated export is never mistaken for a complete one
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) contentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (e *jsonEncoder) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonEncoder) encode(book entity.Book) error {
+	encoded, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "\n"
+	}
+	e.count++
+
+	_, err = io.WriteString(e.w, separator+string(encoded))
+	return err
+}
+
+func (e *jsonEncoder) flush() error {
+	return nil
+}
+
+func (e *jsonEncoder) end() error {
+	closing := "\n]\n"
+	if e.count == 0 {
+		closing = "]\n"
+	}
+	_, err := io.WriteString(e.w, closing)
+	return err
+}
+
+// ExportBooks streams every book matching the listing filters as CSV, NDJSON or a JSON array. Books are written as
+// they are read from the repository, so memory use does not grow with the catalogue. Once the first book is written
+// the status can not change anymore, so a later failure only cuts the export short
+func (b *BookService) ExportBooks(c *gin.Context) {
+	query, fieldErrors := parseListQuery(c)
+
+	format := c.DefaultQuery("format", formatJSON)
+	encoder := newBookEncoder(format, c.Writer)
+	if encoder == nil {
+		fieldErrors = append(fieldErrors, validators.FieldExportFormatInvalid)
+	}
+
+	if len(fieldErrors) != 0 {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opExport)
+	defer cancel()
+
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", encoder.contentType())
+		c.Header("Content-Disposition", `attachment; filename="books.`+format+`"`)
+		c.Status(http.StatusOK)
+		return encoder.begin()
+	}
+
+	exported := 0
+	err := b.repo.ExportBooks(ctx, query, func(book entity.Book) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.encode(book); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushEvery == 0 {
+			if err := encoder.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = encoder.end()
+	}
+
+	if err != nil {
+		if !started {
+			errors.HandleBookError(c, err)
+			return
+		}
+		log.Printf("Export interrupted after %v book(s): %v", exported, err)
+		return
+	}
+
+	c.Writer.Flush()
+}
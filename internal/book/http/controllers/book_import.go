@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	goerrors "errors"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats books can be imported from and exported to. JSON array is for export only
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatJSON   = "json"
+)
+
+// MaxImportRows is the largest number of rows a single import may have
+const MaxImportRows = 10000
+
+// requiredColumns must be named in the header of a CSV import. Description and ISBN columns are optional
+var requiredColumns = []string{"title", "author", "year"}
+
+// ImportedRow is a row of an import stored as book
+type ImportedRow struct {
+	Row  int         `json:"row"`
+	Book entity.Book `json:"book"`
+}
+
+// ImportReport tells what became of every row of an import
+type ImportReport struct {
+	Imported int                     `json:"imported"`
+	Accepted []ImportedRow           `json:"accepted"`
+	Rejected []errors.ImportRowError `json:"rejected"`
+}
+
+// importRow is a parsed record of an import. Rows which could not be parsed already have their field errors
+type importRow struct {
+	number int
+	book   entity.Book
+	fields []ct.FieldError
+}
+
+// ImportBooks stores every valid row of a CSV or NDJSON body and reports which rows were rejected. With atomic=true
+// the rows are stored in one transaction, and only if none of them is rejected. Otherwise rows the database refuses,
+// e.g. for a taken ISBN, are rejected too, while a failing query or timeout stops the import. The report of the rows
+// stored before it is sent along with the error
+func (b *BookService) ImportBooks(c *gin.Context) {
+	var fieldErrors []ct.FieldError
+
+	format, ok := importFormat(c)
+	if !ok {
+		fieldErrors = append(fieldErrors, validators.FieldFormatInvalid)
+	}
+
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "false"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, validators.FieldAtomicInvalid)
+	}
+
+	if len(fieldErrors) != 0 {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	var rows []importRow
+	if format == formatCSV {
+		rows, err = readCSVRows(c.Request.Body)
+	} else {
+		rows, err = readNDJSONRows(c.Request.Body)
+	}
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	report := ImportReport{Accepted: []ImportedRow{}, Rejected: []errors.ImportRowError{}}
+	var valid []importRow
+	for _, row := range rows {
+		if fields := validateRow(row); fields != nil {
+			report.Rejected = append(report.Rejected, errors.ImportRowError{Row: row.number, Fields: fields})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	if atomic && len(report.Rejected) != 0 {
+		errors.HandleBookError(c, errors.NewBookImportRejected(report.Rejected))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opImport)
+	defer cancel()
+
+	save := func(repo repository.BookRepository) error {
+		for _, row := range valid {
+			saved, err := repo.SaveBook(ctx, &row.book)
+			if err != nil && !atomic && errors.IsRowError(err) {
+				report.Rejected = append(report.Rejected, errors.ImportRowError{
+					Row:    row.number,
+					Fields: []ct.FieldError{validators.NewFieldRowNotSaved(err.Error())},
+				})
+				continue
+			} else if err != nil {
+				return err
+			}
+			report.Accepted = append(report.Accepted, ImportedRow{Row: row.number, Book: *saved})
+		}
+		return nil
+	}
+
+	if atomic {
+		err = b.uow.Atomically(ctx, save)
+		if err != nil {
+			errors.HandleBookError(c, err)
+			return
+		}
+	} else {
+		err = save(b.repo)
+	}
+
+	report.Imported = len(report.Accepted)
+	sort.Slice(report.Rejected, func(i, j int) bool {
+		return report.Rejected[i].Row < report.Rejected[j].Row
+	})
+
+	if err != nil {
+		respondImportStopped(c, report, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, report, nil)
+}
+
+// respondImportStopped responds to a non-atomic import stopped by err with the report of the rows handled before, as
+// the rows stored are not rolled back
+func respondImportStopped(c *gin.Context, report ImportReport, err error) {
+	status := http.StatusInternalServerError
+	if goerrors.Is(err, context.DeadlineExceeded) {
+		status, err = http.StatusGatewayTimeout, errors.NewBookQueryTimeout()
+	}
+
+	common_response.Respond(c, status, report, err)
+}
+
+// importFormat returns the format of the import given by format parameter, or else by Content-Type
+func importFormat(c *gin.Context) (string, bool) {
+	format := c.Query("format")
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = formatCSV
+		case "application/x-ndjson", "application/ndjson":
+			format = formatNDJSON
+		}
+	}
+
+	return format, format == formatCSV || format == formatNDJSON
+}
+
+// validateRow checks the book of the row with the binding rules of entity.Book
+func validateRow(row importRow) []ct.FieldError {
+	if row.fields != nil {
+		return row.fields
+	}
+
+	if err := binding.Validator.ValidateStruct(&row.book); err != nil {
+		var validationErrors validator.ValidationErrors
+		if goerrors.As(err, &validationErrors) {
+			return ct.Translate(validationErrors)
+		}
+		return []ct.FieldError{validators.NewFieldRowMalformed(err.Error())}
+	}
+
+	return nil
+}
+
+// readCSVRows reads CSV with a header naming the columns. Columns may come in any order, unknown ones are ignored
+func readCSVRows(body io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldImportEmpty})
+	} else if err != nil {
+		return nil, errors.NewBookBadBody(err.Error())
+	}
+
+	positions := make(map[string]int)
+	for idx, column := range header {
+		positions[strings.ToLower(strings.TrimSpace(column))] = idx
+	}
+
+	var fieldErrors []ct.FieldError
+	for _, column := range requiredColumns {
+		if _, ok := positions[column]; !ok {
+			fieldErrors = append(fieldErrors, validators.NewFieldColumnMissing(column))
+		}
+	}
+	if fieldErrors != nil {
+		return nil, errors.NewBookValidatorError(fieldErrors)
+	}
+
+	rows := []importRow{}
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if number > MaxImportRows {
+			return nil, errors.NewBookValidatorError([]ct.FieldError{validators.NewFieldImportTooLarge(MaxImportRows)})
+		}
+
+		var parseError *csv.ParseError
+		if goerrors.As(err, &parseError) {
+			rows = append(rows, importRow{number: number, fields: []ct.FieldError{validators.NewFieldRowMalformed(parseError.Err.Error())}})
+			continue
+		} else if err != nil {
+			return nil, errors.NewBookBadBody(err.Error())
+		}
+
+		rows = append(rows, csvRow(number, record, positions))
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldImportEmpty})
+	}
+
+	return rows, nil
+}
+
+// csvRow converts a CSV record into a row. Year is the only column which may fail to convert
+func csvRow(number int, record []string, positions map[string]int) importRow {
+	value := func(column string) string {
+		if idx, ok := positions[column]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	row := importRow{number: number}
+	row.book.Title = value("title")
+	row.book.Author = value("author")
+	row.book.Description = value("description")
+	row.book.ISBN = value("isbn")
+
+	if year := value("year"); year != "" {
+		parsed, err := strconv.Atoi(year)
+		if err != nil {
+			row.fields = []ct.FieldError{validators.FieldYearNotInteger}
+		}
+		row.book.Year = parsed
+	}
+
+	return row
+}
+
+// readNDJSONRows reads one JSON book per line. Blank lines are skipped and do not count as rows
+func readNDJSONRows(body io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	rows := []importRow{}
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		number := len(rows) + 1
+		if number > MaxImportRows {
+			return nil, errors.NewBookValidatorError([]ct.FieldError{validators.NewFieldImportTooLarge(MaxImportRows)})
+		}
+
+		row := importRow{number: number}
+		if err := json.Unmarshal(line, &row.book); err != nil {
+			row.fields = []ct.FieldError{validators.NewFieldRowMalformed(err.Error())}
+		}
+		row.book.ID = 0
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.NewBookBadBody(err.Error())
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.NewBookValidatorError([]ct.FieldError{validators.FieldImportEmpty})
+	}
+
+	return rows, nil
+}
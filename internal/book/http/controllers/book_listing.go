@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	bookDB "github.com/foxfurry/simple-rest/internal/book/db"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bookCursor is the position encoded into the opaque cursor of keyset pagination. Sort is kept along with the
+// position, since the position means nothing in a different order
+type bookCursor struct {
+	Sort   string   `json:"sort"`
+	Values []string `json:"values"`
+	ID     uint64   `json:"id"`
+}
+
+// parseSort parses comma separated sort specification like "-year,title" into sort fields. Minus prefix means
+// descending order
+func parseSort(spec string) ([]repository.SortField, []ct.FieldError) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	var fields []repository.SortField
+	var fieldErrors []ct.FieldError
+	seen := make(map[string]bool)
+
+	for _, column := range strings.Split(spec, ",") {
+		field := repository.SortField{Column: strings.TrimPrefix(column, "-"), Desc: strings.HasPrefix(column, "-")}
+
+		if !bookDB.IsSortable(field.Column) {
+			fieldErrors = append(fieldErrors, validators.NewFieldSortInvalid(field.Column))
+			continue
+		}
+		if seen[field.Column] {
+			fieldErrors = append(fieldErrors, validators.NewFieldSortRepeated(field.Column))
+			continue
+		}
+
+		seen[field.Column] = true
+		fields = append(fields, field)
+	}
+
+	return fields, fieldErrors
+}
+
+// parseYear reads optional integer query parameter. Nil is returned if the parameter is absent
+func parseYear(c *gin.Context, param string, fieldError ct.FieldError) (*int, []ct.FieldError) {
+	value, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	year, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, []ct.FieldError{fieldError}
+	}
+
+	return &year, nil
+}
+
+// parseListQuery reads filtering and sorting parameters shared by offset and keyset pagination
+func parseListQuery(c *gin.Context) (repository.BookListQuery, []ct.FieldError) {
+	var fieldErrors []ct.FieldError
+
+	query := repository.BookListQuery{
+		Author:        c.Query("author"),
+		TitleContains: c.Query("title_contains"),
+	}
+
+	yearGTE, yearErrors := parseYear(c, "year_gte", validators.FieldYearGTEInvalid)
+	fieldErrors = append(fieldErrors, yearErrors...)
+
+	yearLTE, yearErrors := parseYear(c, "year_lte", validators.FieldYearLTEInvalid)
+	fieldErrors = append(fieldErrors, yearErrors...)
+
+	if yearGTE != nil && yearLTE != nil && *yearGTE > *yearLTE {
+		fieldErrors = append(fieldErrors, validators.FieldYearRangeInvalid)
+	}
+
+	query.YearGTE = yearGTE
+	query.YearLTE = yearLTE
+
+	tags, allTags, tagErrors := parseTagFilter(c)
+	fieldErrors = append(fieldErrors, tagErrors...)
+
+	query.Tags = tags
+	query.AllTags = allTags
+
+	sort, sortErrors := parseSort(c.Query("sort"))
+	fieldErrors = append(fieldErrors, sortErrors...)
+
+	query.Sort = sort
+
+	return query, fieldErrors
+}
+
+// respondBooksAfter responds with a keyset paginated page of books matching query. Cursor from the request
+// overrides the sort of query, so the iteration keeps the order it was started with
+func (b *BookService) respondBooksAfter(c *gin.Context, query repository.BookListQuery) {
+	perPage, fieldErrors := common_pagination.ParsePerPage(c)
+
+	var cursor bookCursor
+	started, cursorErrors := common_pagination.ParseCursor(c, &cursor)
+	fieldErrors = append(fieldErrors, cursorErrors...)
+
+	if started {
+		sort, sortErrors := parseSort(cursor.Sort)
+		if sortErrors != nil || len(cursor.Values) != len(sort) || (c.Query("sort") != "" && c.Query("sort") != cursor.Sort) {
+			fieldErrors = append(fieldErrors, common_pagination.FieldCursorInvalid)
+		}
+
+		query.Sort = sort
+		query.After = &repository.Keyset{Values: cursor.Values, ID: cursor.ID}
+	}
+
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	query.Limit = perPage
+
+	ctx, cancel := operationContext(c, opList)
+	defer cancel()
+
+	books, next, err := b.repo.GetBooksAfter(ctx, query)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	var nextCursor string
+	if next != nil {
+		nextCursor = common_pagination.EncodeCursor(bookCursor{
+			Sort:   formatSort(query.Sort),
+			Values: next.Values,
+			ID:     next.ID,
+		})
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, books, common_pagination.NewCursorMeta(c, perPage, nextCursor))
+}
+
+// formatSort is the inverse of parseSort
+func formatSort(sort []repository.SortField) string {
+	var columns []string
+	for _, field := range sort {
+		column := field.Column
+		if field.Desc {
+			column = "-" + column
+		}
+		columns = append(columns, column)
+	}
+	return strings.Join(columns, ",")
+}
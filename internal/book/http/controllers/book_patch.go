@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content types a patch may be sent as. Plain JSON is read as merge patch
+const (
+	contentMergePatch = "application/merge-patch+json"
+	contentJSONPatch  = "application/json-patch+json"
+	contentJSON       = "application/json"
+)
+
+// patchableFields maps JSON names of the fields a patch may change to their names in entity.Book
+var patchableFields = map[string]string{
+	"title":       "Title",
+	"author":      "Author",
+	"year":        "Year",
+	"description": "Description",
+	"isbn":        "ISBN",
+}
+
+// jsonPatchOperation is an operation of JSON Patch (RFC 6902)
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// PatchBook changes some fields of a book, given as JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902). Only the
+// fields the patch touches are validated. If-Match is required as for UpdateBook, "*" applies the patch to any version
+func (b *BookService) PatchBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	contentType := c.ContentType()
+	if contentType != contentMergePatch && contentType != contentJSONPatch && contentType != contentJSON {
+		errors.HandleBookError(c, errors.NewBookUnsupportedPatch(contentType))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookBadBody(err.Error()))
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		errors.HandleBookError(c, errors.NewBookEmptyBody())
+		return
+	}
+
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	var current *entity.VersionedBook
+	var patch entity.BookPatch
+	if contentType == contentJSONPatch {
+		if current, err = b.repo.GetBook(ctx, uint64(id)); err != nil {
+			errors.HandleBookError(c, err)
+			return
+		}
+		if version == 0 {
+			version = current.Version
+		}
+		patch, err = parseJSONPatch(body, current.Book)
+	} else {
+		patch, err = parseMergePatch(body)
+	}
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	if fieldErrors := validatePatch(patch); fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	if patch.IsEmpty() {
+		if current == nil {
+			if current, err = b.repo.GetBook(ctx, uint64(id)); err != nil {
+				errors.HandleBookError(c, err)
+				return
+			}
+		}
+		if version != 0 && version != current.Version {
+			errors.HandleBookError(c, errors.NewBookVersionMismatch(current.Version))
+			return
+		}
+
+		c.Header("ETag", bookETag(current.Version))
+		common_response.Respond(c, http.StatusOK, current.Book, nil)
+		return
+	}
+
+	patched, err := b.repo.PatchBook(ctx, uint64(id), version, patch)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	c.Header("ETag", bookETag(patched.Version))
+	common_response.Respond(c, http.StatusOK, patched.Book, nil)
+}
+
+// parseMergePatch reads a merge patch. Null removes a field, which resets it to its zero value
+func parseMergePatch(body []byte) (entity.BookPatch, error) {
+	var patch entity.BookPatch
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return patch, errors.NewBookValidatorError([]ct.FieldError{validators.NewFieldPatchMalformed("merge patch should be a JSON object")})
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fieldErrors []ct.FieldError
+	for _, name := range names {
+		if fieldError := setPatchField(&patch, name, members[name]); fieldError != nil {
+			fieldErrors = append(fieldErrors, *fieldError)
+		}
+	}
+	if fieldErrors != nil {
+		return patch, errors.NewBookValidatorError(fieldErrors)
+	}
+
+	return patch, nil
+}
+
+// parseJSONPatch applies the operations of a JSON Patch to book one after another and returns the resulting changes.
+// A failed test operation fails the whole patch
+func parseJSONPatch(body []byte, book entity.Book) (entity.BookPatch, error) {
+	var patch entity.BookPatch
+
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(body, &operations); err != nil || operations == nil {
+		return patch, errors.NewBookValidatorError([]ct.FieldError{validators.NewFieldPatchMalformed("JSON Patch should be an array of operations")})
+	}
+
+	for idx, operation := range operations {
+		invalid := func(reason string) error {
+			return errors.NewBookValidatorError([]ct.FieldError{validators.NewFieldPatchOperationInvalid(idx, reason)})
+		}
+
+		name, err := patchPath(operation.Path)
+		if err != nil {
+			return patch, invalid(err.Error())
+		}
+
+		value := operation.Value
+		switch operation.Op {
+		case "add", "replace", "test":
+			if value == nil {
+				return patch, invalid(fmt.Sprintf("%v operation needs a value", operation.Op))
+			}
+		case "remove":
+			value = json.RawMessage("null")
+		case "copy", "move":
+			from, err := patchPath(operation.From)
+			if err != nil {
+				return patch, invalid(err.Error())
+			}
+			value = fieldValue(patch.Apply(book), from)
+
+			if operation.Op == "move" && from != name {
+				if fieldError := setPatchField(&patch, from, json.RawMessage("null")); fieldError != nil {
+					return patch, errors.NewBookValidatorError([]ct.FieldError{*fieldError})
+				}
+			}
+		default:
+			return patch, invalid(fmt.Sprintf("%q is not an operation of JSON Patch", operation.Op))
+		}
+
+		if operation.Op == "test" {
+			if !jsonEqual(fieldValue(patch.Apply(book), name), value) {
+				return patch, errors.NewBookPatchTestFailed(operation.Path)
+			}
+			continue
+		}
+
+		if fieldError := setPatchField(&patch, name, value); fieldError != nil {
+			return patch, errors.NewBookValidatorError([]ct.FieldError{*fieldError})
+		}
+	}
+
+	return patch, nil
+}
+
+// patchPath returns the field name a JSON Pointer of an operation points at
+func patchPath(pointer string) (string, error) {
+	name := strings.TrimPrefix(pointer, "/")
+	if name == pointer {
+		return "", fmt.Errorf("path %q should start with /", pointer)
+	}
+	if _, ok := patchableFields[name]; !ok {
+		return "", fmt.Errorf("path %q does not point at a field which can be patched", pointer)
+	}
+	return name, nil
+}
+
+// setPatchField sets field of the patch by its JSON name. Null resets the field to its zero value
+func setPatchField(patch *entity.BookPatch, name string, value json.RawMessage) *ct.FieldError {
+	field, ok := patchableFields[name]
+	if !ok {
+		fieldError := validators.NewFieldNotPatchable(name)
+		return &fieldError
+	}
+
+	null := bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+
+	if field == "Year" {
+		var year int
+		if !null {
+			if err := json.Unmarshal(value, &year); err != nil {
+				fieldError := validators.FieldYearNotInteger
+				return &fieldError
+			}
+		}
+		patch.Year = &year
+		return nil
+	}
+
+	var text string
+	if !null {
+		if err := json.Unmarshal(value, &text); err != nil {
+			fieldError := validators.NewFieldNotString(field)
+			return &fieldError
+		}
+	}
+
+	switch field {
+	case "Title":
+		patch.Title = &text
+	case "Author":
+		patch.Author = &text
+	case "Description":
+		patch.Description = &text
+	case "ISBN":
+		patch.ISBN = &text
+	}
+	return nil
+}
+
+// fieldValue returns field of the book by its JSON name, encoded as JSON
+func fieldValue(book entity.Book, name string) json.RawMessage {
+	var value interface{}
+	switch name {
+	case "title":
+		value = book.Title
+	case "author":
+		value = book.Author
+	case "year":
+		value = book.Year
+	case "description":
+		value = book.Description
+	case "isbn":
+		value = book.ISBN
+	}
+
+	encoded, _ := json.Marshal(value)
+	return encoded
+}
+
+// jsonEqual compares two JSON values regardless of their formatting
+func jsonEqual(lhs, rhs json.RawMessage) bool {
+	var left, right interface{}
+	if json.Unmarshal(lhs, &left) != nil || json.Unmarshal(rhs, &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// validatePatch checks the fields changed by the patch with the binding rules of entity.Book, leaving the rest alone
+func validatePatch(patch entity.BookPatch) []ct.FieldError {
+	var fields []string
+	if patch.Title != nil {
+		fields = append(fields, "Title")
+	}
+	if patch.Author != nil {
+		fields = append(fields, "Author")
+	}
+	if patch.Year != nil {
+		fields = append(fields, "Year")
+	}
+	if patch.Description != nil {
+		fields = append(fields, "Description")
+	}
+	if patch.ISBN != nil {
+		fields = append(fields, "ISBN")
+	}
+	if fields == nil {
+		return nil
+	}
+
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	book := patch.Apply(entity.Book{})
+	if err := engine.StructPartial(&book, fields...); err != nil {
+		var validationErrors validator.ValidationErrors
+		if goerrors.As(err, &validationErrors) {
+			return ct.Translate(validationErrors)
+		}
+		return []ct.FieldError{validators.NewFieldPatchMalformed(err.Error())}
+	}
+
+	return nil
+}
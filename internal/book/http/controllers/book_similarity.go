@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultSimilarityThreshold is used when search.similaritythreshold is not configured
+	DefaultSimilarityThreshold = 0.3
+	maxCandidates              = 20
+)
+
+// similarityThreshold returns configured minimal similarity of a fuzzy match
+func similarityThreshold() float64 {
+	if viper.IsSet("search.similaritythreshold") {
+		return viper.GetFloat64("search.similaritythreshold")
+	}
+	return DefaultSimilarityThreshold
+}
+
+// fuzzyRequested returns true if client asked for typo tolerant lookup by passing fuzzy=true
+func fuzzyRequested(c *gin.Context) bool {
+	fuzzy, _ := strconv.ParseBool(c.Query("fuzzy"))
+	return fuzzy
+}
+
+// parseThreshold reads threshold query parameter, falling back to the configured one
+func parseThreshold(c *gin.Context) (float64, []ct.FieldError) {
+	param, ok := c.GetQuery("threshold")
+	if !ok {
+		return similarityThreshold(), nil
+	}
+
+	threshold, err := strconv.ParseFloat(param, 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return 0, []ct.FieldError{validators.FieldThresholdInvalid}
+	}
+
+	return threshold, nil
+}
+
+// respondSimilar responds with books whose field is similar to value, ranked by similarity
+func (b *BookService) respondSimilar(c *gin.Context, field string, value string) {
+	threshold, fieldErrors := parseThreshold(c)
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opSearch)
+	defer cancel()
+
+	candidates, err := b.repo.SearchSimilar(ctx, field, value, threshold, maxCandidates)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	if len(candidates) == 0 {
+		var notFound error = errors.NewBookNotFoundByAuthor(value)
+		if field == "title" {
+			notFound = errors.NewBookNotFoundByTitle(value)
+		}
+		errors.HandleBookError(c, notFound)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, candidates, nil)
+}
+
+// suggest attaches the most similar author or title to not found error of exact lookup, so client could ask
+// "did you mean ...?". Any other error, or failure to find a suggestion, leaves err as is
+func (b *BookService) suggest(ctx context.Context, err error, field string, value string) error {
+	if !errors.IsNotFoundByName(err) {
+		return err
+	}
+
+	candidates, searchErr := b.repo.SearchSimilar(ctx, field, value, similarityThreshold(), 1)
+	if searchErr != nil || len(candidates) == 0 {
+		return err
+	}
+
+	suggestion := candidates[0].Author
+	if field == "title" {
+		suggestion = candidates[0].Title
+	}
+
+	return errors.WithSuggestion(err, suggestion)
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	tagMatchAny = "any"
+	tagMatchAll = "all"
+)
+
+// bindTags reads the JSON array of tags of the request body. Tags are normalized, and the ones repeated once
+// normalized are kept once
+func bindTags(c *gin.Context) ([]string, error) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errors.NewBookBadBody(err.Error())
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errors.NewBookEmptyBody()
+	}
+
+	var names []string
+	if err = json.Unmarshal(body, &names); err != nil {
+		return nil, errors.NewBookBadBody("tags should be a JSON array of strings")
+	}
+
+	var fieldErrors []ct.FieldError
+	if len(names) == 0 {
+		fieldErrors = append(fieldErrors, validators.FieldTagsEmpty)
+	}
+	if len(names) > validators.MaxTags {
+		fieldErrors = append(fieldErrors, validators.FieldTagsTooMany)
+	}
+
+	var tags []string
+	seen := make(map[string]bool, len(names))
+
+	for idx, name := range names {
+		tag := entity.NormalizeTag(name)
+
+		switch {
+		case tag == "":
+			fieldErrors = append(fieldErrors, validators.NewFieldTagEmpty(idx))
+		case utf8.RuneCountInString(tag) > validators.MaxTagLength:
+			fieldErrors = append(fieldErrors, validators.NewFieldTagTooLong(idx))
+		case !seen[tag]:
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	if fieldErrors != nil {
+		return nil, errors.NewBookValidatorError(fieldErrors)
+	}
+
+	return tags, nil
+}
+
+// parseTagFilter reads tag parameters, which may be repeated, and tag_match parameter telling whether books have to
+// have any or all of the tags. Any is the default
+func parseTagFilter(c *gin.Context) ([]string, bool, []ct.FieldError) {
+	var fieldErrors []ct.FieldError
+
+	match := c.DefaultQuery("tag_match", tagMatchAny)
+	if match != tagMatchAny && match != tagMatchAll {
+		fieldErrors = append(fieldErrors, validators.FieldTagMatchInvalid)
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, param := range c.QueryArray("tag") {
+		tag := entity.NormalizeTag(param)
+
+		switch {
+		case tag == "":
+			fieldErrors = append(fieldErrors, validators.FieldTagParamEmpty)
+		case utf8.RuneCountInString(tag) > validators.MaxTagLength:
+			fieldErrors = append(fieldErrors, validators.FieldTagParamTooLong)
+		case !seen[tag]:
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > validators.MaxTags {
+		fieldErrors = append(fieldErrors, validators.FieldTagParamTooMany)
+	}
+
+	return tags, match == tagMatchAll, fieldErrors
+}
+
+// GetBookTags responds with tags of the book in alphabetical order
+func (b *BookService) GetBookTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	ctx, cancel := operationContext(c, opRead)
+	defer cancel()
+
+	tags, err := b.repo.GetBookTags(ctx, uint64(id))
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, tags, nil)
+}
+
+// TagBook adds a JSON array of tags to the book and responds with all its tags
+func (b *BookService) TagBook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	tags, err := bindTags(c)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	bookTags, err := b.repo.TagBook(ctx, uint64(id), tags)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, bookTags, nil)
+}
+
+// UntagBook removes a JSON array of tags from the book and responds with the tags left
+func (b *BookService) UntagBook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	tags, err := bindTags(c)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	bookTags, err := b.repo.UntagBook(ctx, uint64(id), tags)
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, bookTags, nil)
+}
+
+// GetTagCounts responds with a page of tags, each with the number of books having it, the most used first
+func (b *BookService) GetTagCounts(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opList)
+	defer cancel()
+
+	counts, total, err := b.repo.GetTagCounts(ctx, page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, counts, common_pagination.NewPageMeta(c, page, total))
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetTrash responds with a page of deleted books, the latest deleted first. Empty trash is not an error
+func (b *BookService) GetTrash(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleBookError(c, errors.NewBookValidatorError(fieldErrors))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opList)
+	defer cancel()
+
+	books, total, err := b.repo.GetTrash(ctx, page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, books, common_pagination.NewPageMeta(c, page, total))
+}
+
+// RestoreBook takes a deleted book out of the trash and responds with it
+func (b *BookService) RestoreBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+
+	if err != nil {
+		errors.HandleBookError(c, errors.NewBookInvalidSerial())
+		return
+	}
+
+	ctx, cancel := operationContext(c, opWrite)
+	defer cancel()
+
+	restored, err := b.repo.RestoreBook(ctx, uint64(id))
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	c.Header("ETag", bookETag(restored.Version))
+	common_response.Respond(c, http.StatusOK, restored.Book, nil)
+}
+
+// PurgeTrash removes for good the books deleted longer than older_than ago, or the whole trash when older_than is
+// not given, and responds with the number of purged books
+func (b *BookService) PurgeTrash(c *gin.Context) {
+	olderThan, err := time.ParseDuration(c.DefaultQuery("older_than", "0s"))
+	if err != nil || olderThan < 0 {
+		errors.HandleBookError(c, errors.NewBookValidatorError([]ct.FieldError{validators.FieldOlderThanInvalid}))
+		return
+	}
+
+	ctx, cancel := operationContext(c, opPurge)
+	defer cancel()
+
+	purged, err := b.repo.PurgeBooks(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		errors.HandleBookError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, purged, nil)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/book/http/errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// bookETag returns the strong entity tag of a book version
+func bookETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// ifMatchVersion returns the version of the book the client based its write on, taken from If-Match header. "*"
+// matches any version and is returned as 0. Weak or malformed tags can never match the book, so they are reported
+// as a mismatch
+func ifMatchVersion(c *gin.Context) (uint64, error) {
+	tag := strings.TrimSpace(c.GetHeader("If-Match"))
+	if tag == "" {
+		return 0, errors.NewBookVersionRequired()
+	}
+	if tag == "*" {
+		return 0, nil
+	}
+
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, errors.NewBookVersionMismatch(0)
+	}
+
+	version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version == 0 {
+		return 0, errors.NewBookVersionMismatch(0)
+	}
+
+	return version, nil
+}
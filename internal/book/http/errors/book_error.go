@@ -1,7 +1,9 @@
 package errors
 
 import (
+	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
 	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
@@ -11,10 +13,20 @@ import (
 
 type bookNotFoundByTitle struct {
 	common_errors.CommonError
+	Suggestion string `json:"did_you_mean,omitempty"`
 }
 
 type bookNotFoundByAuthor struct {
 	common_errors.CommonError
+	Suggestion string `json:"did_you_mean,omitempty"`
+}
+
+type bookNotFoundByISBN struct {
+	common_errors.CommonError
+}
+
+type bookNothingMatches struct {
+	common_errors.CommonError
 }
 
 type booksNotFound struct{
@@ -25,6 +37,18 @@ type bookBadBody struct{
 	common_errors.CommonError
 }
 
+// ImportRowError tells why a row of an import was rejected. Row is the 1-based number of the record, CSV header not
+// counted
+type ImportRowError struct {
+	Row    int                    `json:"row"`
+	Fields []validator.FieldError `json:"fields"`
+}
+
+type bookImportRejected struct {
+	common_errors.CommonError
+	Rows []ImportRowError `json:"rows"`
+}
+
 type bookBadScanOptions struct {
 	common_errors.CommonError
 }
@@ -33,10 +57,35 @@ type bookTitleAlreadyExists struct{
 	common_errors.CommonError
 }
 
+type bookISBNAlreadyExists struct {
+	common_errors.CommonError
+}
+
 type bookCouldNotQuery struct {
 	common_errors.CommonError
 }
 
+type bookQueryTimeout struct {
+	common_errors.CommonError
+}
+
+type bookVersionMismatch struct {
+	common_errors.CommonError
+	CurrentVersion uint64 `json:"current_version,omitempty"`
+}
+
+type bookVersionRequired struct {
+	common_errors.CommonError
+}
+
+type bookPatchTestFailed struct {
+	common_errors.CommonError
+}
+
+type bookUnsupportedPatch struct {
+	common_errors.CommonError
+}
+
 type bookInvalidSerial struct{
 	common_errors.CommonError
 }
@@ -55,13 +104,58 @@ type bookValidatorError struct {
 
 func NewBookNotFoundByTitle(title string) bookNotFoundByTitle {
 	return bookNotFoundByTitle{
-		common_errors.CommonError{Msg: fmt.Sprintf("Book(s) with title %v not found in db", title)},
+		CommonError: common_errors.CommonError{Msg: fmt.Sprintf("Book(s) with title %v not found in db", title)},
 	}
 }
 
 func NewBookNotFoundByAuthor(author string) bookNotFoundByAuthor {
 	return bookNotFoundByAuthor{
-		common_errors.CommonError{Msg: fmt.Sprintf("Book(s) with author %v not found in db", author)},
+		CommonError: common_errors.CommonError{Msg: fmt.Sprintf("Book(s) with author %v not found in db", author)},
+	}
+}
+
+func NewBookNotFoundByISBN(isbn string) bookNotFoundByISBN {
+	return bookNotFoundByISBN{
+		common_errors.CommonError{Msg: fmt.Sprintf("Book with ISBN %v not found in db", isbn)},
+	}
+}
+
+// IsRowError returns true if err was caused by the book written rather than by the database, so a write of another
+// book may still succeed
+func IsRowError(err error) bool {
+	switch err.(type) {
+	case bookTitleAlreadyExists, bookISBNAlreadyExists, bookValidatorError, bookBadBody:
+		return true
+	}
+	return false
+}
+
+// IsNotFoundByName returns true if err is not found by author or title error, i.e. it may be given a suggestion
+func IsNotFoundByName(err error) bool {
+	switch err.(type) {
+	case bookNotFoundByAuthor, bookNotFoundByTitle:
+		return true
+	}
+	return false
+}
+
+// WithSuggestion returns copy of not found by author or title error with "did you mean" suggestion attached.
+// Other errors are returned as is
+func WithSuggestion(err error, suggestion string) error {
+	switch e := err.(type) {
+	case bookNotFoundByAuthor:
+		e.Suggestion = suggestion
+		return e
+	case bookNotFoundByTitle:
+		e.Suggestion = suggestion
+		return e
+	}
+	return err
+}
+
+func NewBookNothingMatches(query string) bookNothingMatches {
+	return bookNothingMatches{
+		common_errors.CommonError{Msg: fmt.Sprintf("No books match %v", query)},
 	}
 }
 
@@ -71,12 +165,33 @@ func NewBooksNotFound() booksNotFound {
 	}
 }
 
+func NewBookBadBody(msg string) bookBadBody {
+	return bookBadBody{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not read the body: %v", msg)},
+	}
+}
+
+// NewBookImportRejected returns the error of an all-or-nothing import, which was not done because of rejected rows
+func NewBookImportRejected(rows []ImportRowError) bookImportRejected {
+	return bookImportRejected{
+		CommonError: common_errors.CommonError{Msg: fmt.Sprintf("%v row(s) rejected, nothing was imported", len(rows))},
+		Rows:        rows,
+	}
+}
+
 func NewBookTitleAlreadyExists() bookTitleAlreadyExists {
 	return bookTitleAlreadyExists{
 		common_errors.CommonError{Msg: "Requested title already exists"},
 	}
 }
 
+// NewBookISBNAlreadyExists returns the error of a write or a restore giving a book the ISBN of another book
+func NewBookISBNAlreadyExists(isbn string) bookISBNAlreadyExists {
+	return bookISBNAlreadyExists{
+		common_errors.CommonError{Msg: fmt.Sprintf("Book with ISBN %v already exists", isbn)},
+	}
+}
+
 func NewBookBadScanOptions(msg string) bookBadScanOptions {
 	return bookBadScanOptions{
 		common_errors.CommonError{Msg: fmt.Sprintf("Bad SQL scan options: %v", msg)},
@@ -89,6 +204,44 @@ func NewBookCouldNotQuery(msg string) bookCouldNotQuery {
 	}
 }
 
+func NewBookQueryTimeout() bookQueryTimeout {
+	return bookQueryTimeout{
+		common_errors.CommonError{Msg: "Query did not complete in time"},
+	}
+}
+
+// Unwrap lets errors.Is match the query timeout against context.DeadlineExceeded
+func (b bookQueryTimeout) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// NewBookVersionMismatch returns the error of a write based on a stale version of the book. Zero current version
+// means it is not known
+func NewBookVersionMismatch(current uint64) bookVersionMismatch {
+	return bookVersionMismatch{
+		CommonError:    common_errors.CommonError{Msg: "Book was modified by someone else, fetch it again and retry"},
+		CurrentVersion: current,
+	}
+}
+
+func NewBookVersionRequired() bookVersionRequired {
+	return bookVersionRequired{
+		common_errors.CommonError{Msg: "If-Match header with the ETag of the book is required"},
+	}
+}
+
+func NewBookPatchTestFailed(path string) bookPatchTestFailed {
+	return bookPatchTestFailed{
+		common_errors.CommonError{Msg: fmt.Sprintf("Patch test of %v failed, nothing was changed", path)},
+	}
+}
+
+func NewBookUnsupportedPatch(contentType string) bookUnsupportedPatch {
+	return bookUnsupportedPatch{
+		common_errors.CommonError{Msg: fmt.Sprintf("Patch of type %v is not supported, use application/merge-patch+json or application/json-patch+json", contentType)},
+	}
+}
+
 func NewBookInvalidSerial() bookInvalidSerial {
 	return bookInvalidSerial{
 		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
@@ -125,15 +278,29 @@ func (b bookValidatorError) Error() string {
 
 func HandleBookError(c *gin.Context, err error) {
 	switch err.(type) {
-	case booksNotFound, bookNotFoundByAuthor, bookNotFoundByTitle:
+	case booksNotFound, bookNotFoundByAuthor, bookNotFoundByTitle, bookNotFoundByISBN, bookNothingMatches:
 		common_errors.RespondNotFound(c, err)
-	case bookValidatorError, bookInvalidSerial, bookEmptyBody:
+	case bookValidatorError, bookInvalidSerial, bookEmptyBody, bookBadBody, bookImportRejected:
 		common_errors.RespondBadRequest(c, err)
-	case bookTitleAlreadyExists:
+	case bookTitleAlreadyExists, bookISBNAlreadyExists:
 		common_errors.RespondAlreadyExists(c, err)
+	case bookVersionMismatch:
+		common_errors.RespondPreconditionFailed(c, err)
+	case bookPatchTestFailed:
+		common_errors.RespondConflict(c, err)
+	case bookUnsupportedPatch:
+		common_errors.RespondUnsupportedMediaType(c, err)
+	case bookVersionRequired:
+		common_errors.RespondPreconditionRequired(c, err)
+	case bookQueryTimeout:
+		common_errors.RespondGatewayTimeout(c, err)
 	case bookUnexpectedError, bookCouldNotQuery:
 		common_errors.RespondInternalError(c, err)
 	default:
+		if goerrors.Is(err, context.DeadlineExceeded) {
+			common_errors.RespondGatewayTimeout(c, NewBookQueryTimeout())
+			return
+		}
 		common_errors.RespondInternalError(c, err)
 	}
 }
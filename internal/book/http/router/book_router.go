@@ -1,16 +1,37 @@
 package router
 
 import (
-	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
 	"github.com/foxfurry/simple-rest/internal/book/http/controllers"
 	"github.com/foxfurry/simple-rest/internal/book/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterBookRoutes(router *gin.Engine, db *sql.DB) {
-	bookRepo := controllers.NewBookService(db)
+// bookPolicy lets librarians change books one by one and read what was changed, while only admins may delete all of
+// them at once
+var bookPolicy = common_auth.Policy{
+	"GET /book/trash":        common_auth.PermReadAudit,
+	"GET /book/:id/history":  common_auth.PermReadAudit,
+	"GET /audit":             common_auth.PermReadAudit,
+	"POST /book/":            common_auth.PermWrite,
+	"POST /book/import":      common_auth.PermWrite,
+	"POST /book/:id/restore": common_auth.PermWrite,
+	"POST /book/:id/tags":    common_auth.PermWrite,
+	"PUT /book/:id":          common_auth.PermWrite,
+	"PATCH /book/:id":        common_auth.PermWrite,
+	"DELETE /book/:id":       common_auth.PermWrite,
+	"DELETE /book/:id/tags":  common_auth.PermWrite,
+	"DELETE /book/":          common_auth.PermDeleteAll,
+	"DELETE /book/trash":     common_auth.PermDeleteAll,
+}
+
+// RegisterBookRoutes registers routes of books. Reading books is open to everyone, changing them and reading their
+// audit is guarded by bookPolicy
+func RegisterBookRoutes(router *gin.Engine, repo repository.BookRepository, uow repository.BookUnitOfWork, guard *common_auth.Guard) {
+	bookRepo := controllers.NewBookService(repo, uow)
 
-	book := router.Group("/book")
+	book := router.Group("/book", guard.Enforce(bookPolicy))
 	{
 		book.GET("/:id", bookRepo.GetBook)
 
@@ -20,14 +41,34 @@ func RegisterBookRoutes(router *gin.Engine, db *sql.DB) {
 		book.GET("/author/:author", bookRepo.SearchByAuthor)
 		book.GET("/author/", bookRepo.SearchByAuthor)
 
+		book.GET("/isbn/:isbn", bookRepo.GetBookByISBN)
+
+		book.GET("/search", bookRepo.FullTextSearch)
+		book.GET("/export", bookRepo.ExportBooks)
+		book.GET("/trash", bookRepo.GetTrash)
+		book.GET("/tags", bookRepo.GetTagCounts)
+		book.GET("/:id/history", bookRepo.GetBookHistory)
+		book.GET("/:id/tags", bookRepo.GetBookTags)
+
 		book.GET("/", bookRepo.GetAllBooks)
 
 		book.POST("/", bookRepo.SaveBook)
+		book.POST("/import", bookRepo.ImportBooks)
+		book.POST("/:id/restore", bookRepo.RestoreBook)
+		book.POST("/:id/tags", bookRepo.TagBook)
 
-		book.PUT("/", bookRepo.UpdateBook)
+		book.PUT("/:id", bookRepo.UpdateBook)
+		book.PATCH("/:id", bookRepo.PatchBook)
 
 		book.DELETE("/:id", bookRepo.DeleteBook)
 		book.DELETE("/", bookRepo.DeleteAllBooks)
+		book.DELETE("/trash", bookRepo.PurgeTrash)
+		book.DELETE("/:id/tags", bookRepo.UntagBook)
+	}
+
+	audit := router.Group("/audit", guard.Enforce(bookPolicy))
+	{
+		audit.GET("", bookRepo.GetAudit)
 	}
 
 	validators.RegisterBookValidators()
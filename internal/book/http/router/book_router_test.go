@@ -0,0 +1,88 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	bookdb "github.com/foxfurry/simple-rest/internal/book/db"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type denialLog []common_auth.Denial
+
+func (l *denialLog) RecordDenial(_ context.Context, denial common_auth.Denial) error {
+	*l = append(*l, denial)
+	return nil
+}
+
+func TestRegisterBookRoutes_Policy(t *testing.T) {
+	signer := common_auth.NewSigner([]byte("secret"), time.Minute, time.Hour)
+	issue := func(role common_auth.Role) string {
+		tokens, err := signer.Issue(common_auth.User{ID: 1, Username: string(role), Role: role})
+		assert.NoError(t, err)
+		return tokens.AccessToken
+	}
+	reader, librarian, admin := issue(common_auth.RoleReader), issue(common_auth.RoleLibrarian), issue(common_auth.RoleAdmin)
+
+	var denials denialLog
+	repo := bookdb.NewBookMemoryRepo()
+	router := gin.New()
+	RegisterBookRoutes(router, repo, repo, common_auth.NewGuard(signer, &denials))
+
+	request := func(method string, url string, body string, token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		if token != "" {
+			req.Header.Set(common_auth.HeaderAuthorization, "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	book := `{"title": "Test", "author": "Test", "year": 1}`
+
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "/book/", book, ""))
+	assert.Equal(t, http.StatusUnauthorized, request("DELETE", "/book/", "", ""))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/book/", book, reader))
+	assert.Equal(t, http.StatusOK, request("POST", "/book/", book, librarian))
+	assert.Equal(t, http.StatusOK, request("GET", "/book/1", "", ""), "Reading should stay anonymous")
+	assert.Equal(t, http.StatusOK, request("GET", "/book/1", "", reader))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/book/", "", librarian))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/book/trash", "", librarian))
+	assert.Equal(t, http.StatusOK, request("DELETE", "/book/", "", admin))
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/audit", "", ""))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/book/trash", "", reader))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/book/1/history", "", reader))
+	assert.Equal(t, http.StatusOK, request("GET", "/audit", "", librarian))
+	assert.Equal(t, http.StatusOK, request("GET", "/book/trash", "", librarian))
+	assert.Equal(t, http.StatusOK, request("GET", "/book/1/history", "", admin))
+
+	if assert.Len(t, denials, 8, "Every denied request should be recorded") {
+		assert.Equal(t, http.StatusUnauthorized, denials[0].Status)
+		assert.Equal(t, common_request.AnonymousActor, denials[0].Actor)
+
+		last := denials[4]
+		assert.Equal(t, "librarian", last.Actor)
+		assert.Equal(t, common_auth.RoleLibrarian, last.Role)
+		assert.Equal(t, "DELETE", last.Method)
+		assert.Equal(t, "/book/trash", last.Route)
+		assert.Equal(t, common_auth.PermDeleteAll, last.Permission)
+		assert.Equal(t, http.StatusForbidden, last.Status)
+	}
+
+	t.Run("Every route changing books has a policy", func(t *testing.T) {
+		for _, route := range router.Routes() {
+			if route.Method == http.MethodGet {
+				continue
+			}
+			_, ok := bookPolicy.Permission(route.Method, route.Path)
+			assert.True(t, ok, "Route %v %v is not in bookPolicy", route.Method, route.Path)
+		}
+	})
+}
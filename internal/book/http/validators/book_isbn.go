@@ -0,0 +1,70 @@
+package validators
+
+import "strings"
+
+// isbnSeparators are dropped from ISBN before it is checked, so "978-0-261-10221-7" and "978 0 261 10221 7" are the
+// same ISBN
+var isbnSeparators = strings.NewReplacer("-", "", " ", "")
+
+// NormalizeISBN returns ISBN-13 for ISBN-10 or ISBN-13 with or without hyphens, and false if isbn has a wrong length,
+// wrong characters or a wrong check digit. Books are stored and looked up by the ISBN-13 it returns
+func NormalizeISBN(isbn string) (string, bool) {
+	isbn = strings.ToUpper(isbnSeparators.Replace(isbn))
+
+	switch len(isbn) {
+	case 10:
+		if !validISBN10(isbn) {
+			return "", false
+		}
+		return isbn10To13(isbn), true
+	case 13:
+		if !validISBN13(isbn) {
+			return "", false
+		}
+		return isbn, true
+	}
+
+	return "", false
+}
+
+// validISBN10 checks the mod 11 check digit of ISBN-10. Check digit X stands for 10
+func validISBN10(isbn string) bool {
+	sum := 0
+	for idx, r := range isbn {
+		var digit int
+		switch {
+		case r >= '0' && r <= '9':
+			digit = int(r - '0')
+		case r == 'X' && idx == 9:
+			digit = 10
+		default:
+			return false
+		}
+		sum += digit * (10 - idx)
+	}
+	return sum%11 == 0
+}
+
+// validISBN13 checks the mod 10 check digit of ISBN-13, whose digits are weighted 1 and 3 in turn
+func validISBN13(isbn string) bool {
+	sum := 0
+	for idx, r := range isbn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * (1 + 2*(idx%2))
+	}
+	return sum%10 == 0
+}
+
+// isbn10To13 converts valid ISBN-10 to ISBN-13 by prefixing it with 978 and computing the new check digit
+func isbn10To13(isbn string) string {
+	body := "978" + isbn[:9]
+
+	sum := 0
+	for idx, r := range body {
+		sum += int(r-'0') * (1 + 2*(idx%2))
+	}
+
+	return body + string(rune('0'+(10-sum%10)%10))
+}
@@ -13,12 +13,14 @@ import (
 const (
 	idTag       = "validID"
 	yearTag     = "validYear"
+	isbnTag     = "validISBN"
 	requiredTag = "required"
 	emptyFieldMsg = "cannot be empty"
 )
 
 var(
 	invalidYearMsg = fmt.Sprintf("Year should be between -868 and %v", time.Now().Year())
+	invalidISBNMsg = "should be ISBN-10 or ISBN-13 with a valid check digit"
 
 	FieldTitleEmpty = common_translators.FieldError{
 		Field: "Title",
@@ -32,12 +34,173 @@ var(
 		Field: "Year",
 		Msg:   "Year " + emptyFieldMsg,
 	}
+	FieldISBNInvalid = common_translators.FieldError{
+		Field: "ISBN",
+		Msg:   "ISBN " + invalidISBNMsg,
+	}
+	FieldISBNParamInvalid = common_translators.FieldError{
+		Field: "isbn",
+		Msg:   "isbn " + invalidISBNMsg,
+	}
+	FieldQueryEmpty = common_translators.FieldError{
+		Field: "q",
+		Msg:   "Search query " + emptyFieldMsg,
+	}
+	FieldThresholdInvalid = common_translators.FieldError{
+		Field: "threshold",
+		Msg:   "threshold should be a number between 0 and 1",
+	}
+	FieldYearGTEInvalid = common_translators.FieldError{
+		Field: "year_gte",
+		Msg:   "year_gte should be an integer",
+	}
+	FieldYearLTEInvalid = common_translators.FieldError{
+		Field: "year_lte",
+		Msg:   "year_lte should be an integer",
+	}
+	FieldYearRangeInvalid = common_translators.FieldError{
+		Field: "year_gte",
+		Msg:   "year_gte cannot be greater than year_lte",
+	}
+	FieldFormatInvalid = common_translators.FieldError{
+		Field: "format",
+		Msg:   "format should be csv or ndjson, or given by Content-Type text/csv or application/x-ndjson",
+	}
+	FieldExportFormatInvalid = common_translators.FieldError{
+		Field: "format",
+		Msg:   "format should be csv, ndjson or json",
+	}
+	FieldAtomicInvalid = common_translators.FieldError{
+		Field: "atomic",
+		Msg:   "atomic should be true or false",
+	}
+	FieldOlderThanInvalid = common_translators.FieldError{
+		Field: "older_than",
+		Msg:   "older_than should be a non-negative duration, like 720h",
+	}
+	FieldBookIDInvalid = common_translators.FieldError{
+		Field: "book_id",
+		Msg:   "book_id should be positive non-null number",
+	}
+	FieldActionInvalid = common_translators.FieldError{
+		Field: "action",
+		Msg:   "action should be create, update, patch, delete, restore or purge",
+	}
+	FieldSinceInvalid = common_translators.FieldError{
+		Field: "since",
+		Msg:   "since should be a time in RFC 3339 format, like 2021-09-01T00:00:00Z",
+	}
+	FieldUntilInvalid = common_translators.FieldError{
+		Field: "until",
+		Msg:   "until should be a time in RFC 3339 format, like 2021-09-01T00:00:00Z",
+	}
+	FieldTimeRangeInvalid = common_translators.FieldError{
+		Field: "until",
+		Msg:   "until should be later than since",
+	}
+	FieldImportEmpty = common_translators.FieldError{
+		Field: "body",
+		Msg:   "Import " + emptyFieldMsg,
+	}
+	FieldYearNotInteger = common_translators.FieldError{
+		Field: "Year",
+		Msg:   "Year should be an integer",
+	}
 	FieldYearInvalid = common_translators.FieldError{
 		Field: "Year",
 		Msg:   fmt.Sprintf("Year should be between -868 and %v", time.Now().Year()),
 	}
+	FieldTagsEmpty = common_translators.FieldError{
+		Field: "body",
+		Msg:   "Tags " + emptyFieldMsg,
+	}
+	FieldTagsTooMany = common_translators.FieldError{
+		Field: "body",
+		Msg:   fmt.Sprintf("Cannot pass more than %v tags at once", MaxTags),
+	}
+	FieldTagParamEmpty = common_translators.FieldError{
+		Field: "tag",
+		Msg:   "tag " + emptyFieldMsg,
+	}
+	FieldTagParamTooLong = common_translators.FieldError{
+		Field: "tag",
+		Msg:   fmt.Sprintf("tag cannot be longer than %v characters", MaxTagLength),
+	}
+	FieldTagParamTooMany = common_translators.FieldError{
+		Field: "tag",
+		Msg:   fmt.Sprintf("Cannot filter by more than %v tags", MaxTags),
+	}
+	FieldTagMatchInvalid = common_translators.FieldError{
+		Field: "tag_match",
+		Msg:   "tag_match should be any or all",
+	}
 )
 
+const (
+	MaxTagLength = 50 // Characters of a normalized tag
+	MaxTags      = 20 // Tags passed to a single request
+)
+
+// NewFieldTagEmpty returns a field error for a tag of the body which is empty once normalized
+func NewFieldTagEmpty(idx int) common_translators.FieldError {
+	return common_translators.CreateFieldError(fmt.Sprintf("body[%v]", idx), "Tag "+emptyFieldMsg)
+}
+
+// NewFieldTagTooLong returns a field error for a tag of the body longer than MaxTagLength once normalized
+func NewFieldTagTooLong(idx int) common_translators.FieldError {
+	return common_translators.CreateFieldError(fmt.Sprintf("body[%v]", idx), fmt.Sprintf("Tag cannot be longer than %v characters", MaxTagLength))
+}
+
+// NewFieldSortInvalid returns a field error for a column books cannot be ordered by
+func NewFieldSortInvalid(column string) common_translators.FieldError {
+	return common_translators.CreateFieldError("sort", fmt.Sprintf("Books cannot be sorted by %v", column))
+}
+
+// NewFieldSortRepeated returns a field error for a column books are sorted by more than once
+func NewFieldSortRepeated(column string) common_translators.FieldError {
+	return common_translators.CreateFieldError("sort", fmt.Sprintf("Books are already sorted by %v", column))
+}
+
+// NewFieldColumnMissing returns a field error for a required column missing from the CSV header
+func NewFieldColumnMissing(column string) common_translators.FieldError {
+	return common_translators.CreateFieldError("header", fmt.Sprintf("Column %v is missing", column))
+}
+
+// NewFieldImportTooLarge returns a field error for an import of more than limit rows
+func NewFieldImportTooLarge(limit int) common_translators.FieldError {
+	return common_translators.CreateFieldError("body", fmt.Sprintf("Import cannot have more than %v rows", limit))
+}
+
+// NewFieldRowMalformed returns a field error for a row which could not be parsed at all
+func NewFieldRowMalformed(reason string) common_translators.FieldError {
+	return common_translators.CreateFieldError("row", fmt.Sprintf("Row is malformed: %v", reason))
+}
+
+// NewFieldRowNotSaved returns a field error for a valid row which the database refused to store, e.g. for a taken ISBN
+func NewFieldRowNotSaved(reason string) common_translators.FieldError {
+	return common_translators.CreateFieldError("row", fmt.Sprintf("Row could not be saved: %v", reason))
+}
+
+// NewFieldNotPatchable returns a field error for a field a patch cannot change
+func NewFieldNotPatchable(field string) common_translators.FieldError {
+	return common_translators.CreateFieldError(field, fmt.Sprintf("%v cannot be patched", field))
+}
+
+// NewFieldNotString returns a field error for a patched field whose value is not a string
+func NewFieldNotString(field string) common_translators.FieldError {
+	return common_translators.CreateFieldError(field, fmt.Sprintf("%v should be a string", field))
+}
+
+// NewFieldPatchMalformed returns a field error for a patch document of the wrong shape
+func NewFieldPatchMalformed(reason string) common_translators.FieldError {
+	return common_translators.CreateFieldError("patch", fmt.Sprintf("Patch is malformed: %v", reason))
+}
+
+// NewFieldPatchOperationInvalid returns a field error for an operation of JSON Patch which cannot be applied
+func NewFieldPatchOperationInvalid(idx int, reason string) common_translators.FieldError {
+	return common_translators.CreateFieldError(fmt.Sprintf("patch[%v]", idx), reason)
+}
+
 var validID validator.Func = func(fl validator.FieldLevel) bool {
 	id := fl.Field().Int()
 	if id < 1 {
@@ -54,6 +217,16 @@ var validYear validator.Func = func(fl validator.FieldLevel) bool {
 	return true
 }
 
+// validISBN accepts ISBN-10 and ISBN-13, with or without hyphens. Empty ISBN is left to omitempty
+var validISBN validator.Func = func(fl validator.FieldLevel) bool {
+	_, ok := NormalizeISBN(fl.Field().String())
+	return ok
+}
+
+var trslValidISBN validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(isbnTag, "{0} "+invalidISBNMsg, true)
+}
+
 var trslValidYear validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
 	return ut.Add(yearTag, invalidYearMsg, true)
 }
@@ -76,6 +249,12 @@ func RegisterBookValidators() {
 			return t
 		})
 
+		v.RegisterValidation(isbnTag, validISBN)
+		v.RegisterTranslation(isbnTag, errTranslator, trslValidISBN, func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T(isbnTag, fe.Field())
+			return t
+		})
+
 		v.RegisterValidation(idTag, validID)
 		v.RegisterTranslation(idTag, errTranslator, trslValidID, func(ut ut.Translator, fe validator.FieldError) string {
 			t, _ := ut.T(yearTag, fe.Field())
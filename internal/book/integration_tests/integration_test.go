@@ -10,16 +10,25 @@ import (
 	"github.com/foxfurry/simple-rest/internal/book/http/validators"
 	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
 	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
-var baseURL = "http://localhost:8080/book"
+var (
+	baseURL = "http://localhost:8080/book"
+	authURL = "http://localhost:8080/auth"
+
+	accessToken     string
+	accessTokenOnce sync.Once
+)
 
 type expectedErrors struct {
 	Msg    string                          `json:"msg,omitempty"`
@@ -46,22 +55,54 @@ func TestMain(m *testing.M) {
 
 func testMain(m *testing.M) int {
 	configs.LoadConfig()
+	viper.Set("auth.admin", "integration")
+	viper.Set("auth.adminpassword", "integration-password")
 	go app.NewTestApp().Start()
 	return m.Run()
 }
 
+// authorize signs the request in as the test user. The test app appoints the test user admin on start, registering
+// the user if needed, for purgeDB to delete all the books
+func authorize(req *http.Request) {
+	accessTokenOnce.Do(func() {
+		credentials, _ := json.Marshal(map[string]string{"username": "integration", "password": "integration-password"})
+
+		res, err := http.Post(authURL+"/login", "application/json", bytes.NewReader(credentials))
+		if err != nil {
+			log.Panicf("Could not sign in the test user: %v", err)
+		}
+		defer res.Body.Close()
+
+		var login struct {
+			Data struct {
+				AccessToken string `json:"access_token"`
+			} `json:"data"`
+		}
+		if err = json.NewDecoder(res.Body).Decode(&login); err != nil {
+			log.Panicf("Could not read tokens of the test user: %v", err)
+		}
+		accessToken = login.Data.AccessToken
+	})
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+}
+
+// purgeDB deletes every book and purges the trash
 func purgeDB() {
-	req, err := http.NewRequest(http.MethodDelete, baseURL, strings.NewReader(""))
+	for _, url := range []string{baseURL, baseURL + "/trash"} {
+		req, err := http.NewRequest(http.MethodDelete, url, strings.NewReader(""))
 
-	if err != nil {
-		log.Fatalf("Could not create a request: %v", err)
-	}
+		if err != nil {
+			log.Fatalf("Could not create a request: %v", err)
+		}
+		authorize(req)
 
-	client := &http.Client{}
-	res, err := client.Do(req)
+		client := &http.Client{}
+		res, err := client.Do(req)
 
-	if err != nil {
-		log.Panicf("Could not purge database: %v %v", res, err)
+		if err != nil {
+			log.Panicf("Could not purge database: %v %v", res, err)
+		}
 	}
 }
 
@@ -277,6 +318,7 @@ func TestSaveBook(t *testing.T) {
 			}
 
 			req, _ := http.NewRequest(tc.requestMethod, tc.requestURL, bytes.NewReader(jsonRequest))
+			authorize(req)
 			resp, err := client.Do(req)
 			if err != nil {
 				t.Errorf("Could not complete the request: %v", err)
@@ -322,7 +364,7 @@ func TestGetBook(t *testing.T) {
 		requestBody    []entity.Book
 		requestMethod  string
 		requestURL     string
-		requestID      string
+		requestID      string // Empty for the id of the last book saved, since ids keep growing across purges
 		expectedStatus int
 		expectedBody   *entity.Book
 		expectedError  expectedErrors
@@ -346,7 +388,6 @@ func TestGetBook(t *testing.T) {
 			},
 			requestMethod:  getMethod,
 			requestURL:     getURL,
-			requestID:      "2",
 			expectedStatus: 200,
 			expectedBody: &entity.Book{
 				Title:       "Brave New World",
@@ -392,6 +433,7 @@ func TestGetBook(t *testing.T) {
 		purgeDB()
 		t.Run(tc.testName, func(t *testing.T) {
 			client := &http.Client{}
+			requestID := tc.requestID
 
 			if tc.requestBody != nil {
 				var jsonRequest []byte = nil
@@ -400,15 +442,22 @@ func TestGetBook(t *testing.T) {
 					jsonRequest, _ = json.Marshal(book)
 
 					req, _ := http.NewRequest(http.MethodPost, baseURL, bytes.NewReader(jsonRequest))
+					authorize(req)
 
 					resp, err := client.Do(req)
 					if err != nil || resp.StatusCode != http.StatusOK {
 						t.Errorf("Could not complete the request: %v", err)
+						continue
+					}
+
+					saved := singleResponse{}
+					if err = json.NewDecoder(resp.Body).Decode(&saved); err == nil && saved.Data != nil && tc.requestID == "" {
+						requestID = strconv.FormatUint(saved.Data.ID, 10)
 					}
 				}
 			}
 
-			url := getURL + tc.requestID
+			url := getURL + requestID
 
 			resp, err := http.Get(url)
 			if err != nil {
@@ -561,6 +610,7 @@ func TestGetAllBook(t *testing.T) {
 					jsonRequest, _ = json.Marshal(book)
 
 					req, _ := http.NewRequest(http.MethodPost, baseURL, bytes.NewReader(jsonRequest))
+					authorize(req)
 
 					resp, err := client.Do(req)
 					if err != nil || resp.StatusCode != http.StatusOK {
@@ -752,6 +802,7 @@ func TestSearchByAuthorBook(t *testing.T) {
 					jsonRequest, _ = json.Marshal(book)
 
 					req, _ := http.NewRequest(http.MethodPost, baseURL, bytes.NewReader(jsonRequest))
+					authorize(req)
 
 					resp, err := client.Do(req)
 					if err != nil || resp.StatusCode != http.StatusOK {
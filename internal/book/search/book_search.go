@@ -0,0 +1,38 @@
+package search
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/common/search"
+)
+
+const KindBook = "book"
+
+// BookSearchProvider lets books take part in the cross-media search
+type BookSearchProvider struct {
+	repo repository.BookRepository
+}
+
+func NewBookSearchProvider(repo repository.BookRepository) BookSearchProvider {
+	return BookSearchProvider{repo: repo}
+}
+
+var _ search.Provider = BookSearchProvider{}
+
+func (p BookSearchProvider) Kinds() []string {
+	return []string{KindBook}
+}
+
+func (p BookSearchProvider) Search(ctx context.Context, query string, limit int) ([]search.Hit, error) {
+	books, err := p.repo.SearchByKeyword(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, 0, len(books))
+	for _, book := range books {
+		hits = append(hits, search.Hit{Kind: KindBook, ID: book.ID, Title: book.Title, Item: book})
+	}
+
+	return hits, nil
+}
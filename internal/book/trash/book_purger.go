@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/book/domain/repository"
+	"log"
+	"time"
+)
+
+// BookPurger removes for good the books deleted longer than the retention period ago
+type BookPurger struct {
+	repo      repository.BookRepository
+	retention time.Duration
+	interval  time.Duration
+	timeout   time.Duration
+}
+
+// NewBookPurger returns purger of books deleted longer than retention ago, run every interval. Zero timeout lets a
+// purge run as long as it takes
+func NewBookPurger(repo repository.BookRepository, retention time.Duration, interval time.Duration, timeout time.Duration) *BookPurger {
+	return &BookPurger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		timeout:   timeout,
+	}
+}
+
+// Run purges the trash right away and then every interval, until ctx is done. Without positive retention and
+// interval it does nothing, so books stay in the trash until purged by hand
+func (p *BookPurger) Run(ctx context.Context) {
+	if p.retention <= 0 || p.interval <= 0 {
+		log.Printf("Trash is not purged automatically")
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the books deleted longer than retention before now. Failed purge is only logged, the next run
+// picks up the books it left
+func (p *BookPurger) Purge(ctx context.Context, now time.Time) (int64, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	deletedBefore := now.Add(-p.retention)
+
+	purged, err := p.repo.PurgeBooks(ctx, deletedBefore)
+	if err != nil {
+		log.Printf("Could not purge the trash: %v", err)
+		return 0, err
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %v book(s) deleted before %v", purged, deletedBefore.Format(time.RFC3339))
+	}
+
+	return purged, nil
+}
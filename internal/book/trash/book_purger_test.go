@@ -0,0 +1,69 @@
+package trash
+
+import (
+	"context"
+	bookDB "github.com/foxfurry/simple-rest/internal/book/db"
+	"github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBookPurger_Purge(t *testing.T) {
+	repo := bookDB.NewBookMemoryRepo()
+	for _, title := range []string{"Solaris", "Eden"} {
+		if _, err := repo.SaveBook(context.Background(), &entity.Book{Title: title, Author: "Stanislaw Lem", Year: 1961}); err != nil {
+			t.Fatalf("Could not save the book: %v", err)
+		}
+	}
+
+	_, err := repo.DeleteBook(context.Background(), 1)
+	assert.NoError(t, err)
+
+	purger := NewBookPurger(repo, time.Hour, time.Minute, time.Second)
+
+	purged, err := purger.Purge(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "Book deleted within the retention period should be kept")
+
+	purged, err = purger.Purge(context.Background(), time.Now().Add(time.Hour+time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, total, err := repo.GetTrash(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	_, err = repo.GetBook(context.Background(), 2)
+	assert.NoError(t, err, "Live books should never be purged")
+}
+
+func TestBookPurger_Run(t *testing.T) {
+	repo := bookDB.NewBookMemoryRepo()
+	if _, err := repo.SaveBook(context.Background(), &entity.Book{Title: "Solaris", Author: "Stanislaw Lem", Year: 1961}); err != nil {
+		t.Fatalf("Could not save the book: %v", err)
+	}
+	_, err := repo.DeleteBook(context.Background(), 1)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewBookPurger(repo, time.Nanosecond, 10*time.Millisecond, 0).Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, total, err := repo.GetTrash(context.Background(), 10, 0)
+		return err == nil && total == 0
+	}, time.Second, 10*time.Millisecond, "Deleted book should be purged in background")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Purger should stop once the context is done")
+	}
+
+	NewBookPurger(repo, 0, time.Minute, 0).Run(context.Background()) // Returns at once without retention
+}
@@ -0,0 +1,484 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/collection/http/errors"
+	"github.com/foxfurry/simple-rest/internal/collection/http/validators"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"log"
+)
+
+type CollectionDBRepository struct {
+	database *sql.DB
+}
+
+func NewCollectionRepo(db *sql.DB) CollectionDBRepository {
+	return CollectionDBRepository{database: db}
+}
+
+var _ repository.CollectionRepository = &CollectionDBRepository{}
+
+const (
+	QuerySaveCollection   = `INSERT INTO collections (user_id, name, description) VALUES ($1, $2, $3) RETURNING id`
+	QueryGetCollection    = `SELECT id, name, description FROM collections WHERE id=$1 AND user_id=$2`
+	QueryCountCollections = `SELECT COUNT(*) FROM collections WHERE user_id=$1`
+	QueryGetCollections   = `SELECT id, name, description FROM collections WHERE user_id=$1 ORDER BY lower(name), id LIMIT $2 OFFSET $3`
+	QueryUpdateCollection = `UPDATE collections SET name=$3, description=$4 WHERE id=$1 AND user_id=$2`
+	QueryDeleteCollection = `DELETE FROM collections WHERE id=$1 AND user_id=$2`
+	QueryLockCollection   = `SELECT id FROM collections WHERE id=$1 AND user_id=$2 FOR UPDATE`
+	QueryLockBook         = `SELECT id FROM bookstore WHERE id=$1 AND deleted_at IS NULL FOR SHARE`
+	// QueryGetCollectionBooks returns a single row with NULL book for an existing collection without books, to tell
+	// it apart from a missing collection
+	QueryGetCollectionBooks = `SELECT b.id, b.title, b.author, b.year, b.description, b.isbn FROM collections c
+		LEFT JOIN collection_entries e ON e.collection_id = c.id
+		LEFT JOIN bookstore b ON b.id = e.book_id AND b.deleted_at IS NULL
+		WHERE c.id=$1 AND c.user_id=$2 ORDER BY e.position, e.book_id`
+	// QueryGetEntryIDs returns ids of the books in the collection, leaving out the ones in the trash
+	QueryGetEntryIDs = `SELECT e.book_id FROM collection_entries e JOIN bookstore b ON b.id = e.book_id
+		WHERE e.collection_id=$1 AND b.deleted_at IS NULL`
+	// QueryRenumberEntries numbers entries of the collection from 1 without gaps, keeping their order. Gaps are left
+	// by books moved to the trash, whose entries keep their position to be put back about there once restored
+	QueryRenumberEntries = `UPDATE collection_entries e SET position = n.position
+		FROM (SELECT ce.book_id, ROW_NUMBER() OVER (ORDER BY ce.position, ce.book_id) AS position
+			FROM collection_entries ce JOIN bookstore b ON b.id = ce.book_id
+			WHERE ce.collection_id=$1 AND b.deleted_at IS NULL) n
+		WHERE e.collection_id=$1 AND e.book_id = n.book_id AND e.position <> n.position`
+	QueryShiftEntries     = `UPDATE collection_entries SET position = position + 1 WHERE collection_id=$1 AND position >= $2`
+	QueryAddEntry         = `INSERT INTO collection_entries (collection_id, book_id, position) VALUES ($1, $2, $3)`
+	QueryRemoveEntry      = `DELETE FROM collection_entries WHERE collection_id=$1 AND book_id=$2`
+	QuerySetEntryPosition = `UPDATE collection_entries SET position=$3 WHERE collection_id=$1 AND book_id=$2`
+)
+
+// queryer runs queries either on the database or in a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// scanCollections reads all the rows into a slice of collections. Rows which could not be scanned are skipped
+func scanCollections(rows *sql.Rows) []entity.Collection {
+	collections := []entity.Collection{}
+
+	for rows.Next() {
+		var collection entity.Collection
+
+		if err := rows.Scan(&collection.ID, &collection.Name, &collection.Description); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		collections = append(collections, collection)
+	}
+
+	return collections
+}
+
+// SaveCollection stores the collection as one of the user
+func (r *CollectionDBRepository) SaveCollection(ctx context.Context, userID uint64, collection *entity.Collection) (*entity.Collection, error) {
+	var collectionID uint64
+
+	err := r.database.QueryRowContext(ctx, QuerySaveCollection, userID, collection.Name, collection.Description).Scan(&collectionID)
+	if err != nil {
+		log.Printf("Unable to save collection to db: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	returnCollection := *collection
+	returnCollection.ID = collectionID
+	return &returnCollection, nil
+}
+
+// GetCollection returns the collection of the user. Collections of other users are not found
+func (r *CollectionDBRepository) GetCollection(ctx context.Context, userID uint64, collectionID uint64) (*entity.Collection, error) {
+	if collectionID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+	var collection entity.Collection
+
+	err := r.database.QueryRowContext(ctx, QueryGetCollection, collectionID, userID).Scan(&collection.ID, &collection.Name, &collection.Description)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Collection id#%v not found", collectionID)
+		return nil, errors.NewCollectionsNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	return &collection, nil
+}
+
+// GetCollections returns collections of the user in the limit/offset window, ordered by name, and the total count of
+// collections of the user. Finding no collections is not an error
+func (r *CollectionDBRepository) GetCollections(ctx context.Context, userID uint64, limit int, offset int) ([]entity.Collection, int64, error) {
+	var total int64
+
+	err := r.database.QueryRowContext(ctx, QueryCountCollections, userID).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count collections: %v", err)
+		return nil, 0, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	if total == 0 {
+		return []entity.Collection{}, 0, nil
+	}
+
+	rows, err := r.database.QueryContext(ctx, QueryGetCollections, userID, limit, offset)
+	if err != nil {
+		log.Printf("Unable to get collections: %v", err)
+		return nil, 0, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	return scanCollections(rows), total, nil
+}
+
+// UpdateCollection changes the name and the description of the collection of the user
+func (r *CollectionDBRepository) UpdateCollection(ctx context.Context, userID uint64, collectionID uint64, collection *entity.Collection) (*entity.Collection, error) {
+	if collectionID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+
+	res, err := r.database.ExecContext(ctx, QueryUpdateCollection, collectionID, userID, collection.Name, collection.Description)
+	if err != nil {
+		log.Printf("Unable to update collection: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows collection: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return nil, errors.NewCollectionsNotFound()
+	}
+
+	returnCollection := *collection
+	returnCollection.ID = collectionID
+	return &returnCollection, nil
+}
+
+// DeleteCollection removes the collection of the user along with its entries. The books themselves are kept
+func (r *CollectionDBRepository) DeleteCollection(ctx context.Context, userID uint64, collectionID uint64) (int64, error) {
+	if collectionID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewCollectionInvalidSerial()
+	}
+
+	res, err := r.database.ExecContext(ctx, QueryDeleteCollection, collectionID, userID)
+	if err != nil {
+		log.Printf("Unable to delete collection: %v", err)
+		return 0, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows collection: %v", err)
+		return 0, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewCollectionsNotFound()
+	}
+
+	log.Printf("Deleted rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
+
+// GetCollectionBooks returns books of the collection of the user in its order. Collection without books is not an error
+func (r *CollectionDBRepository) GetCollectionBooks(ctx context.Context, userID uint64, collectionID uint64) ([]bookEntity.Book, error) {
+	if collectionID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+
+	return collectionBooks(ctx, r.database, userID, collectionID)
+}
+
+// collectionBooks reads books of QueryGetCollectionBooks, reporting a missing collection if there are no rows at all
+func collectionBooks(ctx context.Context, q queryer, userID uint64, collectionID uint64) ([]bookEntity.Book, error) {
+	rows, err := q.QueryContext(ctx, QueryGetCollectionBooks, collectionID, userID)
+	if err != nil {
+		log.Printf("Could not get books of collection id#%v: %v", collectionID, err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	found := false
+	books := []bookEntity.Book{}
+
+	for rows.Next() {
+		found = true
+
+		var id, year sql.NullInt64
+		var title, author, description, isbn sql.NullString
+
+		if err = rows.Scan(&id, &title, &author, &year, &description, &isbn); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+		if !id.Valid {
+			continue
+		}
+
+		books = append(books, bookEntity.Book{
+			ID:          uint64(id.Int64),
+			Title:       title.String,
+			Author:      author.String,
+			Year:        int(year.Int64),
+			Description: description.String,
+			ISBN:        isbn.String,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	if !found {
+		log.Printf("Collection id#%v not found", collectionID)
+		return nil, errors.NewCollectionsNotFound()
+	}
+
+	return books, nil
+}
+
+// AddCollectionBook puts the book at the position of the entry, moving the books from there on one position down,
+// and returns books of the collection. Books in the trash cannot be added
+func (r *CollectionDBRepository) AddCollectionBook(ctx context.Context, userID uint64, collectionID uint64, entry entity.Entry) ([]bookEntity.Book, error) {
+	if collectionID < 1 || entry.BookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+
+	if entry.Position < 0 {
+		return nil, errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldPositionInvalid})
+	}
+
+	var books []bookEntity.Book
+
+	err := r.atomically(ctx, func(tx *sql.Tx) error {
+		if err := lockCollection(ctx, tx, userID, collectionID); err != nil {
+			return err
+		}
+
+		var lockedID uint64
+		err := tx.QueryRowContext(ctx, QueryLockBook, entry.BookID).Scan(&lockedID)
+		if err == sql.ErrNoRows {
+			log.Printf("Book id#%v not found", entry.BookID)
+			return errors.NewCollectionBookNotFound()
+		} else if err != nil {
+			log.Printf("Could not execute the query: %v", err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		ids, err := entryIDs(ctx, tx, collectionID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if id == entry.BookID {
+				log.Printf("Book id#%v is already in collection id#%v", entry.BookID, collectionID)
+				return errors.NewCollectionEntryAlreadyExists(entry.BookID)
+			}
+		}
+
+		position := entry.Position
+		if position == 0 || position > len(ids) {
+			position = len(ids) + 1
+		}
+
+		if _, err = tx.ExecContext(ctx, QueryRenumberEntries, collectionID); err != nil {
+			log.Printf("Unable to renumber entries of collection id#%v: %v", collectionID, err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		if _, err = tx.ExecContext(ctx, QueryShiftEntries, collectionID, position); err != nil {
+			log.Printf("Unable to shift entries of collection id#%v: %v", collectionID, err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		if _, err = tx.ExecContext(ctx, QueryAddEntry, collectionID, entry.BookID, position); err != nil {
+			log.Printf("Unable to add book id#%v to collection id#%v: %v", entry.BookID, collectionID, err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		books, err = collectionBooks(ctx, tx, userID, collectionID)
+		return err
+	})
+
+	return books, err
+}
+
+// RemoveCollectionBook takes the book out of the collection and returns the books left in it
+func (r *CollectionDBRepository) RemoveCollectionBook(ctx context.Context, userID uint64, collectionID uint64, bookID uint64) ([]bookEntity.Book, error) {
+	if collectionID < 1 || bookID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+
+	var books []bookEntity.Book
+
+	err := r.atomically(ctx, func(tx *sql.Tx) error {
+		if err := lockCollection(ctx, tx, userID, collectionID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, QueryRemoveEntry, collectionID, bookID)
+		if err != nil {
+			log.Printf("Unable to remove book id#%v from collection id#%v: %v", bookID, collectionID, err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			log.Printf("Unable to get affected rows collection: %v", err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		if rowsAffected == 0 {
+			return errors.NewCollectionEntryNotFound(bookID)
+		}
+
+		if _, err = tx.ExecContext(ctx, QueryRenumberEntries, collectionID); err != nil {
+			log.Printf("Unable to renumber entries of collection id#%v: %v", collectionID, err)
+			return errors.NewCollectionCouldNotQuery(err.Error())
+		}
+
+		books, err = collectionBooks(ctx, tx, userID, collectionID)
+		return err
+	})
+
+	return books, err
+}
+
+// ReorderCollectionBooks puts books of the collection in the order of bookIDs and returns them. Book ids should be
+// exactly the books of the collection, so a book added or removed meanwhile makes the order invalid
+func (r *CollectionDBRepository) ReorderCollectionBooks(ctx context.Context, userID uint64, collectionID uint64, bookIDs []uint64) ([]bookEntity.Book, error) {
+	if collectionID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewCollectionInvalidSerial()
+	}
+
+	order := make(map[uint64]int, len(bookIDs))
+	for idx, id := range bookIDs {
+		if _, repeated := order[id]; repeated {
+			return nil, errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid})
+		}
+		order[id] = idx + 1
+	}
+
+	var books []bookEntity.Book
+
+	err := r.atomically(ctx, func(tx *sql.Tx) error {
+		if err := lockCollection(ctx, tx, userID, collectionID); err != nil {
+			return err
+		}
+
+		ids, err := entryIDs(ctx, tx, collectionID)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) != len(order) {
+			return errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid})
+		}
+		for _, id := range ids {
+			if _, ok := order[id]; !ok {
+				return errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid})
+			}
+		}
+
+		for _, id := range bookIDs {
+			if _, err = tx.ExecContext(ctx, QuerySetEntryPosition, collectionID, id, order[id]); err != nil {
+				log.Printf("Unable to move book id#%v of collection id#%v: %v", id, collectionID, err)
+				return errors.NewCollectionCouldNotQuery(err.Error())
+			}
+		}
+
+		books, err = collectionBooks(ctx, tx, userID, collectionID)
+		return err
+	})
+
+	return books, err
+}
+
+// atomically runs fn in a transaction, which is committed if fn succeeds and rolled back otherwise
+func (r *CollectionDBRepository) atomically(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.database.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Could not begin the transaction: %v", err)
+		return errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("Could not roll back the transaction: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Could not commit the transaction: %v", err)
+		return errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	return nil
+}
+
+// lockCollection locks the collection of the user until the end of the transaction, so its entries are changed one at
+// a time. Entries are reached only through a collection locked this way, which keeps them to the owner
+func lockCollection(ctx context.Context, tx *sql.Tx, userID uint64, collectionID uint64) error {
+	var lockedID uint64
+
+	err := tx.QueryRowContext(ctx, QueryLockCollection, collectionID, userID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		log.Printf("Collection id#%v not found", collectionID)
+		return errors.NewCollectionsNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	return nil
+}
+
+// entryIDs returns ids of the books in the collection which are not in the trash, in no particular order
+func entryIDs(ctx context.Context, tx *sql.Tx, collectionID uint64) ([]uint64, error) {
+	rows, err := tx.QueryContext(ctx, QueryGetEntryIDs, collectionID)
+	if err != nil {
+		log.Printf("Could not get entries of collection id#%v: %v", collectionID, err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err = rows.Scan(&id); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			return nil, errors.NewCollectionCouldNotQuery(err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Could not read the rows: %v", err)
+		return nil, errors.NewCollectionCouldNotQuery(err.Error())
+	}
+
+	return ids, nil
+}
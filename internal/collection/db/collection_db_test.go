@@ -0,0 +1,367 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/http/errors"
+	"github.com/foxfurry/simple-rest/internal/collection/http/validators"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"regexp"
+	"testing"
+)
+
+var (
+	collectionColumns = []string{"id", "name", "description"}
+	bookColumns       = []string{"id", "title", "author", "year", "description", "isbn"}
+)
+
+const ownerID uint64 = 7 // User the collections belong to
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestCollectionDBRepository_SaveCollection(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(QuerySaveCollection)).WithArgs(ownerID, "To read", "Next summer").
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+	res, err := repo.SaveCollection(context.Background(), ownerID, &entity.Collection{Name: "To read", Description: "Next summer"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &entity.Collection{ID: 1, Name: "To read", Description: "Next summer"}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectionDBRepository_GetCollection(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollection)).WithArgs(1, ownerID).
+			WillReturnRows(mock.NewRows(collectionColumns).AddRow(1, "To read", "Next summer"))
+
+		res, err := repo.GetCollection(context.Background(), ownerID, 1)
+
+		assert.Nil(t, err)
+		assert.Equal(t, &entity.Collection{ID: 1, Name: "To read", Description: "Next summer"}, res)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Unsuccessful: Collection of another user", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollection)).WithArgs(1, ownerID+1).WillReturnRows(mock.NewRows(collectionColumns))
+
+		res, err := repo.GetCollection(context.Background(), ownerID+1, 1)
+
+		assert.Equal(t, errors.NewCollectionsNotFound(), err)
+		assert.Nil(t, res)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCollectionDBRepository_GetCollections(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(QueryCountCollections)).WithArgs(ownerID).WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollections)).WithArgs(ownerID, 2, 0).
+		WillReturnRows(mock.NewRows(collectionColumns).AddRow(2, "2024 book club", "").AddRow(1, "To read", "Next summer"))
+
+	collections, total, err := repo.GetCollections(context.Background(), ownerID, 2, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []entity.Collection{{ID: 2, Name: "2024 book club"}, {ID: 1, Name: "To read", Description: "Next summer"}}, collections)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectionDBRepository_GetCollectionBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	getCollectionBooksMocks := []struct {
+		testName       string
+		id             uint64
+		expectedOutput []bookEntity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			id:       1,
+			expectedOutput: []bookEntity.Book{
+				{ID: 3, Title: "The Hobbit", Author: "J.R.R. Tolkien", Year: 1937},
+				{ID: 2, Title: "Mort", Author: "Terry Pratchett", Year: 1987},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(bookColumns).
+					AddRow(3, "The Hobbit", "J.R.R. Tolkien", 1937, "", "").
+					AddRow(2, "Mort", "Terry Pratchett", 1987, "", "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Collection without books",
+			id:             1,
+			expectedOutput: []bookEntity.Book{},
+			mockFunc: func() {
+				rows := mock.NewRows(bookColumns).AddRow(nil, nil, nil, nil, nil, nil)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Collection not found",
+			id:            4,
+			expectedError: errors.NewCollectionsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(4, ownerID).WillReturnRows(mock.NewRows(bookColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			expectedError: errors.NewCollectionInvalidSerial(),
+			mockFunc:      func() {},
+		},
+	}
+
+	for _, tc := range getCollectionBooksMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.GetCollectionBooks(context.Background(), ownerID, tc.id)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionDBRepository_AddCollectionBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	addCollectionBookMocks := []struct {
+		testName       string
+		input          entity.Entry
+		expectedOutput []bookEntity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful: Put first",
+			input:          entity.Entry{BookID: 3, Position: 1},
+			expectedOutput: []bookEntity.Book{{ID: 3, Title: "The Hobbit"}, {ID: 2, Title: "Mort"}},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(3).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2))
+				mock.ExpectExec(regexp.QuoteMeta(QueryRenumberEntries)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(QueryShiftEntries)).WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(QueryAddEntry)).WithArgs(1, 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				rows := mock.NewRows(bookColumns).AddRow(3, "The Hobbit", "", 0, "", "").AddRow(2, "Mort", "", 0, "", "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:       "Test Successful: Position past the end appends",
+			input:          entity.Entry{BookID: 3, Position: 10},
+			expectedOutput: []bookEntity.Book{{ID: 2, Title: "Mort"}, {ID: 3, Title: "The Hobbit"}},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(3).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2))
+				mock.ExpectExec(regexp.QuoteMeta(QueryRenumberEntries)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(QueryShiftEntries)).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(QueryAddEntry)).WithArgs(1, 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				rows := mock.NewRows(bookColumns).AddRow(2, "Mort", "", 0, "", "").AddRow(3, "The Hobbit", "", 0, "", "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Already in the collection",
+			input:         entity.Entry{BookID: 2},
+			expectedError: errors.NewCollectionEntryAlreadyExists(2),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(2).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Book not found",
+			input:         entity.Entry{BookID: 5},
+			expectedError: errors.NewCollectionBookNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockBook)).WithArgs(5).WillReturnRows(mock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Negative position",
+			input:         entity.Entry{BookID: 3, Position: -1},
+			expectedError: errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldPositionInvalid}),
+			mockFunc:      func() {},
+		},
+	}
+
+	for _, tc := range addCollectionBookMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.AddCollectionBook(context.Background(), ownerID, 1, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionDBRepository_RemoveCollectionBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(QueryRemoveEntry)).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(QueryRenumberEntries)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).
+			WillReturnRows(mock.NewRows(bookColumns).AddRow(2, "Mort", "", 0, "", ""))
+		mock.ExpectCommit()
+
+		books, err := repo.RemoveCollectionBook(context.Background(), ownerID, 1, 3)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []bookEntity.Book{{ID: 2, Title: "Mort"}}, books)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Unsuccessful: Not in the collection", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(QueryRemoveEntry)).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		books, err := repo.RemoveCollectionBook(context.Background(), ownerID, 1, 3)
+
+		assert.Equal(t, errors.NewCollectionEntryNotFound(3), err)
+		assert.Nil(t, books)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCollectionDBRepository_ReorderCollectionBooks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewCollectionRepo(db)
+
+	reorderCollectionBooksMocks := []struct {
+		testName       string
+		input          []uint64
+		expectedOutput []bookEntity.Book
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          []uint64{3, 2},
+			expectedOutput: []bookEntity.Book{{ID: 3, Title: "The Hobbit"}, {ID: 2, Title: "Mort"}},
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2).AddRow(3))
+				mock.ExpectExec(regexp.QuoteMeta(QuerySetEntryPosition)).WithArgs(1, 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(QuerySetEntryPosition)).WithArgs(1, 2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				rows := mock.NewRows(bookColumns).AddRow(3, "The Hobbit", "", 0, "", "").AddRow(2, "Mort", "", 0, "", "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetCollectionBooks)).WithArgs(1, ownerID).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Book missing from the order",
+			input:         []uint64{3},
+			expectedError: errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid}),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2).AddRow(3))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Book not in the collection",
+			input:         []uint64{3, 4},
+			expectedError: errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid}),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetEntryIDs)).WithArgs(1).WillReturnRows(mock.NewRows([]string{"book_id"}).AddRow(2).AddRow(3))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Repeated book",
+			input:         []uint64{3, 3},
+			expectedError: errors.NewCollectionValidatorError([]ct.FieldError{validators.FieldOrderInvalid}),
+			mockFunc:      func() {},
+		},
+		{
+			testName:      "Test Unsuccessful: Collection not found",
+			input:         []uint64{3},
+			expectedError: errors.NewCollectionsNotFound(),
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(mock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range reorderCollectionBooksMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.ReorderCollectionBooks(context.Background(), ownerID, 1, tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package entity
+
+// Collection is a named list of books, like "to read" or "2024 book club". Books of a collection are its entries
+type Collection struct {
+	ID          uint64 `json:"id,omitempty"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// Entry puts the book into a collection at the 1-based position. Zero position, as well as one past the last book,
+// appends the book to the end
+type Entry struct {
+	BookID   uint64 `json:"book_id" binding:"required"`
+	Position int    `json:"position,omitempty"`
+}
+
+// Equal returns true if all fields in receiver are same as in parameter
+func (lhs Collection) Equal(rhs Collection) bool {
+	return rhs == lhs
+}
+
+// EqualNoID works similar to Equal, except it ignores ID
+func (lhs Collection) EqualNoID(rhs Collection) bool {
+	rhs.ID = lhs.ID
+	return rhs == lhs
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/collection/http/validators"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func init() {
+	validators.RegisterCollectionValidators()
+}
+
+func TestCollection_EqualNoID(t *testing.T) {
+	base := Collection{ID: 1, Name: "1", Description: "1"}
+
+	assert.True(t, base.Equal(Collection{ID: 1, Name: "1", Description: "1"}))
+	assert.True(t, !base.Equal(Collection{ID: 2, Name: "1", Description: "1"}))
+	assert.True(t, base.EqualNoID(Collection{ID: 2, Name: "1", Description: "1"}))
+	assert.True(t, !base.EqualNoID(Collection{ID: 1, Name: "1", Description: "2"}))
+}
+
+func TestCollection_IsValid(t *testing.T) {
+	testCasesValid := []Collection{
+		{Name: "To read"},
+		{Name: "2024 book club", Description: "Every first Monday"},
+	}
+	testCasesInvalid := []Collection{
+		{},
+		{Description: "Every first Monday"},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Collection expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Collection expected to be invalid, but found valid: %v", tc)
+	}
+}
+
+func TestEntry_IsValid(t *testing.T) {
+	testCasesValid := []Entry{
+		{BookID: 1},
+		{BookID: 1, Position: 3},
+	}
+	testCasesInvalid := []Entry{
+		{},
+		{Position: 1},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Entry expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Entry expected to be invalid, but found valid: %v", tc)
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/entity"
+)
+
+// CollectionRepository stores collections and the books they reference, in the order chosen by the user. Collections
+// belong to the user who made them, every method takes the id of the user and does not reach collections of others.
+// Books in the trash are left out of their collections until restored
+type CollectionRepository interface {
+	SaveCollection(context.Context, uint64, *entity.Collection) (*entity.Collection, error)
+	GetCollection(context.Context, uint64, uint64) (*entity.Collection, error)
+	GetCollections(context.Context, uint64, int, int) ([]entity.Collection, int64, error) // Limit/offset window of collections and their total count
+	UpdateCollection(context.Context, uint64, uint64, *entity.Collection) (*entity.Collection, error)
+	DeleteCollection(context.Context, uint64, uint64) (int64, error)                             // Books of the collection are kept
+	GetCollectionBooks(context.Context, uint64, uint64) ([]bookEntity.Book, error)               // Books in the order of the collection
+	AddCollectionBook(context.Context, uint64, uint64, entity.Entry) ([]bookEntity.Book, error)  // Fails if the book is already in the collection
+	RemoveCollectionBook(context.Context, uint64, uint64, uint64) ([]bookEntity.Book, error)     // Books after the removed one move up
+	ReorderCollectionBooks(context.Context, uint64, uint64, []uint64) ([]bookEntity.Book, error) // Book ids should list every book of the collection once
+}
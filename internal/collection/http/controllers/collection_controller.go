@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"database/sql"
+	collectionDB "github.com/foxfurry/simple-rest/internal/collection/db"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/http/errors"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type CollectionService struct {
+	dbRepo collectionDB.CollectionDBRepository
+}
+
+func NewCollectionService(db *sql.DB) CollectionService {
+	return CollectionService{
+		dbRepo: collectionDB.NewCollectionRepo(db),
+	}
+}
+
+// bindBody reads the request body into obj and responds with an error if the body is missing, malformed or invalid
+func bindBody(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	if err == io.EOF {
+		errors.HandleCollectionError(c, errors.NewCollectionEmptyBody())
+	} else if _, ok := err.(validator.ValidationErrors); ok {
+		errors.HandleCollectionError(c, errors.NewCollectionValidatorError(common_translators.Translate(err)))
+	} else {
+		errors.HandleCollectionError(c, errors.NewCollectionBadBody(err.Error()))
+	}
+	return false
+}
+
+// paramID parses the path parameter named key and responds with an error if it is not a number
+func paramID(c *gin.Context, key string) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param(key), 10, 64)
+	if err != nil {
+		errors.HandleCollectionError(c, errors.NewCollectionInvalidSerial())
+		return 0, false
+	}
+	return id, true
+}
+
+// owner returns id of the user the request is signed in as. Collection routes are reached by signed in users only, so
+// every collection is looked up among the ones of the user
+func owner(c *gin.Context) uint64 {
+	user, _ := common_auth.UserFrom(c.Request.Context())
+	return user.ID
+}
+
+func (s *CollectionService) SaveCollection(c *gin.Context) {
+	var collection entity.Collection
+
+	if !bindBody(c, &collection) {
+		return
+	}
+
+	saveCollection, err := s.dbRepo.SaveCollection(c.Request.Context(), owner(c), &collection)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, saveCollection, nil)
+}
+
+func (s *CollectionService) GetCollection(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	getCollection, err := s.dbRepo.GetCollection(c.Request.Context(), owner(c), id)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, getCollection, nil)
+}
+
+// GetCollections responds with the requested page of collections ordered by name
+func (s *CollectionService) GetCollections(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleCollectionError(c, errors.NewCollectionValidatorError(fieldErrors))
+		return
+	}
+
+	collections, total, err := s.dbRepo.GetCollections(c.Request.Context(), owner(c), page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, collections, common_pagination.NewPageMeta(c, page, total))
+}
+
+func (s *CollectionService) UpdateCollection(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var collection entity.Collection
+
+	if !bindBody(c, &collection) {
+		return
+	}
+
+	updatedCollection, err := s.dbRepo.UpdateCollection(c.Request.Context(), owner(c), id, &collection)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, updatedCollection, nil)
+}
+
+func (s *CollectionService) DeleteCollection(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	_, err := s.dbRepo.DeleteCollection(c.Request.Context(), owner(c), id)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, nil, nil)
+}
+
+// GetCollectionBooks responds with books of the collection in its order
+func (s *CollectionService) GetCollectionBooks(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	books, err := s.dbRepo.GetCollectionBooks(c.Request.Context(), owner(c), id)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, books, nil)
+}
+
+// AddCollectionBook puts the book of the entry into the collection and responds with books of the collection
+func (s *CollectionService) AddCollectionBook(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var entry entity.Entry
+
+	if !bindBody(c, &entry) {
+		return
+	}
+
+	books, err := s.dbRepo.AddCollectionBook(c.Request.Context(), owner(c), id, entry)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, books, nil)
+}
+
+// RemoveCollectionBook takes the book out of the collection and responds with the books left in it
+func (s *CollectionService) RemoveCollectionBook(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	bookID, ok := paramID(c, "book_id")
+	if !ok {
+		return
+	}
+
+	books, err := s.dbRepo.RemoveCollectionBook(c.Request.Context(), owner(c), id, bookID)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, books, nil)
+}
+
+// ReorderCollectionBooks orders books of the collection by a JSON array of their ids and responds with them
+func (s *CollectionService) ReorderCollectionBooks(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var bookIDs []uint64
+
+	if !bindBody(c, &bookIDs) {
+		return
+	}
+
+	books, err := s.dbRepo.ReorderCollectionBooks(c.Request.Context(), owner(c), id, bookIDs)
+	if err != nil {
+		errors.HandleCollectionError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, books, nil)
+}
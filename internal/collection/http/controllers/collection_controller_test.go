@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	bookEntity "github.com/foxfurry/simple-rest/internal/book/domain/entity"
+	collectiondb "github.com/foxfurry/simple-rest/internal/collection/db"
+	"github.com/foxfurry/simple-rest/internal/collection/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/collection/http/errors"
+	"github.com/foxfurry/simple-rest/internal/collection/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type collectionResponse struct {
+	Data  *entity.Collection `json:"data"`
+	Error expectedErrors     `json:"error"`
+}
+
+type booksResponse struct {
+	Data  []bookEntity.Book `json:"data"`
+	Error expectedErrors    `json:"error"`
+}
+
+var bookColumns = []string{"id", "title", "author", "year", "description", "isbn"}
+
+const ownerID uint64 = 7 // User the collections belong to
+
+func init() {
+	validators.RegisterCollectionValidators()
+}
+
+// signedIn runs handler as the user, like the guard of collection routes does
+func signedIn(userID uint64, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(common_auth.WithUser(c.Request.Context(), common_auth.User{ID: userID}))
+		handler(c)
+	}
+}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestCollectionService_SaveCollection(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewCollectionService(db)
+
+	saveCollectionServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		requestBody    interface{}
+		expectedStatus int
+		expectedBody   *entity.Collection
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QuerySaveCollection)).WithArgs(ownerID, "To read", "").WillReturnRows(rows)
+			},
+			requestBody:    entity.Collection{Name: "To read"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Collection{ID: 1, Name: "To read"},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty name",
+			requestBody:    entity.Collection{Description: "Next summer"},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldNameEmpty},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Name is not a string",
+			requestBody:    `{"name": 1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewCollectionBadBody("json: cannot unmarshal number into Go struct field Collection.name of type string").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty request body",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewCollectionEmptyBody().Error(),
+			},
+		},
+	}
+
+	for _, tc := range saveCollectionServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(signedIn(ownerID, service.SaveCollection), http.MethodPost, "/collection/", nil, tc.requestBody)
+
+			var response collectionResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, response.Data)
+			assert.Equal(t, tc.expectedError, response.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionService_AddCollectionBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewCollectionService(db)
+
+	addCollectionBookServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		userID         uint64
+		param          string
+		requestBody    interface{}
+		expectedStatus int
+		expectedBody   []bookEntity.Book
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryLockBook)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryGetEntryIDs)).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"book_id"}))
+				mock.ExpectExec(regexp.QuoteMeta(collectiondb.QueryRenumberEntries)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(collectiondb.QueryShiftEntries)).WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(collectiondb.QueryAddEntry)).WithArgs(1, 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryGetCollectionBooks)).WithArgs(1, ownerID).
+					WillReturnRows(sqlmock.NewRows(bookColumns).AddRow(3, "The Hobbit", "J.R.R. Tolkien", 1937, "", ""))
+				mock.ExpectCommit()
+			},
+			userID:         ownerID,
+			param:          "1",
+			requestBody:    entity.Entry{BookID: 3},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []bookEntity.Book{{ID: 3, Title: "The Hobbit", Author: "J.R.R. Tolkien", Year: 1937}},
+		},
+		{
+			testName: "Test Unsuccessful: Collection of another user",
+			mockFunc: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryLockCollection)).WithArgs(1, ownerID+1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			userID:         ownerID + 1,
+			param:          "1",
+			requestBody:    entity.Entry{BookID: 3},
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewCollectionsNotFound().Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty book id",
+			userID:         ownerID,
+			param:          "1",
+			requestBody:    entity.Entry{Position: 1},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldBookIDEmpty},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			userID:         ownerID,
+			param:          "abc",
+			requestBody:    entity.Entry{BookID: 3},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewCollectionInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range addCollectionBookServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(signedIn(tc.userID, service.AddCollectionBook), http.MethodPost, "/collection/"+tc.param+"/books", gin.Params{{Key: "id", Value: tc.param}}, tc.requestBody)
+
+			var response booksResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, response.Data)
+			assert.Equal(t, tc.expectedError, response.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionService_RemoveCollectionBook(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewCollectionService(db)
+
+	t.Run("Test Unsuccessful: Not in the collection", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryLockCollection)).WithArgs(1, ownerID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(collectiondb.QueryRemoveEntry)).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		w := tests.Serve(signedIn(ownerID, service.RemoveCollectionBook), http.MethodDelete, "/collection/1/books/3",
+			gin.Params{{Key: "id", Value: "1"}, {Key: "book_id", Value: "3"}}, nil)
+
+		var response booksResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, errors.NewCollectionEntryNotFound(3).Error(), response.Error.Msg)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,135 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type collectionsNotFound struct {
+	common_errors.CommonError
+}
+
+type collectionBookNotFound struct {
+	common_errors.CommonError
+}
+
+type collectionEntryNotFound struct {
+	common_errors.CommonError
+}
+
+type collectionEntryAlreadyExists struct {
+	common_errors.CommonError
+}
+
+type collectionCouldNotQuery struct {
+	common_errors.CommonError
+}
+
+type collectionInvalidSerial struct {
+	common_errors.CommonError
+}
+
+type collectionUnexpectedError struct {
+	common_errors.CommonError
+}
+
+type collectionEmptyBody struct {
+	common_errors.CommonError
+}
+
+type collectionBadBody struct {
+	common_errors.CommonError
+}
+
+type collectionValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewCollectionsNotFound() collectionsNotFound {
+	return collectionsNotFound{
+		common_errors.CommonError{Msg: "Collection(s) not found in db"},
+	}
+}
+
+func NewCollectionBookNotFound() collectionBookNotFound {
+	return collectionBookNotFound{
+		common_errors.CommonError{Msg: "Book not found in db"},
+	}
+}
+
+func NewCollectionEntryNotFound(bookID uint64) collectionEntryNotFound {
+	return collectionEntryNotFound{
+		common_errors.CommonError{Msg: fmt.Sprintf("Book id#%v is not in the collection", bookID)},
+	}
+}
+
+func NewCollectionEntryAlreadyExists(bookID uint64) collectionEntryAlreadyExists {
+	return collectionEntryAlreadyExists{
+		common_errors.CommonError{Msg: fmt.Sprintf("Book id#%v is already in the collection", bookID)},
+	}
+}
+
+func NewCollectionCouldNotQuery(msg string) collectionCouldNotQuery {
+	return collectionCouldNotQuery{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not execute query: %v", msg)},
+	}
+}
+
+func NewCollectionInvalidSerial() collectionInvalidSerial {
+	return collectionInvalidSerial{
+		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
+	}
+}
+
+func NewCollectionUnexpectedError(msg string) collectionUnexpectedError {
+	return collectionUnexpectedError{
+		common_errors.CommonError{Msg: fmt.Sprintf("Unexpected error: %v", msg)},
+	}
+}
+
+func NewCollectionEmptyBody() collectionEmptyBody {
+	return collectionEmptyBody{
+		common_errors.CommonError{Msg: "Expected body, found EOF"},
+	}
+}
+
+func NewCollectionBadBody(msg string) collectionBadBody {
+	return collectionBadBody{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not read the body: %v", msg)},
+	}
+}
+
+func NewCollectionValidatorError(fields []validator.FieldError) collectionValidatorError {
+	return collectionValidatorError{Fields: fields}
+}
+
+func (a collectionValidatorError) Error() string {
+	var res = ""
+	for _, f := range a.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+func HandleCollectionError(c *gin.Context, err error) {
+	switch err.(type) {
+	case collectionsNotFound, collectionBookNotFound, collectionEntryNotFound:
+		common_errors.RespondNotFound(c, err)
+	case collectionEntryAlreadyExists:
+		common_errors.RespondAlreadyExists(c, err)
+	case collectionValidatorError, collectionInvalidSerial, collectionEmptyBody, collectionBadBody:
+		common_errors.RespondBadRequest(c, err)
+	case collectionUnexpectedError, collectionCouldNotQuery:
+		common_errors.RespondInternalError(c, err)
+	default:
+		common_errors.RespondInternalError(c, err)
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/collection/http/controllers"
+	"github.com/foxfurry/simple-rest/internal/collection/http/validators"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/gin-gonic/gin"
+)
+
+// collectionPolicy lets every signed in user keep collections, readers included. Collections belong to the user who made
+// them, so users reach only their own ones
+var collectionPolicy = common_auth.Policy{
+	"GET /collection/":                      common_auth.PermSignedIn,
+	"GET /collection/:id":                   common_auth.PermSignedIn,
+	"GET /collection/:id/books":             common_auth.PermSignedIn,
+	"POST /collection/":                     common_auth.PermSignedIn,
+	"PUT /collection/:id":                   common_auth.PermSignedIn,
+	"DELETE /collection/:id":                common_auth.PermSignedIn,
+	"POST /collection/:id/books":            common_auth.PermSignedIn,
+	"PUT /collection/:id/books":             common_auth.PermSignedIn,
+	"DELETE /collection/:id/books/:book_id": common_auth.PermSignedIn,
+}
+
+func RegisterCollectionRoutes(router *gin.Engine, db *sql.DB, guard *common_auth.Guard) {
+	collectionRepo := controllers.NewCollectionService(db)
+
+	collection := router.Group("/collection", guard.Enforce(collectionPolicy))
+	{
+		collection.GET("/:id", collectionRepo.GetCollection)
+
+		collection.GET("/", collectionRepo.GetCollections)
+
+		collection.POST("/", collectionRepo.SaveCollection)
+
+		collection.PUT("/:id", collectionRepo.UpdateCollection)
+
+		collection.DELETE("/:id", collectionRepo.DeleteCollection)
+
+		collection.GET("/:id/books", collectionRepo.GetCollectionBooks)
+
+		collection.POST("/:id/books", collectionRepo.AddCollectionBook)
+
+		collection.PUT("/:id/books", collectionRepo.ReorderCollectionBooks)
+
+		collection.DELETE("/:id/books/:book_id", collectionRepo.RemoveCollectionBook)
+	}
+
+	validators.RegisterCollectionValidators()
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"bytes"
+	"github.com/DATA-DOG/go-sqlmock"
+	collectiondb "github.com/foxfurry/simple-rest/internal/collection/db"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRegisterCollectionRoutes_Policy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	signer := common_auth.NewSigner([]byte("secret"), time.Minute, time.Hour)
+	tokens, err := signer.Issue(common_auth.User{ID: 7, Username: "reader", Role: common_auth.RoleReader})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	RegisterCollectionRoutes(router, db, common_auth.NewGuard(signer, nil))
+
+	request := func(method string, url string, body string, token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		if token != "" {
+			req.Header.Set(common_auth.HeaderAuthorization, "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QuerySaveCollection)).WithArgs(7, "To read", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(collectiondb.QueryGetCollection)).WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description"}).AddRow(1, "To read", ""))
+
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/collection/1", "", ""), "Collections should not be read anonymously")
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "/collection/", `{"name": "To read"}`, ""))
+	assert.Equal(t, http.StatusOK, request("POST", "/collection/", `{"name": "To read"}`, tokens.AccessToken), "Readers should keep collections")
+	assert.Equal(t, http.StatusOK, request("GET", "/collection/1", "", tokens.AccessToken))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
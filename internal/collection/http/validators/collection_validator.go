@@ -0,0 +1,55 @@
+package validators
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"log"
+)
+
+const (
+	requiredTag   = "required"
+	emptyFieldMsg = "cannot be empty"
+)
+
+var (
+	FieldNameEmpty = common_translators.FieldError{
+		Field: "Name",
+		Msg:   "Name " + emptyFieldMsg,
+	}
+	FieldBookIDEmpty = common_translators.FieldError{
+		Field: "BookID",
+		Msg:   "BookID " + emptyFieldMsg,
+	}
+	FieldPositionInvalid = common_translators.FieldError{
+		Field: "Position",
+		Msg:   "Position should be a non-negative number",
+	}
+	FieldOrderInvalid = common_translators.FieldError{
+		Field: "body",
+		Msg:   "Book ids should list every book of the collection exactly once",
+	}
+)
+
+var requiredMessage validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(requiredTag, "{0} "+emptyFieldMsg, true)
+}
+
+// translateTag returns a translation function which renders the message registered for tag
+func translateTag(tag string) validator.TranslationFunc {
+	return func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	}
+}
+
+func RegisterCollectionValidators() {
+	errTranslator := common_translators.GetTranslator()
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTranslation(requiredTag, errTranslator, requiredMessage, translateTag(requiredTag))
+	} else {
+		log.Panicf("Could not register common_translators: %v", ok)
+	}
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Conn runs queries. It is implemented by both *sql.DB and *sql.Tx, so a repository can run the same queries in and
+// out of a transaction
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	_ Conn = &sql.DB{}
+	_ Conn = &sql.Tx{}
+)
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/database/migrations"
 	_ "github.com/lib/pq"
 	"log"
 	"time"
@@ -39,24 +40,8 @@ func createDatabase(db *sql.DB, dbname string) {
 	}
 }
 
-// Creates a bookstore table(id, title, author, year, description) for a given database instance
-func createBookstore(db *sql.DB) {
-	query := `CREATE TABLE IF NOT EXISTS bookstore (		
-					id SERIAL PRIMARY KEY,
-					title TEXT NOT NULL,
-					author TEXT NOT NULL,
-					year INT NOT NULL,
-					description TEXT
-					);` // Should move it to separate sql file?
-
-	_, err := db.Query(query)
-	if err != nil {
-		log.Panicf("Could not create tables: %v", err)
-	}
-}
-
-// CreateDBPool returns database connection pool with specified parameters. Function will validate database and tables
-// before returning the instance
+// CreateDBPool returns database connection pool with specified parameters. Function will create the database if needed
+// and apply pending migrations before returning the instance
 func CreateDBPool(host string, port int, user string, pass string, dbname string, dbMaxIdleConns int, dbMaxOpenConns int, dbMaxIdleTime time.Duration) *sql.DB {
 	log.Printf("DB configs:\nHost: %v\nPort: %v\nUser: %v\ndbName: %v\nMax idle conns: %v\nMax open conns: %v\nMax idle time: %v",
 		host, port, user, dbname, dbMaxIdleConns, dbMaxOpenConns, dbMaxIdleTime)
@@ -78,12 +63,14 @@ func CreateDBPool(host string, port int, user string, pass string, dbname string
 		log.Printf("Database %v already exists, skipping creation", dbname)
 	}
 
-	db, err = sql.Open("postgres", initTable) // Connection for table creation
+	db, err = sql.Open("postgres", initTable) // Connection for migrations
 	if err != nil {
 		log.Panicf("Could not connect to db:%v: %v", dbname, err)
 	}
 
-	createBookstore(db)
+	if err = Migrate(db, migrations.Postgres); err != nil {
+		log.Panicf("Could not migrate db:%v: %v", dbname, err)
+	}
 
 	db.SetMaxIdleConns(dbMaxIdleConns)
 	db.SetMaxOpenConns(dbMaxOpenConns)
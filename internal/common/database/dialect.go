@@ -0,0 +1,42 @@
+package database
+
+import (
+	"database/sql"
+	"github.com/mattn/go-sqlite3"
+	"regexp"
+)
+
+// Dialect is the flavour of SQL spoken by a database. Queries are written for postgres and rebound for other dialects
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+var postgresPlaceholder = regexp.MustCompile(`\$(\d+)`)
+
+// DialectOf returns dialect of the driver behind db. Drivers other than SQLite, e.g. sqlmock in tests, are assumed
+// to speak postgres
+func DialectOf(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*sqlite3.SQLiteDriver); ok {
+		return SQLite
+	}
+	return Postgres
+}
+
+// Rebind rewrites postgres $N placeholders of query into placeholders of the dialect
+func (d Dialect) Rebind(query string) string {
+	if d == SQLite {
+		return postgresPlaceholder.ReplaceAllString(query, "?$1")
+	}
+	return query
+}
+
+// ContainsCondition returns case insensitive condition which matches column against ContainsPattern at placeholder
+func (d Dialect) ContainsCondition(column string, placeholder string) string {
+	if d == SQLite {
+		return column + " LIKE " + placeholder + ` ESCAPE '\'` // SQLite LIKE ignores case, but has no default escape
+	}
+	return column + " ILIKE " + placeholder
+}
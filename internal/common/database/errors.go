@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// uniqueViolation is the postgres error code of an insert or update breaking a unique constraint
+const uniqueViolation = "23505"
+
+// IsUniqueViolation returns true if err tells that a query would break a unique constraint, in any dialect
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == uniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	return false
+}
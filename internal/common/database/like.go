@@ -0,0 +1,11 @@
+package database
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern returns LIKE pattern which matches any text containing s. Wildcards inside s are escaped,
+// so they are matched literally
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationLockKey identifies postgres advisory lock held while migrations are applied, so concurrently started
+// instances apply them one at a time. Any number works as long as every instance uses the same one. SQLite has no
+// such locks, there migrations rely on write lock of immediate transactions instead
+const migrationLockKey = 7130913
+
+const (
+	queryLockMigrations   = `SELECT pg_advisory_lock($1)`
+	queryUnlockMigrations = `SELECT pg_advisory_unlock($1)`
+	queryCreateMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+					version BIGINT PRIMARY KEY,
+					name TEXT NOT NULL,
+					applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+					)`
+	queryGetMigrations   = `SELECT version FROM schema_migrations`
+	queryIsMigrated      = `SELECT COUNT(*) FROM schema_migrations WHERE version=$1`
+	querySaveMigration   = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	queryDeleteMigration = `DELETE FROM schema_migrations WHERE version=$1`
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single numbered schema change. Down reverts everything Up does
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads migrations from NNNN_name.up.sql and NNNN_name.down.sql files of fsys root, ordered by version.
+// Files not matching the pattern are ignored
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		parts := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || parts == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %v: %v", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		} else if migration.Name != parts[2] {
+			return nil, fmt.Errorf("migration %v is named both %v and %v", version, migration.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %v_%v has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrate applies every migration of fsys which is not yet recorded in schema_migrations. Each migration runs in its
+// own transaction together with its record, so a failed one leaves the schema at the previous version
+func Migrate(db *sql.DB, fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(conn *sql.Conn, applied map[int64]bool) error {
+		for _, migration := range migrations {
+			if applied[migration.Version] {
+				continue
+			}
+
+			log.Printf("Applying migration %v_%v", migration.Version, migration.Name)
+
+			err := migrateInTransaction(conn, DialectOf(db), migration, true)
+			if err != nil {
+				return fmt.Errorf("could not apply migration %v_%v: %v", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback reverts up to steps most recent applied migrations of fsys, newest first
+func Rollback(db *sql.DB, fsys fs.FS, steps int) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(conn *sql.Conn, applied map[int64]bool) error {
+		for idx := len(migrations) - 1; idx >= 0 && steps > 0; idx-- {
+			migration := migrations[idx]
+			if !applied[migration.Version] {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %v_%v has no down file", migration.Version, migration.Name)
+			}
+
+			log.Printf("Reverting migration %v_%v", migration.Version, migration.Name)
+
+			err := migrateInTransaction(conn, DialectOf(db), migration, false)
+			if err != nil {
+				return fmt.Errorf("could not revert migration %v_%v: %v", migration.Version, migration.Name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// withMigrationLock runs fn on a single connection holding the migration lock. Advisory locks belong to a session,
+// hence everything, including fn, has to use the same connection. fn receives versions applied so far
+func withMigrationLock(db *sql.DB, fn func(*sql.Conn, map[int64]bool) error) error {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if DialectOf(db) == Postgres {
+		if _, err = conn.ExecContext(ctx, queryLockMigrations, migrationLockKey); err != nil {
+			return fmt.Errorf("could not lock migrations: %v", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(ctx, queryUnlockMigrations, migrationLockKey); err != nil {
+				log.Printf("Could not unlock migrations: %v", err)
+			}
+		}()
+	}
+
+	if _, err = conn.ExecContext(ctx, queryCreateMigrations); err != nil {
+		return fmt.Errorf("could not create schema_migrations: %v", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, queryGetMigrations)
+	if err != nil {
+		return fmt.Errorf("could not get applied migrations: %v", err)
+	}
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err = rows.Scan(&version); err != nil {
+			rows.Close()
+			return fmt.Errorf("could not scan applied migration: %v", err)
+		}
+		applied[version] = true
+	}
+	rows.Close()
+
+	return fn(conn, applied)
+}
+
+// migrateInTransaction applies (up) or reverts migration together with its record in a single transaction. Migration
+// applied or reverted by another instance since the applied versions were read is skipped
+func migrateInTransaction(conn *sql.Conn, dialect Dialect, migration Migration, up bool) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var migrated int
+	if err = tx.QueryRowContext(ctx, dialect.Rebind(queryIsMigrated), migration.Version).Scan(&migrated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if (migrated > 0) == up {
+		return tx.Rollback()
+	}
+
+	script, query, args := migration.Up, querySaveMigration, []interface{}{migration.Version, migration.Name}
+	if !up {
+		script, query, args = migration.Down, queryDeleteMigration, []interface{}{migration.Version}
+	}
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, dialect.Rebind(query), args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
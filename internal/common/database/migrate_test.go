@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/common/database/migrations"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+var testMigrations = fstest.MapFS{
+	"0002_add_year.up.sql":      {Data: []byte("ALTER TABLE test ADD COLUMN year INT")},
+	"0002_add_year.down.sql":    {Data: []byte("ALTER TABLE test DROP COLUMN year")},
+	"0001_create_test.up.sql":   {Data: []byte("CREATE TABLE test (id SERIAL PRIMARY KEY)")},
+	"0001_create_test.down.sql": {Data: []byte("DROP TABLE test")},
+	"README.md":                 {Data: []byte("Not a migration")},
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+// expectLocked expects migration lock to be taken and schema_migrations to report applied versions
+func expectLocked(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectExec(regexp.QuoteMeta(queryLockMigrations)).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(queryCreateMigrations)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, version := range applied {
+		rows.AddRow(version)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(queryGetMigrations)).WillReturnRows(rows)
+}
+
+func expectMigrated(mock sqlmock.Sqlmock, version int64, count int) {
+	mock.ExpectQuery(regexp.QuoteMeta(queryIsMigrated)).WithArgs(version).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func expectUnlocked(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta(queryUnlockMigrations)).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	loadMigrationsTests := []struct {
+		testName       string
+		fsys           fstest.MapFS
+		expectedOutput []Migration
+		expectError    bool
+	}{
+		{
+			testName: "Test Successful",
+			fsys:     testMigrations,
+			expectedOutput: []Migration{
+				{Version: 1, Name: "create_test", Up: "CREATE TABLE test (id SERIAL PRIMARY KEY)", Down: "DROP TABLE test"},
+				{Version: 2, Name: "add_year", Up: "ALTER TABLE test ADD COLUMN year INT", Down: "ALTER TABLE test DROP COLUMN year"},
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Missing up file",
+			fsys: fstest.MapFS{
+				"0001_create_test.down.sql": {Data: []byte("DROP TABLE test")},
+			},
+			expectError: true,
+		},
+		{
+			testName: "Test Unsuccessful: Names differ",
+			fsys: fstest.MapFS{
+				"0001_create_test.up.sql":   {Data: []byte("CREATE TABLE test (id SERIAL PRIMARY KEY)")},
+				"0001_create_best.down.sql": {Data: []byte("DROP TABLE test")},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range loadMigrationsTests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := LoadMigrations(test.fsys)
+
+			assert.Equal(t, test.expectError, err != nil, "Unexpected error: %v", err)
+			assert.Equal(t, test.expectedOutput, res)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	migrateTests := []struct {
+		testName    string
+		mockFunc    func(mock sqlmock.Sqlmock)
+		expectError bool
+	}{
+		{
+			testName: "Test Successful: Pending migration applied",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectLocked(mock, 1)
+				mock.ExpectBegin()
+				expectMigrated(mock, 2, 0)
+				mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE test ADD COLUMN year INT")).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(querySaveMigration)).WithArgs(2, "add_year").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlocked(mock)
+			},
+		},
+		{
+			testName: "Test Successful: Up to date",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectLocked(mock, 1, 2)
+				expectUnlocked(mock)
+			},
+		},
+		{
+			testName: "Test Successful: Applied concurrently",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectLocked(mock, 1)
+				mock.ExpectBegin()
+				expectMigrated(mock, 2, 1)
+				mock.ExpectRollback()
+				expectUnlocked(mock)
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Migration failed",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				expectLocked(mock)
+				mock.ExpectBegin()
+				expectMigrated(mock, 1, 0)
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE test (id SERIAL PRIMARY KEY)")).WillReturnError(goerrors.New("syntax error"))
+				mock.ExpectRollback()
+				expectUnlocked(mock)
+			},
+			expectError: true,
+		},
+		{
+			testName: "Test Unsuccessful: Lock failed",
+			mockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(queryLockMigrations)).WithArgs(migrationLockKey).WillReturnError(goerrors.New("connection reset"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range migrateTests {
+		t.Run(test.testName, func(t *testing.T) {
+			db, mock := newMock(t)
+			defer db.Close()
+
+			test.mockFunc(mock)
+
+			err := Migrate(db, testMigrations)
+
+			assert.Equal(t, test.expectError, err != nil, "Unexpected error: %v", err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	db, mock := newMock(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 2)
+	mock.ExpectBegin()
+	expectMigrated(mock, 2, 1)
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE test DROP COLUMN year")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(queryDeleteMigration)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlocked(mock)
+
+	err := Rollback(db, testMigrations, 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	for _, fsys := range []fs.FS{migrations.Postgres, migrations.SQLite} {
+		res, err := LoadMigrations(fsys)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, res)
+		for idx, migration := range res {
+			assert.Equal(t, int64(idx+1), migration.Version, "Migrations should be numbered without gaps")
+			assert.NotEmpty(t, migration.Down, "Migration %v_%v has no down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestMigrate_SQLite(t *testing.T) {
+	db, err := sql.Open(sqliteDriver, ":memory:")
+	if err != nil {
+		t.Fatalf("Could not open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+
+	assert.NoError(t, Migrate(db, migrations.SQLite))
+	assert.NoError(t, Migrate(db, migrations.SQLite), "Migrating twice should do nothing")
+
+	_, err = db.Exec(`INSERT INTO bookstore (title, author, year) VALUES ('Solaris', 'Stanislaw Lem', 1961)`)
+	assert.NoError(t, err)
+
+	var similarity float64
+	assert.NoError(t, db.QueryRow(`SELECT similarity(author, 'Stanislav Lem') FROM bookstore`).Scan(&similarity))
+	assert.Greater(t, similarity, 0.5)
+
+	all, err := LoadMigrations(migrations.SQLite)
+	assert.NoError(t, err)
+	assert.NoError(t, Rollback(db, migrations.SQLite, len(all)))
+
+	_, err = db.Exec(`SELECT * FROM bookstore`)
+	assert.Error(t, err, "Table should be dropped by rollback")
+}
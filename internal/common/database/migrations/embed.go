@@ -0,0 +1,27 @@
+// Package migrations holds numbered schema migrations of the media library, a set per SQL dialect. Every migration is
+// a pair of NNNN_name.up.sql and NNNN_name.down.sql files, applied in the order of their numbers
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var (
+	// Postgres contains migrations of postgres schema
+	Postgres = sub("postgres")
+
+	// SQLite contains migrations of SQLite schema. Only books, users and access denials are stored in SQLite
+	SQLite = sub("sqlite")
+)
+
+func sub(dir string) fs.FS {
+	fsys, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return fsys
+}
@@ -1,7 +1,7 @@
-CREATE TABLE IF NOT EXISTS bookstore (
-    id SERIAL PRIMARY KEY,
-    title TEXT NOT NULL,
-    author TEXT NOT NULL,
-    year INT NOT NULL,
-    description TEST
-);
\ No newline at end of file
+CREATE TABLE IF NOT EXISTS bookstore (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    year INT NOT NULL,
+    description TEXT
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS movies (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    director TEXT NOT NULL,
+    year INT NOT NULL,
+    runtime INT NOT NULL,
+    rating REAL NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT ''
+);
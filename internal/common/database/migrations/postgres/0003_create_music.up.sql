@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    artist TEXT NOT NULL,
+    year INT NOT NULL,
+    genre TEXT NOT NULL DEFAULT ''
+);
+
+-- Tracks are removed together with their album
+CREATE TABLE IF NOT EXISTS tracks (
+    id SERIAL PRIMARY KEY,
+    album_id INT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    number INT NOT NULL,
+    title TEXT NOT NULL,
+    duration INT NOT NULL
+);
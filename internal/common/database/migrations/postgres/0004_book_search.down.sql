@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS bookstore_search_idx;
+ALTER TABLE bookstore DROP COLUMN IF EXISTS search_vector;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,12 @@
+-- Full-text search vector over title, author and description. Title matches weigh the most and description ones
+-- the least. Vector is generated by postgres, so it never goes out of sync with the row
+ALTER TABLE bookstore ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', author), 'B') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS bookstore_search_idx ON bookstore USING GIN (search_vector);
+
+-- Typo tolerant author and title lookup relies on trigram similarity
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
@@ -0,0 +1,14 @@
+-- Audit log of every mutation of books. Records outlive the books they are about, so book_id is not a foreign key
+CREATE TABLE IF NOT EXISTS book_audit (
+    id BIGSERIAL PRIMARY KEY,
+    book_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS book_audit_book_idx ON book_audit (book_id, created_at);
+CREATE INDEX IF NOT EXISTS book_audit_created_at_idx ON book_audit (created_at);
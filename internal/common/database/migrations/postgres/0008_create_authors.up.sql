@@ -0,0 +1,35 @@
+-- Authors are told apart by name_key, the lower case name with dots and runs of spaces collapsed into single spaces,
+-- so "J.R.R. Tolkien" and "J. R. R. Tolkien" are one author. entity.AuthorNameKey computes the same key
+CREATE TABLE IF NOT EXISTS authors (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    name_key TEXT NOT NULL UNIQUE
+);
+
+-- Links are removed together with the book or the author. Position orders co-authors of a book
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INT NOT NULL REFERENCES bookstore(id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_idx ON book_authors (author_id);
+
+-- Author strings of existing books become records. Co-authors are separated by '&' or ';'. Of the spellings sharing
+-- a key the longest one is kept as the name
+CREATE TEMPORARY TABLE book_author_names ON COMMIT DROP AS
+    SELECT b.id AS book_id, btrim(n.name) AS name, n.position::INT AS position,
+        btrim(regexp_replace(lower(n.name), '[[:space:].]+', ' ', 'g')) AS name_key
+    FROM bookstore b, regexp_split_to_table(b.author, '[&;]') WITH ORDINALITY AS n(name, position);
+
+DELETE FROM book_author_names WHERE name_key = '';
+
+INSERT INTO authors (name, name_key)
+    SELECT DISTINCT ON (name_key) name, name_key FROM book_author_names ORDER BY name_key, length(name) DESC, name
+    ON CONFLICT (name_key) DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, position)
+    SELECT n.book_id, a.id, MIN(n.position) FROM book_author_names n JOIN authors a ON a.name_key = n.name_key
+    GROUP BY n.book_id, a.id
+    ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- ISBN is stored as ISBN-13 without hyphens, ISBN-10 is converted on the way in. Books without ISBN have an empty
+-- one, so only non-empty ISBNs have to be unique. Books in the trash give their ISBN up, restoring one fails while
+-- another book has it
+ALTER TABLE bookstore ADD COLUMN IF NOT EXISTS isbn TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS bookstore_isbn_key ON bookstore (isbn) WHERE isbn <> '' AND deleted_at IS NULL;
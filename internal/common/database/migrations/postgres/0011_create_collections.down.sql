@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS collection_entries;
+DROP TABLE IF EXISTS collections;
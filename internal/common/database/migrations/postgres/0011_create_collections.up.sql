@@ -0,0 +1,17 @@
+-- Collections are named lists of books, like "to read". Position orders books of a collection, starting from 1
+CREATE TABLE IF NOT EXISTS collections (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+-- Entries are removed together with the collection or the purged book. Entries of books in the trash are kept but
+-- not listed, so a restored book is back in its collections
+CREATE TABLE IF NOT EXISTS collection_entries (
+    collection_id INT NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    book_id INT NOT NULL REFERENCES bookstore(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    PRIMARY KEY (collection_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_entries_book_idx ON collection_entries (book_id);
@@ -0,0 +1,8 @@
+-- Users sign in by username and password. Usernames are stored lower case, so they are unique regardless of case.
+-- Passwords are kept as bcrypt hashes only
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
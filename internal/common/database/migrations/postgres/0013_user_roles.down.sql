@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS access_denials;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,5 @@
+-- Collections belong to the user who made them and are removed together with the user. Collections made before they
+-- had owners are left without one, so no user reaches them
+ALTER TABLE collections ADD COLUMN user_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS collections_user_idx ON collections (user_id);
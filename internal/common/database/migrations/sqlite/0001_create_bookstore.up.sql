@@ -0,0 +1,8 @@
+-- AUTOINCREMENT keeps ids of deleted books from being reused, like postgres SERIAL does
+CREATE TABLE IF NOT EXISTS bookstore (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    year INT NOT NULL,
+    description TEXT
+);
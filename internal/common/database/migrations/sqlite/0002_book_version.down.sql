@@ -0,0 +1 @@
+ALTER TABLE bookstore DROP COLUMN version;
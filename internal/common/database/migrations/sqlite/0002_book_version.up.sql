@@ -0,0 +1,2 @@
+-- Version is bumped by every update, so a client can tell its copy of the book went stale
+ALTER TABLE bookstore ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
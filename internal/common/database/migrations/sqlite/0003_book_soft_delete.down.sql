@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS bookstore_deleted_at_idx;
+ALTER TABLE bookstore DROP COLUMN deleted_at;
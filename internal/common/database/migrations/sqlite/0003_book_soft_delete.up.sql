@@ -0,0 +1,4 @@
+-- Deleted books stay in the table until purged, with the time they were deleted. Live books have no deleted_at
+ALTER TABLE bookstore ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS bookstore_deleted_at_idx ON bookstore (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Audit log of every mutation of books. Records outlive the books they are about, so book_id is not a foreign key.
+-- Old and new values are JSON text
+CREATE TABLE IF NOT EXISTS book_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS book_audit_book_idx ON book_audit (book_id, created_at);
+CREATE INDEX IF NOT EXISTS book_audit_created_at_idx ON book_audit (created_at);
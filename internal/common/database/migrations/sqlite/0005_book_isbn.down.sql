@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS bookstore_isbn_key;
+ALTER TABLE bookstore DROP COLUMN isbn;
@@ -0,0 +1,15 @@
+-- Tags, genres among them, are stored once and linked to books. Names are normalized by entity.NormalizeTag. Tags
+-- no book has any more are kept, tag counts leave them out
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+-- Links are removed together with the book or the tag. Books in the trash keep their tags until purged
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id INTEGER NOT NULL REFERENCES bookstore(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_tags_tag_idx ON book_tags (tag_id);
@@ -0,0 +1,20 @@
+-- Roles are reader, librarian and admin. Users sign up as readers, the admin is appointed by configuration
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'reader';
+
+-- Requests turned away for lacking a valid token (status 401) or permission (status 403). Route is the pattern the
+-- request matched, path is the one it was made to
+CREATE TABLE IF NOT EXISTS access_denials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    path TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS access_denials_created_at_idx ON access_denials (created_at);
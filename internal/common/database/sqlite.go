@@ -0,0 +1,106 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/database/migrations"
+	"github.com/mattn/go-sqlite3"
+	"log"
+	"time"
+)
+
+// sqliteDriver is SQLite driver with functions postgres has built in, so the same queries work on both databases
+const sqliteDriver = "sqlite3_medialibrary"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("similarity", similarity, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("text_rank", textRank, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("json_build_object", jsonBuildObject, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("text_headline", Headline, true)
+		},
+	})
+}
+
+func similarity(lhs string, rhs string) float64 {
+	return float64(Similarity(lhs, rhs))
+}
+
+// textRank weights title, author and description the same way ts_rank does with A, B and C weighted vectors
+func textRank(query string, title string, author string, description string) float64 {
+	return float64(TextRank(query, title, author, description))
+}
+
+// jsonBuildObject builds a JSON object of alternating keys and values, like json_build_object of postgres does
+func jsonBuildObject(args ...interface{}) (string, error) {
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("json_build_object needs pairs of keys and values, got %v arguments", len(args))
+	}
+
+	var object bytes.Buffer
+	object.WriteByte('{')
+	for idx := 0; idx < len(args); idx += 2 {
+		key, ok := args[idx].(string)
+		if !ok {
+			return "", fmt.Errorf("key of json_build_object should be text, got %T", args[idx])
+		}
+
+		value := args[idx+1]
+		if blob, ok := value.([]byte); ok {
+			value = string(blob)
+		}
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return "", err
+		}
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+
+		if idx > 0 {
+			object.WriteString(", ")
+		}
+		object.Write(encodedKey)
+		object.WriteString(" : ")
+		object.Write(encodedValue)
+	}
+	object.WriteByte('}')
+
+	return object.String(), nil
+}
+
+// CreateSQLitePool returns connection pool of SQLite database at path, creating the file if needed. Pending
+// migrations are applied before returning the instance
+func CreateSQLitePool(path string, dbMaxIdleConns int, dbMaxOpenConns int, dbMaxIdleTime time.Duration) *sql.DB {
+	log.Printf("DB configs:\nPath: %v\nMax idle conns: %v\nMax open conns: %v\nMax idle time: %v",
+		path, dbMaxIdleConns, dbMaxOpenConns, dbMaxIdleTime)
+
+	// Immediate transactions take the write lock at BEGIN, busy timeout makes writers wait for it instead of failing
+	db, err := sql.Open(sqliteDriver, "file:"+path+"?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		log.Panicf("Could not open db:%v: %v", path, err)
+	}
+
+	if err = Migrate(db, migrations.SQLite); err != nil {
+		log.Panicf("Could not migrate db:%v: %v", path, err)
+	}
+
+	db.SetMaxIdleConns(dbMaxIdleConns)
+	db.SetMaxOpenConns(dbMaxOpenConns)
+	db.SetConnMaxIdleTime(dbMaxIdleTime)
+
+	log.Println("Connected to database successfully")
+
+	return db
+}
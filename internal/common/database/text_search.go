@@ -0,0 +1,107 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Weights of fields passed to TextRank, in the order of postgres A, B, C and D weights
+var textRankWeights = []float32{1.0, 0.4, 0.2, 0.1}
+
+// TextRank approximates ts_rank for databases without full-text search. Every word of query has to be contained in
+// at least one of fields, otherwise the rank is 0. Each containing field adds its weight to the rank, the first field
+// weighing the most
+func TextRank(query string, fields ...string) float32 {
+	var rank float32
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		var wordRank float32
+		for idx, field := range fields {
+			if idx < len(textRankWeights) && strings.Contains(strings.ToLower(field), word) {
+				wordRank += textRankWeights[idx]
+			}
+		}
+
+		if wordRank == 0 {
+			return 0
+		}
+		rank += wordRank
+	}
+
+	return rank
+}
+
+// Headline approximates ts_headline: every occurrence of words of query in text is wrapped with <b></b>
+func Headline(text string, query string) string {
+	lowerText := strings.ToLower(text)
+	if len(lowerText) != len(text) { // Case mapping changed byte offsets, positions would not match
+		return text
+	}
+
+	marked := make([]bool, len(text))
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		for start := 0; ; {
+			idx := strings.Index(lowerText[start:], word)
+			if idx < 0 {
+				break
+			}
+			for pos := start + idx; pos < start+idx+len(word); pos++ {
+				marked[pos] = true
+			}
+			start += idx + len(word)
+		}
+	}
+
+	var res strings.Builder
+	for pos := range text {
+		if marked[pos] && (pos == 0 || !marked[pos-1]) {
+			res.WriteString("<b>")
+		}
+		res.WriteByte(text[pos])
+		if marked[pos] && (pos == len(text)-1 || !marked[pos+1]) {
+			res.WriteString("</b>")
+		}
+	}
+
+	return res.String()
+}
+
+// Similarity works the same as pg_trgm similarity: count of trigrams shared by lhs and rhs divided by count of
+// distinct trigrams of both
+func Similarity(lhs string, rhs string) float32 {
+	lhsTrigrams := trigrams(lhs)
+	rhsTrigrams := trigrams(rhs)
+
+	var shared int
+	for trigram := range lhsTrigrams {
+		if rhsTrigrams[trigram] {
+			shared++
+		}
+	}
+
+	total := len(lhsTrigrams) + len(rhsTrigrams) - shared
+	if total == 0 {
+		return 0
+	}
+
+	return float32(shared) / float32(total)
+}
+
+// trigrams returns set of trigrams of s the way pg_trgm builds it: words of lower case alphanumerics padded with two
+// spaces in front and one space behind
+func trigrams(s string) map[string]bool {
+	res := make(map[string]bool)
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for idx := 0; idx+3 <= len(padded); idx++ {
+			res[string(padded[idx:idx+3])] = true
+		}
+	}
+
+	return res
+}
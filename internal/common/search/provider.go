@@ -0,0 +1,21 @@
+package search
+
+import "context"
+
+// Hit is a single result of the cross-media search. Kind tells which media the Item belongs to, so clients can
+// decode Item as a book, movie, album or track
+type Hit struct {
+	Kind  string      `json:"kind"`
+	ID    uint64      `json:"id"`
+	Title string      `json:"title"`
+	Item  interface{} `json:"item"`
+}
+
+// Provider is implemented by every media module which takes part in the cross-media search
+type Provider interface {
+	// Kinds returns all the hit kinds provider can produce
+	Kinds() []string
+	// Search returns at most limit hits of every kind matching the query. No matches is not an error. Search should
+	// give up once ctx is done
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+}
@@ -0,0 +1,54 @@
+package common_auth
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// HeaderAuthorization carries the access token of a request, as "Bearer <token>"
+const HeaderAuthorization = "Authorization"
+
+// User is the signed in user a request is made by
+type User struct {
+	ID       uint64 `json:"id"`
+	Username string `json:"username"`
+	Role     Role   `json:"role"`
+}
+
+type contextKey int
+
+const userKey contextKey = iota
+
+type authUnauthorized struct {
+	common_errors.CommonError
+}
+
+func NewAuthUnauthorized(msg string) authUnauthorized {
+	return authUnauthorized{
+		common_errors.CommonError{Msg: msg},
+	}
+}
+
+// WithUser returns copy of ctx carrying the user
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFrom returns the user ctx carries. It is false for requests which were not authenticated by a Guard
+func UserFrom(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey).(User)
+	return user, ok
+}
+
+// bearerToken returns the token of the Authorization header, if the header uses the bearer scheme
+func bearerToken(c *gin.Context) (string, bool) {
+	parts := strings.SplitN(strings.TrimSpace(c.GetHeader(HeaderAuthorization)), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], TokenType) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(parts[1])
+	return token, token != ""
+}
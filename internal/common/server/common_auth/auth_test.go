@@ -0,0 +1,60 @@
+package common_auth
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testUser = User{ID: 7, Username: "librarian", Role: RoleLibrarian}
+
+func TestSigner_Verify(t *testing.T) {
+	signer := NewSigner([]byte("secret"), time.Minute, time.Hour)
+
+	tokens, err := signer.Issue(testUser)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenType, tokens.TokenType)
+	assert.Equal(t, int64(60), tokens.ExpiresIn)
+
+	user, err := signer.Verify(tokens.AccessToken, KindAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, testUser, user)
+
+	user, err = signer.Verify(tokens.RefreshToken, KindRefresh)
+	assert.NoError(t, err)
+	assert.Equal(t, testUser, user)
+
+	t.Run("Test Unsuccessful: Token of another kind", func(t *testing.T) {
+		_, err := signer.Verify(tokens.RefreshToken, KindAccess)
+		assert.Equal(t, ErrTokenInvalid, err)
+	})
+
+	t.Run("Test Unsuccessful: Signed by another key", func(t *testing.T) {
+		_, err := NewSigner([]byte("another secret"), time.Minute, time.Hour).Verify(tokens.AccessToken, KindAccess)
+		assert.Equal(t, ErrTokenInvalid, err)
+	})
+
+	t.Run("Test Unsuccessful: Not signed", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "7", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			Username:         "librarian",
+			Kind:             KindAccess,
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+
+		_, err = signer.Verify(token, KindAccess)
+		assert.Equal(t, ErrTokenInvalid, err)
+	})
+
+	t.Run("Test Unsuccessful: Expired", func(t *testing.T) {
+		expired := NewSigner([]byte("secret"), time.Minute, time.Hour)
+		expired.now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+
+		tokens, err := expired.Issue(testUser)
+		assert.NoError(t, err)
+
+		_, err = signer.Verify(tokens.AccessToken, KindAccess)
+		assert.Equal(t, ErrTokenExpired, err)
+	})
+}
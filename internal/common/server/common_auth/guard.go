@@ -0,0 +1,154 @@
+package common_auth
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_request"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Policy tells the permission each route needs, keyed by the method and the full route pattern, as "DELETE /book/:id".
+// Routes left out of a policy are open for reading, but closed for everything else, so a new route changing media
+// cannot be reached before it is given a permission
+type Policy map[string]Permission
+
+// Permission returns the permission needed by the route, and whether the policy lists the route at all
+func (p Policy) Permission(method string, route string) (Permission, bool) {
+	permission, ok := p[method+" "+route]
+	return permission, ok
+}
+
+// Denial is a request turned away by a Guard, for lacking a valid token (401) or permission (403)
+type Denial struct {
+	ID         uint64     `json:"id"`
+	Actor      string     `json:"actor"`
+	Role       Role       `json:"role"`
+	Method     string     `json:"method"`
+	Route      string     `json:"route"`
+	Path       string     `json:"path"`
+	Permission Permission `json:"permission"`
+	Status     int        `json:"status"`
+	Reason     string     `json:"reason"`
+	RequestID  string     `json:"request_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// DenialRecorder keeps the audit of denied requests
+type DenialRecorder interface {
+	RecordDenial(context.Context, Denial) error
+}
+
+// Guard lets requests through routes according to a Policy, authenticating them by their access tokens. Every denied
+// request is recorded
+type Guard struct {
+	signer  *Signer
+	denials DenialRecorder
+	now     func() time.Time
+}
+
+func NewGuard(signer *Signer, denials DenialRecorder) *Guard {
+	return &Guard{
+		signer:  signer,
+		denials: denials,
+		now:     time.Now,
+	}
+}
+
+// Enforce returns a middleware checking requests against the policy. It is meant for route groups, since it looks the
+// permission up by the route the request matched. Requests with a valid token carry the user in their context, who
+// becomes the actor of the request, replacing the one of X-Actor
+//
+// The role is the one the access token was issued with, so a changed role takes effect once the user gets new tokens
+func (g *Guard) Enforce(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permission, listed := policy.Permission(c.Request.Method, c.FullPath())
+		if !listed {
+			if isSafeMethod(c.Request.Method) {
+				c.Next()
+				return
+			}
+
+			g.deny(c, User{}, permission, http.StatusForbidden, common_errors.CommonError{Msg: "Route has no policy"})
+			return
+		}
+
+		if permission == Public {
+			c.Next()
+			return
+		}
+
+		user, err := g.authenticate(c)
+		if err != nil {
+			g.deny(c, User{}, permission, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !user.Role.Can(permission) {
+			g.deny(c, user, permission, http.StatusForbidden, common_errors.NewForbiddenError(string(permission)))
+			return
+		}
+
+		ctx := common_request.WithActor(WithUser(c.Request.Context(), user), user.Username)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+func (g *Guard) authenticate(c *gin.Context) (User, error) {
+	token, ok := bearerToken(c)
+	if !ok {
+		return User{}, NewAuthUnauthorized("Expected access token in Authorization header")
+	}
+
+	user, err := g.signer.Verify(token, KindAccess)
+	if err != nil {
+		return User{}, NewAuthUnauthorized("Access " + err.Error())
+	}
+
+	return user, nil
+}
+
+// deny responds with the status and aborts the request, recording it first. Failing to record does not change the
+// response, since the request is turned away either way
+func (g *Guard) deny(c *gin.Context, user User, permission Permission, status int, reason error) {
+	ctx := c.Request.Context()
+
+	actor := common_request.Actor(ctx)
+	if user.Username != "" {
+		actor = user.Username
+	}
+
+	denial := Denial{
+		Actor:      actor,
+		Role:       user.Role,
+		Method:     c.Request.Method,
+		Route:      c.FullPath(),
+		Path:       c.Request.URL.Path,
+		Permission: permission,
+		Status:     status,
+		Reason:     reason.Error(),
+		RequestID:  common_request.RequestID(ctx),
+		CreatedAt:  g.now().UTC().Truncate(time.Second),
+	}
+
+	if g.denials != nil {
+		if err := g.denials.RecordDenial(ctx, denial); err != nil {
+			log.Printf("Could not record denied request %v %v: %v", denial.Method, denial.Path, err)
+		}
+	}
+
+	if status == http.StatusUnauthorized {
+		common_errors.RespondUnauthorized(c, reason)
+	} else {
+		common_errors.RespondForbidden(c, reason)
+	}
+	c.Abort()
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,42 @@
+package common_auth
+
+// Role of a user tells what the user may do beyond reading, which is open to everyone
+type Role string
+
+const (
+	RoleReader    Role = "reader"    // Reads only
+	RoleLibrarian Role = "librarian" // Creates and updates media
+	RoleAdmin     Role = "admin"     // Everything, including wiping media out and managing users
+)
+
+// Permission is what a route of a Policy needs
+type Permission string
+
+const (
+	Public          Permission = "public"       // Anyone, signed in or not
+	PermSignedIn    Permission = "signed_in"    // Any signed in user
+	PermWrite       Permission = "write"        // Create, change and delete single media
+	PermDeleteAll   Permission = "delete_all"   // Delete all media of a kind or purge the trash for good
+	PermReadAudit   Permission = "read_audit"   // Read the audit of changes, the history and the trash of media
+	PermManageUsers Permission = "manage_users" // Change roles of users and read the denied requests
+)
+
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleReader:    {PermSignedIn: true},
+	RoleLibrarian: {PermSignedIn: true, PermWrite: true, PermReadAudit: true},
+	RoleAdmin:     {PermSignedIn: true, PermWrite: true, PermReadAudit: true, PermDeleteAll: true, PermManageUsers: true},
+}
+
+// Roles lists every role, the least privileged first
+var Roles = []Role{RoleReader, RoleLibrarian, RoleAdmin}
+
+// Valid returns true for a known role
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can returns true if the role has the permission. Public permission is had by every role, even an unknown one
+func (r Role) Can(permission Permission) bool {
+	return permission == Public || rolePermissions[r][permission]
+}
@@ -0,0 +1,30 @@
+package common_auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRole_Can(t *testing.T) {
+	permissions := []Permission{Public, PermSignedIn, PermWrite, PermReadAudit, PermDeleteAll, PermManageUsers}
+
+	testCases := []struct {
+		role     Role
+		expected []bool
+	}{
+		{role: RoleReader, expected: []bool{true, true, false, false, false, false}},
+		{role: RoleLibrarian, expected: []bool{true, true, true, true, false, false}},
+		{role: RoleAdmin, expected: []bool{true, true, true, true, true, true}},
+		{role: "", expected: []bool{true, false, false, false, false, false}},
+		{role: "owner", expected: []bool{true, false, false, false, false, false}},
+	}
+
+	for _, tc := range testCases {
+		for i, permission := range permissions {
+			assert.Equal(t, tc.expected[i], tc.role.Can(permission), "Role %q with permission %v", tc.role, permission)
+		}
+	}
+
+	assert.True(t, RoleLibrarian.Valid())
+	assert.False(t, Role("owner").Valid())
+}
@@ -0,0 +1,115 @@
+package common_auth
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"strconv"
+	"time"
+)
+
+// Kinds of tokens. Access tokens authenticate requests, refresh tokens are only good for getting new tokens
+const (
+	KindAccess  = "access"
+	KindRefresh = "refresh"
+
+	// TokenType is the scheme tokens are sent with in the Authorization header
+	TokenType = "Bearer"
+)
+
+var (
+	ErrTokenInvalid = errors.New("token is invalid")
+	ErrTokenExpired = errors.New("token is expired")
+)
+
+// Tokens are issued to a signed in user. ExpiresIn is the lifetime of the access token in seconds
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// claims of the tokens. Subject is the user id
+type claims struct {
+	jwt.RegisteredClaims
+	Username string `json:"username"`
+	Role     Role   `json:"role"`
+	Kind     string `json:"kind"`
+}
+
+// Signer issues and verifies tokens signed by HMAC-SHA256 with a secret key
+type Signer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewSigner(secret []byte, accessTTL time.Duration, refreshTTL time.Duration) *Signer {
+	return &Signer{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// Issue returns a new pair of access and refresh tokens of the user
+func (s *Signer) Issue(user User) (Tokens, error) {
+	accessToken, err := s.sign(user, KindAccess, s.accessTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	refreshToken, err := s.sign(user, KindRefresh, s.refreshTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    TokenType,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+	}, nil
+}
+
+func (s *Signer) sign(user User, kind string, ttl time.Duration) (string, error) {
+	now := s.now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Username: user.Username,
+		Role:     user.Role,
+		Kind:     kind,
+	})
+
+	return token.SignedString(s.secret)
+}
+
+// Verify returns the user a token of the kind was issued to, with the role the user had then. Tokens of another kind,
+// signed by another key or algorithm are ErrTokenInvalid, the ones past their lifetime are ErrTokenExpired
+func (s *Signer) Verify(token string, kind string) (User, error) {
+	var c claims
+
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return User{}, ErrTokenExpired
+	} else if err != nil {
+		return User{}, ErrTokenInvalid
+	}
+
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil || id < 1 || c.Kind != kind {
+		return User{}, ErrTokenInvalid
+	}
+
+	return User{ID: id, Username: c.Username, Role: c.Role}, nil
+}
@@ -1,6 +1,7 @@
 package common_errors
 
 import (
+	"fmt"
 	common_response "github.com/foxfurry/simple-rest/internal/common/server/common_response"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -14,6 +15,17 @@ func (ce CommonError) Error() string {
 	return ce.Msg
 }
 
+// ForbiddenError is returned for requests of a signed in user whose role lacks the permission they need
+type ForbiddenError struct {
+	CommonError
+}
+
+func NewForbiddenError(permission string) ForbiddenError {
+	return ForbiddenError{
+		CommonError{Msg: fmt.Sprintf("Permission %v is required", permission)},
+	}
+}
+
 func respondWithError(c *gin.Context, status int, err error) {
 	common_response.Respond(c, status, nil, err)
 }
@@ -30,6 +42,36 @@ func RespondBadRequest(c *gin.Context, err error) {
 	respondWithError(c, http.StatusBadRequest, err)
 }
 
+// RespondUnauthorized responds to a request lacking valid credentials, telling the client to send a bearer token
+func RespondUnauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", "Bearer")
+	respondWithError(c, http.StatusUnauthorized, err)
+}
+
+func RespondForbidden(c *gin.Context, err error) {
+	respondWithError(c, http.StatusForbidden, err)
+}
+
 func RespondAlreadyExists(c *gin.Context, err error) {
 	respondWithError(c, http.StatusConflict, err)
 }
+
+func RespondGatewayTimeout(c *gin.Context, err error) {
+	respondWithError(c, http.StatusGatewayTimeout, err)
+}
+
+func RespondPreconditionFailed(c *gin.Context, err error) {
+	respondWithError(c, http.StatusPreconditionFailed, err)
+}
+
+func RespondPreconditionRequired(c *gin.Context, err error) {
+	respondWithError(c, http.StatusPreconditionRequired, err)
+}
+
+func RespondConflict(c *gin.Context, err error) {
+	respondWithError(c, http.StatusConflict, err)
+}
+
+func RespondUnsupportedMediaType(c *gin.Context, err error) {
+	respondWithError(c, http.StatusUnsupportedMediaType, err)
+}
@@ -0,0 +1,93 @@
+package common_pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+const cursorParam = "cursor"
+
+var FieldCursorInvalid = ct.FieldError{
+	Field: cursorParam,
+	Msg:   "Cursor is malformed or does not match the requested order",
+}
+
+// CursorMeta is sent along with the data of keyset paginated listing. NextCursor is empty on the last page
+type CursorMeta struct {
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
+
+// CursorRequested returns true if client asked for keyset pagination by passing cursor parameter. Empty cursor
+// requests the first page
+func CursorRequested(c *gin.Context) bool {
+	_, ok := c.GetQuery(cursorParam)
+	return ok
+}
+
+// ParseCursor decodes cursor query parameter into position. It returns false if cursor is empty, i.e. the first
+// page is requested
+func ParseCursor(c *gin.Context, position interface{}) (bool, []ct.FieldError) {
+	cursor := c.Query(cursorParam)
+	if cursor == "" {
+		return false, nil
+	}
+
+	if err := DecodeCursor(cursor, position); err != nil {
+		return false, []ct.FieldError{FieldCursorInvalid}
+	}
+
+	return true, nil
+}
+
+// ParsePerPage reads per_page query parameter, falling back to default size
+func ParsePerPage(c *gin.Context) (int, []ct.FieldError) {
+	perPage, err := strconv.Atoi(c.DefaultQuery(perPageParam, strconv.Itoa(DefaultPerPage)))
+	if err != nil || perPage < 1 || perPage > MaxPerPage {
+		return 0, []ct.FieldError{FieldPerPageInvalid}
+	}
+	return perPage, nil
+}
+
+// EncodeCursor returns opaque cursor holding position. Clients should pass it back as is
+func EncodeCursor(position interface{}) string {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor reads position from cursor created by EncodeCursor
+func DecodeCursor(cursor string, position interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, position)
+}
+
+// NewCursorMeta returns meta of keyset paginated page. Next link points to the same url as the request, with only
+// cursor parameter changed
+func NewCursorMeta(c *gin.Context, perPage int, nextCursor string) CursorMeta {
+	meta := CursorMeta{
+		PerPage:    perPage,
+		NextCursor: nextCursor,
+	}
+
+	if nextCursor != "" {
+		link := *c.Request.URL
+
+		query := link.Query()
+		query.Set(cursorParam, nextCursor)
+		link.RawQuery = query.Encode()
+
+		meta.Next = link.RequestURI()
+	}
+
+	return meta
+}
@@ -0,0 +1,102 @@
+package common_pagination
+
+import (
+	"fmt"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+const (
+	pageParam    = "page"
+	perPageParam = "per_page"
+
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+var (
+	FieldPageInvalid = ct.FieldError{
+		Field: pageParam,
+		Msg:   "Page should be positive non-null number",
+	}
+	FieldPerPageInvalid = ct.FieldError{
+		Field: perPageParam,
+		Msg:   fmt.Sprintf("Per page should be between 1 and %v", MaxPerPage),
+	}
+)
+
+// Page is a window of a listing requested with page and per_page query parameters. Pages are numbered from 1
+type Page struct {
+	Number  int
+	PerPage int
+}
+
+func (p Page) Limit() int {
+	return p.PerPage
+}
+
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.PerPage
+}
+
+// PageMeta is sent along with the page data, so clients could navigate the listing
+type PageMeta struct {
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Total   int64  `json:"total"`
+	Next    string `json:"next,omitempty"`
+	Prev    string `json:"prev,omitempty"`
+}
+
+// ParsePage reads page and per_page query parameters. Missing parameters fall back to the first page of default size
+func ParsePage(c *gin.Context) (Page, []ct.FieldError) {
+	var fieldErrors []ct.FieldError
+
+	number, err := strconv.Atoi(c.DefaultQuery(pageParam, "1"))
+	if err != nil || number < 1 {
+		fieldErrors = append(fieldErrors, FieldPageInvalid)
+	}
+
+	perPage, perPageErrors := ParsePerPage(c)
+	fieldErrors = append(fieldErrors, perPageErrors...)
+
+	return Page{Number: number, PerPage: perPage}, fieldErrors
+}
+
+// NewPageMeta returns meta of page out of total items. Links point to the same url as the request, with only page
+// parameter changed
+func NewPageMeta(c *gin.Context, page Page, total int64) PageMeta {
+	meta := PageMeta{
+		Page:    page.Number,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	if int64(page.Offset()+page.PerPage) < total {
+		meta.Next = pageLink(c, page.Number+1, page.PerPage)
+	}
+
+	if page.Number > 1 {
+		prev := page.Number - 1
+		if lastPage := int((total + int64(page.PerPage) - 1) / int64(page.PerPage)); prev > lastPage { // Client is past the end
+			prev = lastPage
+		}
+		if prev >= 1 {
+			meta.Prev = pageLink(c, prev, page.PerPage)
+		}
+	}
+
+	return meta
+}
+
+func pageLink(c *gin.Context, number int, perPage int) string {
+	link := *c.Request.URL
+
+	query := link.Query()
+	query.Set(pageParam, strconv.Itoa(number))
+	query.Set(perPageParam, strconv.Itoa(perPage))
+	link.RawQuery = query.Encode()
+
+	return link.RequestURI()
+}
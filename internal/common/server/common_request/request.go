@@ -0,0 +1,91 @@
+package common_request
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+const (
+	// HeaderRequestID carries the id of a request. Ids given by clients are kept, so one id can follow a request
+	// across services
+	HeaderRequestID = "X-Request-ID"
+
+	// HeaderActor names who makes the request
+	HeaderActor = "X-Actor"
+
+	// AnonymousActor is the actor of requests which name nobody
+	AnonymousActor = "anonymous"
+
+	// maxHeaderLength bounds the values taken from headers, since they end up stored along with the changes
+	maxHeaderLength = 128
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	actorKey
+)
+
+// Middleware stores the request id and the actor of every request in its context, where repositories can find them.
+// Requests without an id are given a random one, which is sent back in the response
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := headerValue(c, HeaderRequestID)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		ctx := WithRequestID(c.Request.Context(), requestID)
+		if actor := headerValue(c, HeaderActor); actor != "" {
+			ctx = WithActor(ctx, actor)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// WithRequestID returns copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request id ctx carries, or empty string for work not started by a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithActor returns copy of ctx carrying the actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor ctx carries, or AnonymousActor
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+func headerValue(c *gin.Context, header string) string {
+	value := strings.TrimSpace(c.GetHeader(header))
+	if len(value) > maxHeaderLength {
+		value = value[:maxHeaderLength]
+	}
+	return value
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -7,6 +7,7 @@ import (
 type genericResponse struct {
 	DataField interface{} `json:"data,omitempty"`
 	ErrorField interface{} `json:"error,omitempty"`
+	MetaField interface{} `json:"meta,omitempty"`
 }
 
 func Respond(c *gin.Context, status int, respData interface{}, respError interface{}) {
@@ -15,3 +16,11 @@ func Respond(c *gin.Context, status int, respData interface{}, respError interfa
 		ErrorField: respError,
 	})
 }
+
+// RespondWithMeta works similar to Respond, except it attaches meta (e.g. pagination) to the data
+func RespondWithMeta(c *gin.Context, status int, respData interface{}, respMeta interface{}) {
+	c.JSON(status, genericResponse{
+		DataField: respData,
+		MetaField: respMeta,
+	})
+}
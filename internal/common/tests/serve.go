@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Serve runs handler against a request built from method, url, params and body and returns the recorder. Body of
+// type string is sent as it is, other bodies are marshalled to JSON
+func Serve(handler gin.HandlerFunc, method string, url string, params gin.Params, body interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+
+	var jsonRequest []byte = nil
+	if raw, ok := body.(string); ok {
+		jsonRequest = []byte(raw)
+	} else if body != nil {
+		jsonRequest, _ = json.Marshal(body)
+	}
+
+	req, _ := http.NewRequest(method, url, bytes.NewReader(jsonRequest))
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = params
+
+	handler(c)
+
+	return w
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/movie/http/errors"
+	"github.com/foxfurry/simple-rest/internal/movie/http/validators"
+	"log"
+)
+
+type MovieDBRepository struct {
+	database *sql.DB
+}
+
+func NewMovieRepo(db *sql.DB) MovieDBRepository {
+	return MovieDBRepository{database: db}
+}
+
+var _ repository.MovieRepository = &MovieDBRepository{}
+
+const (
+	QuerySaveMovie               = `INSERT INTO movies (title, director, year, runtime, rating, description) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	QueryGetMovie                = `SELECT id, title, director, year, runtime, rating, description FROM movies WHERE id=$1`
+	QueryGetAllMovies            = `SELECT id, title, director, year, runtime, rating, description FROM movies`
+	QuerySearchByDirectorMovie   = `SELECT id, title, director, year, runtime, rating, description FROM movies WHERE director=$1`
+	QuerySearchByTitleMovie      = `SELECT id, title, director, year, runtime, rating, description FROM movies WHERE title=$1`
+	QuerySearchByKeywordMovie    = `SELECT id, title, director, year, runtime, rating, description FROM movies WHERE title ILIKE $1 OR director ILIKE $1 OR description ILIKE $1 ORDER BY id LIMIT $2`
+	QueryUpdateMovie             = `UPDATE movies SET title=$2, director=$3, year=$4, runtime=$5, rating=$6, description=$7 WHERE id=$1`
+	QueryDeleteMovie             = `DELETE FROM movies WHERE id=$1`
+	QueryDeleteAllMoviesAndAlter = `DELETE FROM movies; ALTER SEQUENCE movies_id_seq RESTART WITH 1`
+)
+
+// scanMovies reads all the rows into a slice of movies. Rows which could not be scanned are skipped
+func scanMovies(rows *sql.Rows) []entity.Movie {
+	var movies []entity.Movie
+
+	for rows.Next() {
+		var tempMovie entity.Movie
+
+		err := rows.Scan(&tempMovie.ID, &tempMovie.Title, &tempMovie.Director, &tempMovie.Year, &tempMovie.Runtime, &tempMovie.Rating, &tempMovie.Description)
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		movies = append(movies, tempMovie)
+	}
+
+	return movies
+}
+
+func (r *MovieDBRepository) SaveMovie(movie *entity.Movie) (*entity.Movie, error) {
+	var movieID uint64
+
+	err := r.database.QueryRow(QuerySaveMovie, movie.Title, movie.Director, movie.Year, movie.Runtime, movie.Rating, movie.Description).Scan(&movieID)
+
+	if err != nil {
+		log.Printf("Unable to save movie to db: %v", err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	returnMovie := *movie
+	returnMovie.ID = movieID
+	return &returnMovie, nil
+}
+
+func (r *MovieDBRepository) GetMovie(movieID uint64) (*entity.Movie, error) {
+	if movieID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMovieInvalidSerial()
+	}
+	var movie entity.Movie
+
+	row := r.database.QueryRow(QueryGetMovie, movieID)
+
+	err := row.Scan(&movie.ID, &movie.Title, &movie.Director, &movie.Year, &movie.Runtime, &movie.Rating, &movie.Description)
+
+	if err == sql.ErrNoRows {
+		log.Printf("Movie id#%v not found", movieID)
+		return nil, errors.NewMoviesNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	return &movie, nil
+}
+
+func (r *MovieDBRepository) GetAllMovies() ([]entity.Movie, error) {
+	rows, err := r.database.Query(QueryGetAllMovies)
+	if err != nil {
+		log.Printf("Unable to get all movies: %v", err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	movies := scanMovies(rows)
+
+	if len(movies) == 0 {
+		log.Printf("Could not get all the movies\n")
+		return nil, errors.NewMoviesNotFound()
+	}
+
+	return movies, nil
+}
+
+func (r *MovieDBRepository) SearchByDirector(director string) ([]entity.Movie, error) {
+	if director == "" {
+		log.Printf("Director field is empty")
+		return nil, errors.NewMovieValidatorError([]ct.FieldError{validators.FieldDirectorEmpty})
+	}
+
+	rows, err := r.database.Query(QuerySearchByDirectorMovie, director)
+	if err != nil {
+		log.Printf("Could not get all movies with director %v: %v", director, err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	movies := scanMovies(rows)
+
+	if len(movies) == 0 {
+		log.Printf("Could not get all the movies by director: %v\n", director)
+		return nil, errors.NewMovieNotFoundByDirector(director)
+	}
+
+	return movies, nil
+}
+
+func (r *MovieDBRepository) SearchByTitle(title string) ([]entity.Movie, error) {
+	if title == "" {
+		log.Printf("Title field is empty")
+		return nil, errors.NewMovieValidatorError([]ct.FieldError{validators.FieldTitleEmpty})
+	}
+
+	rows, err := r.database.Query(QuerySearchByTitleMovie, title)
+	if err != nil {
+		log.Printf("Could not get all movies with title %v: %v", title, err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	movies := scanMovies(rows)
+
+	if len(movies) == 0 {
+		log.Printf("Movie title#%v not found", title)
+		return nil, errors.NewMovieNotFoundByTitle(title)
+	}
+
+	return movies, nil
+}
+
+// SearchByKeyword returns movies which contain keyword in title, director or description. Empty result is not an error
+func (r *MovieDBRepository) SearchByKeyword(keyword string, limit int) ([]entity.Movie, error) {
+	rows, err := r.database.Query(QuerySearchByKeywordMovie, database.ContainsPattern(keyword), limit)
+	if err != nil {
+		log.Printf("Could not search movies by keyword %v: %v", keyword, err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	movies := scanMovies(rows)
+	if movies == nil {
+		movies = []entity.Movie{}
+	}
+
+	return movies, nil
+}
+
+func (r *MovieDBRepository) UpdateMovie(movieID uint64, movie *entity.Movie) (*entity.Movie, error) {
+	if movieID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMovieInvalidSerial()
+	}
+
+	res, err := r.database.Exec(QueryUpdateMovie, movieID, movie.Title, movie.Director, movie.Year, movie.Runtime, movie.Rating, movie.Description)
+	if err != nil {
+		log.Printf("Unable to update movie: %v", err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows movie: %v", err)
+		return nil, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return nil, errors.NewMoviesNotFound()
+	}
+
+	returnMovie := *movie
+	returnMovie.ID = movieID
+	return &returnMovie, nil
+}
+
+func (r *MovieDBRepository) DeleteMovie(movieID uint64) (int64, error) {
+	if movieID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewMovieInvalidSerial()
+	}
+
+	res, err := r.database.Exec(QueryDeleteMovie, movieID)
+	if err != nil {
+		log.Printf("Unable to delete movie: %v", err)
+		return 0, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows movie: %v", err)
+		return 0, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewMoviesNotFound()
+	}
+
+	log.Printf("Deleted rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
+
+func (r *MovieDBRepository) DeleteAllMovies() (int64, error) {
+	res, err := r.database.Exec(QueryDeleteAllMoviesAndAlter)
+	if err != nil {
+		log.Printf("Unable to delete movies or alter the sequence: %v", err)
+		return 0, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows movie: %v", err)
+		return 0, errors.NewMovieCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewMoviesNotFound()
+	}
+
+	log.Printf("Rows affected: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
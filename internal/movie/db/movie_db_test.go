@@ -0,0 +1,510 @@
+package db
+
+import (
+	"database/sql"
+	goerrors "errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/movie/http/errors"
+	"github.com/foxfurry/simple-rest/internal/movie/http/validators"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"regexp"
+	"testing"
+)
+
+var movieColumns = []string{"id", "title", "director", "year", "runtime", "rating", "description"}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestMovieDBRepository_SaveMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	saveMovieMocks := []struct {
+		testName       string
+		input          entity.Movie
+		expectedOutput *entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input: entity.Movie{
+				Title:       "Stalker",
+				Director:    "Andrei Tarkovsky",
+				Year:        1979,
+				Runtime:     161,
+				Rating:      8.1,
+				Description: "test description",
+			},
+			expectedOutput: &entity.Movie{
+				ID:          1,
+				Title:       "Stalker",
+				Director:    "Andrei Tarkovsky",
+				Year:        1979,
+				Runtime:     161,
+				Rating:      8.1,
+				Description: "test description",
+			},
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveMovie)).WithArgs("Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "test description").WillReturnRows(rows)
+			},
+		},
+		{
+			testName: "Test Unsuccessful: No id returned",
+			input: entity.Movie{
+				Title:    "Stalker",
+				Director: "Andrei Tarkovsky",
+				Year:     1979,
+				Runtime:  161,
+			},
+			expectedError: errors.NewMovieCouldNotQuery("sql: no rows in result set"),
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"id"})
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveMovie)).WillReturnRows(rows)
+			},
+		},
+	}
+
+	for _, tc := range saveMovieMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.SaveMovie(&tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_GetMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	getMovieMocks := []struct {
+		testName       string
+		input          uint64
+		expectedOutput *entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    1,
+			expectedOutput: &entity.Movie{
+				ID:       1,
+				Title:    "Stalker",
+				Director: "Andrei Tarkovsky",
+				Year:     1979,
+				Runtime:  161,
+				Rating:   8.1,
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(movieColumns).AddRow(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetMovie)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         2,
+			expectedError: errors.NewMoviesNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetMovie)).WithArgs(2).WillReturnRows(mock.NewRows(movieColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			input:         0,
+			expectedError: errors.NewMovieInvalidSerial(),
+		},
+	}
+
+	for _, tc := range getMovieMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.GetMovie(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_GetAllMovies(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	getAllMoviesMocks := []struct {
+		testName       string
+		expectedOutput []entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful: Row 2 unparsed",
+			expectedOutput: []entity.Movie{
+				{ID: 1, Title: "test title 1", Director: "test director 1", Year: 1901, Runtime: 1},
+				{ID: 3, Title: "test title 3", Director: "test director 3", Year: 1903, Runtime: 3},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(movieColumns).
+					AddRow(1, "test title 1", "test director 1", 1901, 1, 0, "").
+					AddRow(2, "test title 2", "test director 2", "error", 2, 0, "").
+					AddRow(3, "test title 3", "test director 3", 1903, 3, 0, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAllMovies)).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Movies not found",
+			expectedError: errors.NewMoviesNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAllMovies)).WillReturnRows(mock.NewRows(movieColumns))
+			},
+		},
+		{ // DO NOT ADD ANY TC AFTER THIS ONE. IN THIS TC DB IS BEING CLOSED AND NOT REOPENED FOR REST OF TEST
+			testName:      "Test Unsuccessful: DB is closed",
+			expectedError: errors.NewMovieCouldNotQuery("sql: database is closed"),
+			mockFunc: func() {
+				db.Close()
+			},
+		},
+	}
+
+	for _, tc := range getAllMoviesMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.GetAllMovies()
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_SearchByDirector(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	searchByDirectorMocks := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    "Andrei Tarkovsky",
+			expectedOutput: []entity.Movie{
+				{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161},
+				{ID: 2, Title: "Solaris", Director: "Andrei Tarkovsky", Year: 1972, Runtime: 167},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(movieColumns).
+					AddRow(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 0, "").
+					AddRow(2, "Solaris", "Andrei Tarkovsky", 1972, 167, 0, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByDirectorMovie)).WithArgs("Andrei Tarkovsky").WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         "Andrei Tarkovsky",
+			expectedError: errors.NewMovieNotFoundByDirector("Andrei Tarkovsky"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByDirectorMovie)).WithArgs("Andrei Tarkovsky").WillReturnRows(mock.NewRows(movieColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Empty director",
+			input:         "",
+			expectedError: errors.NewMovieValidatorError([]ct.FieldError{validators.FieldDirectorEmpty}),
+		},
+	}
+
+	for _, tc := range searchByDirectorMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.SearchByDirector(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_SearchByTitle(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	searchByTitleMocks := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful: Remake",
+			input:    "Solaris",
+			expectedOutput: []entity.Movie{
+				{ID: 1, Title: "Solaris", Director: "Andrei Tarkovsky", Year: 1972, Runtime: 167},
+				{ID: 2, Title: "Solaris", Director: "Steven Soderbergh", Year: 2002, Runtime: 99},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(movieColumns).
+					AddRow(1, "Solaris", "Andrei Tarkovsky", 1972, 167, 0, "").
+					AddRow(2, "Solaris", "Steven Soderbergh", 2002, 99, 0, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByTitleMovie)).WithArgs("Solaris").WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         "Solaris",
+			expectedError: errors.NewMovieNotFoundByTitle("Solaris"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByTitleMovie)).WithArgs("Solaris").WillReturnRows(mock.NewRows(movieColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Empty title",
+			input:         "",
+			expectedError: errors.NewMovieValidatorError([]ct.FieldError{validators.FieldTitleEmpty}),
+		},
+	}
+
+	for _, tc := range searchByTitleMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.SearchByTitle(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_UpdateMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	input := entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1}
+
+	updateMovieMocks := []struct {
+		testName       string
+		inputID        uint64
+		expectedOutput *entity.Movie
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			inputID:        1,
+			expectedOutput: &entity.Movie{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryUpdateMovie)).WithArgs(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			inputID:       2,
+			expectedError: errors.NewMoviesNotFound(),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryUpdateMovie)).WithArgs(2, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			inputID:       0,
+			expectedError: errors.NewMovieInvalidSerial(),
+		},
+	}
+
+	for _, tc := range updateMovieMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.UpdateMovie(tc.inputID, &input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_DeleteMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	deleteMovieMocks := []struct {
+		testName       string
+		input          uint64
+		expectedOutput int64
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          1,
+			expectedOutput: 1,
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteMovie)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         2,
+			expectedError: errors.NewMoviesNotFound(),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteMovie)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			input:         0,
+			expectedError: errors.NewMovieInvalidSerial(),
+		},
+	}
+
+	for _, tc := range deleteMovieMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.DeleteMovie(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_DeleteAllMovies(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	deleteAllMoviesMocks := []struct {
+		testName       string
+		expectedOutput int64
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			expectedOutput: 4,
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllMoviesAndAlter)).WillReturnResult(sqlmock.NewResult(0, 4))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid rows affected",
+			expectedError: errors.NewMovieCouldNotQuery("no RowsAffected available after DDL statement"),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllMoviesAndAlter)).WillReturnResult(sqlmock.NewErrorResult(goerrors.New("no RowsAffected available after DDL statement")))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Movies not found",
+			expectedError: errors.NewMoviesNotFound(),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAllMoviesAndAlter)).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, tc := range deleteAllMoviesMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.DeleteAllMovies()
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMovieDBRepository_SearchByKeyword(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMovieRepo(db)
+
+	searchByKeywordMocks := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Movie
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    "tarkovsky",
+			expectedOutput: []entity.Movie{
+				{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(movieColumns).AddRow(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 0, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordMovie)).WithArgs("%tarkovsky%", 10).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:       "Test Successful: Nothing found",
+			input:          "kubrick",
+			expectedOutput: []entity.Movie{},
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordMovie)).WithArgs("%kubrick%", 10).WillReturnRows(mock.NewRows(movieColumns))
+			},
+		},
+	}
+
+	for _, tc := range searchByKeywordMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.SearchByKeyword(tc.input, 10)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
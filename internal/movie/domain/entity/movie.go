@@ -0,0 +1,37 @@
+package entity
+
+type Movie struct {
+	ID          uint64  `json:"id,omitempty" binding:"omitempty,numeric,validID"`
+	Title       string  `json:"title" binding:"required"`
+	Director    string  `json:"director" binding:"required"`
+	Year        int     `json:"year" binding:"required,validMovieYear"`
+	Runtime     int     `json:"runtime" binding:"required,validRuntime"` // Runtime in minutes
+	Rating      float64 `json:"rating,omitempty" binding:"omitempty,validRating"`
+	Description string  `json:"description,omitempty"`
+}
+
+// Equal returns true if all fields in receiver are same as in parameter
+func (lhs Movie) Equal(rhs Movie) bool {
+	return rhs == lhs
+}
+
+// EqualNoID works similar to Equal, except it ignores ID
+func (lhs Movie) EqualNoID(rhs Movie) bool {
+	rhs.ID = lhs.ID
+	return rhs == lhs
+}
+
+// MovieArrayEqualNoID compares two arrays of Movie(s) using Movie.EqualNoID on each element
+func MovieArrayEqualNoID(lhs []Movie, rhs []Movie) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for idx := range lhs {
+		if !lhs[idx].EqualNoID(rhs[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
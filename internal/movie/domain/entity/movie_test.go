@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/movie/http/validators"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func init() {
+	validators.RegisterMovieValidators()
+}
+
+func TestMovie_Equal(t *testing.T) {
+	base := Movie{
+		ID:          1,
+		Title:       "1",
+		Director:    "1",
+		Year:        1,
+		Runtime:     1,
+		Rating:      1,
+		Description: "1",
+	}
+
+	same := base
+	assert.True(t, base.Equal(same))
+
+	testCasesDifferent := []Movie{
+		{ID: 2, Title: "1", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "1"},
+		{ID: 1, Title: "2", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "1"},
+		{ID: 1, Title: "1", Director: "2", Year: 1, Runtime: 1, Rating: 1, Description: "1"},
+		{ID: 1, Title: "1", Director: "1", Year: 2, Runtime: 1, Rating: 1, Description: "1"},
+		{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 2, Rating: 1, Description: "1"},
+		{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 1, Rating: 2, Description: "1"},
+		{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "2"},
+	}
+
+	for _, tc := range testCasesDifferent {
+		assert.True(t, !base.Equal(tc), "Movies expected to be different: %v %v", base, tc)
+	}
+}
+
+func TestMovie_EqualNoID(t *testing.T) {
+	base := Movie{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "1"}
+
+	assert.True(t, base.EqualNoID(Movie{ID: 2, Title: "1", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "1"}))
+	assert.True(t, !base.EqualNoID(Movie{ID: 1, Title: "2", Director: "1", Year: 1, Runtime: 1, Rating: 1, Description: "1"}))
+}
+
+func TestMovieArrayEqualNoID(t *testing.T) {
+	lhs := []Movie{
+		{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 1},
+		{ID: 2, Title: "2", Director: "2", Year: 2, Runtime: 2},
+	}
+	rhsEqual := []Movie{
+		{ID: 3, Title: "1", Director: "1", Year: 1, Runtime: 1},
+		{ID: 4, Title: "2", Director: "2", Year: 2, Runtime: 2},
+	}
+	rhsDifferent := []Movie{
+		{ID: 1, Title: "1", Director: "1", Year: 1, Runtime: 1},
+		{ID: 2, Title: "3", Director: "3", Year: 3, Runtime: 3},
+	}
+
+	assert.True(t, MovieArrayEqualNoID(lhs, rhsEqual))
+	assert.True(t, !MovieArrayEqualNoID(lhs, rhsDifferent))
+	assert.True(t, !MovieArrayEqualNoID(lhs, rhsEqual[:1]))
+}
+
+func TestMovie_IsValid(t *testing.T) {
+	testCasesValid := []Movie{
+		{
+			Title:    "Stalker",
+			Director: "Andrei Tarkovsky",
+			Year:     1979,
+			Runtime:  161,
+			Rating:   8.1,
+		},
+		{
+			Title:       "Roundhay Garden Scene",
+			Director:    "Louis Le Prince",
+			Year:        1888,
+			Runtime:     1,
+			Description: "Oldest surviving film",
+		},
+	}
+	testCasesInvalid := []Movie{
+		{
+			Director: "Andrei Tarkovsky",
+			Year:     1979,
+			Runtime:  161,
+		},
+		{
+			Title:   "Stalker",
+			Year:    1979,
+			Runtime: 161,
+		},
+		{
+			Title:    "Stalker",
+			Director: "Andrei Tarkovsky",
+			Year:     1700,
+			Runtime:  161,
+		},
+		{
+			Title:    "Stalker",
+			Director: "Andrei Tarkovsky",
+			Year:     1979,
+			Runtime:  -5,
+		},
+		{
+			Title:    "Stalker",
+			Director: "Andrei Tarkovsky",
+			Year:     1979,
+			Runtime:  161,
+			Rating:   11,
+		},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Movie expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Movie expected to be invalid, but found valid: %v", tc)
+	}
+}
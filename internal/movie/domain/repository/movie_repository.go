@@ -0,0 +1,15 @@
+package repository
+
+import "github.com/foxfurry/simple-rest/internal/movie/domain/entity"
+
+type MovieRepository interface {
+	SaveMovie(*entity.Movie) (*entity.Movie, error)
+	GetMovie(uint64) (*entity.Movie, error)
+	GetAllMovies() ([]entity.Movie, error)
+	SearchByDirector(string) ([]entity.Movie, error)     // A director can have multiple movies
+	SearchByTitle(string) ([]entity.Movie, error)        // Remakes share the title with the original
+	SearchByKeyword(string, int) ([]entity.Movie, error) // Up to limit movies with keyword in title, director or description
+	UpdateMovie(uint64, *entity.Movie) (*entity.Movie, error)
+	DeleteMovie(uint64) (int64, error)
+	DeleteAllMovies() (int64, error)
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	movieDB "github.com/foxfurry/simple-rest/internal/movie/db"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/movie/http/errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type MovieService struct {
+	dbRepo movieDB.MovieDBRepository
+}
+
+func NewMovieService(db *sql.DB) MovieService {
+	return MovieService{
+		dbRepo: movieDB.NewMovieRepo(db),
+	}
+}
+
+// bindMovie reads the request body into movie and responds with an error if the body is missing or invalid
+func bindMovie(c *gin.Context, movie *entity.Movie) bool {
+	if err := c.ShouldBindJSON(movie); err != nil {
+		if err == io.EOF {
+			errors.HandleMovieError(c, errors.NewMovieEmptyBody())
+		} else {
+			errors.HandleMovieError(c, errors.NewMovieValidatorError(common_translators.Translate(err)))
+		}
+		return false
+	}
+	return true
+}
+
+func (m *MovieService) SaveMovie(c *gin.Context) {
+	var movie entity.Movie
+
+	if !bindMovie(c, &movie) {
+		return
+	}
+
+	saveMovie, err := m.dbRepo.SaveMovie(&movie)
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, saveMovie, nil)
+}
+
+func (m *MovieService) GetMovie(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleMovieError(c, errors.NewMovieInvalidSerial())
+		return
+	}
+
+	getMovie, err := m.dbRepo.GetMovie(uint64(id))
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, getMovie, nil)
+}
+
+func (m *MovieService) GetAllMovies(c *gin.Context) {
+	allMovies, err := m.dbRepo.GetAllMovies()
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, allMovies, nil)
+}
+
+func (m *MovieService) SearchByDirector(c *gin.Context) {
+	moviesByDirector, err := m.dbRepo.SearchByDirector(c.Param("director"))
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, moviesByDirector, nil)
+}
+
+func (m *MovieService) SearchByTitle(c *gin.Context) {
+	moviesByTitle, err := m.dbRepo.SearchByTitle(c.Param("title"))
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, moviesByTitle, nil)
+}
+
+func (m *MovieService) UpdateMovie(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleMovieError(c, errors.NewMovieInvalidSerial())
+		return
+	}
+
+	var movie entity.Movie
+
+	if !bindMovie(c, &movie) {
+		return
+	}
+
+	updatedMovie, err := m.dbRepo.UpdateMovie(uint64(id), &movie)
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, updatedMovie, nil)
+}
+
+func (m *MovieService) DeleteMovie(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleMovieError(c, errors.NewMovieInvalidSerial())
+		return
+	}
+
+	_, err = m.dbRepo.DeleteMovie(uint64(id))
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, nil, nil)
+}
+
+func (m *MovieService) DeleteAllMovies(c *gin.Context) {
+	deletedRows, err := m.dbRepo.DeleteAllMovies()
+	if err != nil {
+		errors.HandleMovieError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, deletedRows, nil)
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/common/tests"
+	moviedb "github.com/foxfurry/simple-rest/internal/movie/db"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/movie/http/errors"
+	"github.com/foxfurry/simple-rest/internal/movie/http/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type singleResponse struct {
+	Data  *entity.Movie  `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type arrayResponse struct {
+	Data  []entity.Movie `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+var movieColumns = []string{"id", "title", "director", "year", "runtime", "rating", "description"}
+
+func init() {
+	validators.RegisterMovieValidators()
+}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestMovieService_SaveMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMovieService(db)
+
+	saveMovieServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		requestBody    *entity.Movie
+		expectedStatus int
+		expectedBody   *entity.Movie
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(moviedb.QuerySaveMovie)).WithArgs("Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "").WillReturnRows(rows)
+			},
+			requestBody:    &entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Movie{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty director",
+			requestBody:    &entity.Movie{Title: "Stalker", Year: 1979, Runtime: 161},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldDirectorEmpty},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid rating",
+			requestBody:    &entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 12},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldRatingInvalid},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty request body",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewMovieEmptyBody().Error(),
+			},
+		},
+	}
+
+	for _, tc := range saveMovieServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			var body interface{}
+			if tc.requestBody != nil {
+				body = tc.requestBody
+			}
+
+			w := tests.Serve(service.SaveMovie, http.MethodPost, "/movie", nil, body)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMovieService_GetMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMovieService(db)
+
+	getMovieServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		id             string
+		expectedStatus int
+		expectedBody   *entity.Movie
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows(movieColumns).AddRow(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "")
+				mock.ExpectQuery(regexp.QuoteMeta(moviedb.QueryGetMovie)).WithArgs(1).WillReturnRows(rows)
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Movie{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+		},
+		{
+			testName: "Test Unsuccessful: Not found",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(moviedb.QueryGetMovie)).WithArgs(2).WillReturnRows(sqlmock.NewRows(movieColumns))
+			},
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewMoviesNotFound().Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewMovieInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range getMovieServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.GetMovie, http.MethodGet, "/movie/"+tc.id, gin.Params{{Key: "id", Value: tc.id}}, nil)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMovieService_SearchByDirector(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMovieService(db)
+
+	searchByDirectorServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		director       string
+		expectedStatus int
+		expectedBody   []entity.Movie
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows(movieColumns).
+					AddRow(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "").
+					AddRow(2, "Solaris", "Andrei Tarkovsky", 1972, 167, 8, "")
+				mock.ExpectQuery(regexp.QuoteMeta(moviedb.QuerySearchByDirectorMovie)).WithArgs("Andrei Tarkovsky").WillReturnRows(rows)
+			},
+			director:       "Andrei Tarkovsky",
+			expectedStatus: http.StatusOK,
+			expectedBody: []entity.Movie{
+				{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+				{ID: 2, Title: "Solaris", Director: "Andrei Tarkovsky", Year: 1972, Runtime: 167, Rating: 8},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty director",
+			director:       "",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldDirectorEmpty},
+			},
+		},
+	}
+
+	for _, tc := range searchByDirectorServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.SearchByDirector, http.MethodGet, "/movie/director/"+tc.director, gin.Params{{Key: "director", Value: tc.director}}, nil)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := arrayResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMovieService_UpdateMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMovieService(db)
+
+	updateMovieServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		id             string
+		requestBody    *entity.Movie
+		expectedStatus int
+		expectedBody   *entity.Movie
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(moviedb.QueryUpdateMovie)).WithArgs(1, "Stalker", "Andrei Tarkovsky", 1979, 161, 8.1, "").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			id:             "1",
+			requestBody:    &entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Movie{ID: 1, Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161, Rating: 8.1},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid year",
+			id:             "1",
+			requestBody:    &entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1500, Runtime: 161},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldYearInvalid},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			id:             "abc",
+			requestBody:    &entity.Movie{Title: "Stalker", Director: "Andrei Tarkovsky", Year: 1979, Runtime: 161},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewMovieInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range updateMovieServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.UpdateMovie, http.MethodPut, "/movie/"+tc.id, gin.Params{{Key: "id", Value: tc.id}}, tc.requestBody)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMovieService_DeleteMovie(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMovieService(db)
+
+	deleteMovieServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		id             string
+		expectedStatus int
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(moviedb.QueryDeleteMovie)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "Test Unsuccessful: Not found",
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(moviedb.QueryDeleteMovie)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewMoviesNotFound().Error(),
+			},
+		},
+	}
+
+	for _, tc := range deleteMovieServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.DeleteMovie, http.MethodDelete, "/movie/"+tc.id, gin.Params{{Key: "id", Value: tc.id}}, nil)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := singleResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type movieNotFoundByTitle struct {
+	common_errors.CommonError
+}
+
+type movieNotFoundByDirector struct {
+	common_errors.CommonError
+}
+
+type moviesNotFound struct {
+	common_errors.CommonError
+}
+
+type movieCouldNotQuery struct {
+	common_errors.CommonError
+}
+
+type movieInvalidSerial struct {
+	common_errors.CommonError
+}
+
+type movieUnexpectedError struct {
+	common_errors.CommonError
+}
+
+type movieEmptyBody struct {
+	common_errors.CommonError
+}
+
+type movieValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewMovieNotFoundByTitle(title string) movieNotFoundByTitle {
+	return movieNotFoundByTitle{
+		common_errors.CommonError{Msg: fmt.Sprintf("Movie(s) with title %v not found in db", title)},
+	}
+}
+
+func NewMovieNotFoundByDirector(director string) movieNotFoundByDirector {
+	return movieNotFoundByDirector{
+		common_errors.CommonError{Msg: fmt.Sprintf("Movie(s) with director %v not found in db", director)},
+	}
+}
+
+func NewMoviesNotFound() moviesNotFound {
+	return moviesNotFound{
+		common_errors.CommonError{Msg: "Movie(s) not found in db"},
+	}
+}
+
+func NewMovieCouldNotQuery(msg string) movieCouldNotQuery {
+	return movieCouldNotQuery{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not execute query: %v", msg)},
+	}
+}
+
+func NewMovieInvalidSerial() movieInvalidSerial {
+	return movieInvalidSerial{
+		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
+	}
+}
+
+func NewMovieUnexpectedError(msg string) movieUnexpectedError {
+	return movieUnexpectedError{
+		common_errors.CommonError{Msg: fmt.Sprintf("Unexpected error: %v", msg)},
+	}
+}
+
+func NewMovieEmptyBody() movieEmptyBody {
+	return movieEmptyBody{
+		common_errors.CommonError{Msg: "Expected body, found EOF"},
+	}
+}
+
+func NewMovieValidatorError(fields []validator.FieldError) movieValidatorError {
+	return movieValidatorError{Fields: fields}
+}
+
+func (m movieValidatorError) Error() string {
+	var res = ""
+	for _, f := range m.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+func HandleMovieError(c *gin.Context, err error) {
+	switch err.(type) {
+	case moviesNotFound, movieNotFoundByDirector, movieNotFoundByTitle:
+		common_errors.RespondNotFound(c, err)
+	case movieValidatorError, movieInvalidSerial, movieEmptyBody:
+		common_errors.RespondBadRequest(c, err)
+	case movieUnexpectedError, movieCouldNotQuery:
+		common_errors.RespondInternalError(c, err)
+	default:
+		common_errors.RespondInternalError(c, err)
+	}
+}
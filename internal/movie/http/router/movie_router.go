@@ -0,0 +1,42 @@
+package router
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/movie/http/controllers"
+	"github.com/foxfurry/simple-rest/internal/movie/http/validators"
+	"github.com/gin-gonic/gin"
+)
+
+var moviePolicy = common_auth.Policy{
+	"POST /movie/":      common_auth.PermWrite,
+	"PUT /movie/:id":    common_auth.PermWrite,
+	"DELETE /movie/:id": common_auth.PermWrite,
+	"DELETE /movie/":    common_auth.PermDeleteAll,
+}
+
+func RegisterMovieRoutes(router *gin.Engine, db *sql.DB, guard *common_auth.Guard) {
+	movieRepo := controllers.NewMovieService(db)
+
+	movie := router.Group("/movie", guard.Enforce(moviePolicy))
+	{
+		movie.GET("/:id", movieRepo.GetMovie)
+
+		movie.GET("/title/:title", movieRepo.SearchByTitle)
+		movie.GET("/title/", movieRepo.SearchByTitle)
+
+		movie.GET("/director/:director", movieRepo.SearchByDirector)
+		movie.GET("/director/", movieRepo.SearchByDirector)
+
+		movie.GET("/", movieRepo.GetAllMovies)
+
+		movie.POST("/", movieRepo.SaveMovie)
+
+		movie.PUT("/:id", movieRepo.UpdateMovie)
+
+		movie.DELETE("/:id", movieRepo.DeleteMovie)
+		movie.DELETE("/", movieRepo.DeleteAllMovies)
+	}
+
+	validators.RegisterMovieValidators()
+}
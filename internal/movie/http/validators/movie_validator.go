@@ -0,0 +1,120 @@
+package validators
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"time"
+)
+
+const (
+	idTag         = "validID"
+	yearTag       = "validMovieYear"
+	runtimeTag    = "validRuntime"
+	ratingTag     = "validRating"
+	requiredTag   = "required"
+	emptyFieldMsg = "cannot be empty"
+
+	firstMovieYear = 1888 // Roundhay Garden Scene
+	maxRating      = 10
+)
+
+var (
+	invalidYearMsg = fmt.Sprintf("Year should be between %v and %v", firstMovieYear, time.Now().Year())
+
+	FieldTitleEmpty = common_translators.FieldError{
+		Field: "Title",
+		Msg:   "Title " + emptyFieldMsg,
+	}
+	FieldDirectorEmpty = common_translators.FieldError{
+		Field: "Director",
+		Msg:   "Director " + emptyFieldMsg,
+	}
+	FieldYearEmpty = common_translators.FieldError{
+		Field: "Year",
+		Msg:   "Year " + emptyFieldMsg,
+	}
+	FieldYearInvalid = common_translators.FieldError{
+		Field: "Year",
+		Msg:   invalidYearMsg,
+	}
+	FieldRuntimeInvalid = common_translators.FieldError{
+		Field: "Runtime",
+		Msg:   "Runtime should be positive number of minutes",
+	}
+	FieldRatingInvalid = common_translators.FieldError{
+		Field: "Rating",
+		Msg:   fmt.Sprintf("Rating should be between 0 and %v", maxRating),
+	}
+)
+
+var validID validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() >= 1
+}
+
+var validYear validator.Func = func(fl validator.FieldLevel) bool {
+	year := fl.Field().Int()
+	return year >= firstMovieYear && year <= int64(time.Now().Year())
+}
+
+var validRuntime validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() > 0
+}
+
+var validRating validator.Func = func(fl validator.FieldLevel) bool {
+	rating := fl.Field().Float()
+	return rating >= 0 && rating <= maxRating
+}
+
+var trslValidYear validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(yearTag, invalidYearMsg, true)
+}
+
+var trslValidRuntime validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(runtimeTag, FieldRuntimeInvalid.Msg, true)
+}
+
+var trslValidRating validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(ratingTag, FieldRatingInvalid.Msg, true)
+}
+
+var trslValidID validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(idTag, "{0} should be positive non-null number", true)
+}
+
+var requiredMessage validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(requiredTag, "{0} "+emptyFieldMsg, true)
+}
+
+// translateTag returns a translation function which renders the message registered for tag
+func translateTag(tag string) validator.TranslationFunc {
+	return func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	}
+}
+
+func RegisterMovieValidators() {
+	errTranslator := common_translators.GetTranslator()
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation(yearTag, validYear)
+		v.RegisterTranslation(yearTag, errTranslator, trslValidYear, translateTag(yearTag))
+
+		v.RegisterValidation(runtimeTag, validRuntime)
+		v.RegisterTranslation(runtimeTag, errTranslator, trslValidRuntime, translateTag(runtimeTag))
+
+		v.RegisterValidation(ratingTag, validRating)
+		v.RegisterTranslation(ratingTag, errTranslator, trslValidRating, translateTag(ratingTag))
+
+		v.RegisterValidation(idTag, validID)
+		v.RegisterTranslation(idTag, errTranslator, trslValidID, translateTag(idTag))
+
+		v.RegisterTranslation(requiredTag, errTranslator, requiredMessage, translateTag(requiredTag))
+	} else {
+		log.Panicf("Could not register common_translators: %v", ok)
+	}
+}
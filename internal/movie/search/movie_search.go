@@ -0,0 +1,43 @@
+package search
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/movie/domain/repository"
+)
+
+const KindMovie = "movie"
+
+// MovieSearchProvider lets movies take part in the cross-media search
+type MovieSearchProvider struct {
+	repo repository.MovieRepository
+}
+
+func NewMovieSearchProvider(repo repository.MovieRepository) MovieSearchProvider {
+	return MovieSearchProvider{repo: repo}
+}
+
+var _ search.Provider = MovieSearchProvider{}
+
+func (p MovieSearchProvider) Kinds() []string {
+	return []string{KindMovie}
+}
+
+func (p MovieSearchProvider) Search(ctx context.Context, query string, limit int) ([]search.Hit, error) {
+	// Movie repository can not cancel its queries, so ctx is only checked before querying
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	movies, err := p.repo.SearchByKeyword(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, 0, len(movies))
+	for _, movie := range movies {
+		hits = append(hits, search.Hit{Kind: KindMovie, ID: movie.ID, Title: movie.Title, Item: movie})
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"github.com/foxfurry/simple-rest/internal/music/http/validators"
+	"log"
+)
+
+type MusicDBRepository struct {
+	database *sql.DB
+}
+
+func NewMusicRepo(db *sql.DB) MusicDBRepository {
+	return MusicDBRepository{database: db}
+}
+
+var _ repository.MusicRepository = &MusicDBRepository{}
+
+const (
+	QuerySaveAlbum            = `INSERT INTO albums (title, artist, year, genre) VALUES ($1, $2, $3, $4) RETURNING id`
+	QueryGetAlbum             = `SELECT id, title, artist, year, genre FROM albums WHERE id=$1`
+	QueryGetAllAlbums         = `SELECT id, title, artist, year, genre FROM albums`
+	QuerySearchByArtistAlbum  = `SELECT id, title, artist, year, genre FROM albums WHERE artist=$1`
+	QuerySearchByKeywordAlbum = `SELECT id, title, artist, year, genre FROM albums WHERE title ILIKE $1 OR artist ILIKE $1 OR genre ILIKE $1 ORDER BY id LIMIT $2`
+	QueryUpdateAlbum          = `UPDATE albums SET title=$2, artist=$3, year=$4, genre=$5 WHERE id=$1`
+	QueryDeleteAlbum          = `DELETE FROM albums WHERE id=$1`
+)
+
+// scanAlbums reads all the rows into a slice of albums. Rows which could not be scanned are skipped
+func scanAlbums(rows *sql.Rows) []entity.Album {
+	var albums []entity.Album
+
+	for rows.Next() {
+		var tempAlbum entity.Album
+
+		err := rows.Scan(&tempAlbum.ID, &tempAlbum.Title, &tempAlbum.Artist, &tempAlbum.Year, &tempAlbum.Genre)
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		albums = append(albums, tempAlbum)
+	}
+
+	return albums
+}
+
+func (r *MusicDBRepository) SaveAlbum(album *entity.Album) (*entity.Album, error) {
+	var albumID uint64
+
+	err := r.database.QueryRow(QuerySaveAlbum, album.Title, album.Artist, album.Year, album.Genre).Scan(&albumID)
+	if err != nil {
+		log.Printf("Unable to save album to db: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	returnAlbum := *album
+	returnAlbum.ID = albumID
+	return &returnAlbum, nil
+}
+
+func (r *MusicDBRepository) GetAlbum(albumID uint64) (*entity.Album, error) {
+	if albumID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+	var album entity.Album
+
+	row := r.database.QueryRow(QueryGetAlbum, albumID)
+
+	err := row.Scan(&album.ID, &album.Title, &album.Artist, &album.Year, &album.Genre)
+	if err == sql.ErrNoRows {
+		log.Printf("Album id#%v not found", albumID)
+		return nil, errors.NewAlbumsNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	return &album, nil
+}
+
+func (r *MusicDBRepository) GetAllAlbums() ([]entity.Album, error) {
+	rows, err := r.database.Query(QueryGetAllAlbums)
+	if err != nil {
+		log.Printf("Unable to get all albums: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	albums := scanAlbums(rows)
+
+	if len(albums) == 0 {
+		log.Printf("Could not get all the albums\n")
+		return nil, errors.NewAlbumsNotFound()
+	}
+
+	return albums, nil
+}
+
+func (r *MusicDBRepository) SearchByArtist(artist string) ([]entity.Album, error) {
+	if artist == "" {
+		log.Printf("Artist field is empty")
+		return nil, errors.NewMusicValidatorError([]ct.FieldError{validators.FieldArtistEmpty})
+	}
+
+	rows, err := r.database.Query(QuerySearchByArtistAlbum, artist)
+	if err != nil {
+		log.Printf("Could not get all albums with artist %v: %v", artist, err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	albums := scanAlbums(rows)
+
+	if len(albums) == 0 {
+		log.Printf("Could not get all the albums by artist: %v\n", artist)
+		return nil, errors.NewAlbumNotFoundByArtist(artist)
+	}
+
+	return albums, nil
+}
+
+// SearchAlbumsByKeyword returns albums which contain keyword in title, artist or genre. Empty result is not an error
+func (r *MusicDBRepository) SearchAlbumsByKeyword(keyword string, limit int) ([]entity.Album, error) {
+	rows, err := r.database.Query(QuerySearchByKeywordAlbum, database.ContainsPattern(keyword), limit)
+	if err != nil {
+		log.Printf("Could not search albums by keyword %v: %v", keyword, err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	albums := scanAlbums(rows)
+	if albums == nil {
+		albums = []entity.Album{}
+	}
+
+	return albums, nil
+}
+
+func (r *MusicDBRepository) UpdateAlbum(albumID uint64, album *entity.Album) (*entity.Album, error) {
+	if albumID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+
+	res, err := r.database.Exec(QueryUpdateAlbum, albumID, album.Title, album.Artist, album.Year, album.Genre)
+	if err != nil {
+		log.Printf("Unable to update album: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows album: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return nil, errors.NewAlbumsNotFound()
+	}
+
+	returnAlbum := *album
+	returnAlbum.ID = albumID
+	return &returnAlbum, nil
+}
+
+func (r *MusicDBRepository) DeleteAlbum(albumID uint64) (int64, error) {
+	if albumID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewMusicInvalidSerial()
+	}
+
+	res, err := r.database.Exec(QueryDeleteAlbum, albumID)
+	if err != nil {
+		log.Printf("Unable to delete album: %v", err)
+		return 0, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows album: %v", err)
+		return 0, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewAlbumsNotFound()
+	}
+
+	log.Printf("Deleted rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,443 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	_ "github.com/foxfurry/simple-rest/internal/common/tests"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"github.com/foxfurry/simple-rest/internal/music/http/validators"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"regexp"
+	"testing"
+)
+
+var (
+	albumColumns = []string{"id", "title", "artist", "year", "genre"}
+	trackColumns = []string{"id", "album_id", "number", "title", "duration"}
+)
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestMusicDBRepository_SaveAlbum(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	saveAlbumMocks := []struct {
+		testName       string
+		input          entity.Album
+		expectedOutput *entity.Album
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          entity.Album{Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+			expectedOutput: &entity.Album{ID: 1, Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveAlbum)).WithArgs("Abbey Road", "The Beatles", 1969, "Rock").WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: No id returned",
+			input:         entity.Album{Title: "Abbey Road", Artist: "The Beatles", Year: 1969},
+			expectedError: errors.NewMusicCouldNotQuery("sql: no rows in result set"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveAlbum)).WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+		},
+	}
+
+	for _, tc := range saveAlbumMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.SaveAlbum(&tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_GetAlbum(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	getAlbumMocks := []struct {
+		testName       string
+		input          uint64
+		expectedOutput *entity.Album
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          1,
+			expectedOutput: &entity.Album{ID: 1, Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+			mockFunc: func() {
+				rows := mock.NewRows(albumColumns).AddRow(1, "Abbey Road", "The Beatles", 1969, "Rock")
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAlbum)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         2,
+			expectedError: errors.NewAlbumsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAlbum)).WithArgs(2).WillReturnRows(mock.NewRows(albumColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			input:         0,
+			expectedError: errors.NewMusicInvalidSerial(),
+		},
+	}
+
+	for _, tc := range getAlbumMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.GetAlbum(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_SearchByArtist(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	searchByArtistMocks := []struct {
+		testName       string
+		input          string
+		expectedOutput []entity.Album
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			input:    "The Beatles",
+			expectedOutput: []entity.Album{
+				{ID: 1, Title: "Abbey Road", Artist: "The Beatles", Year: 1969},
+				{ID: 2, Title: "Revolver", Artist: "The Beatles", Year: 1966},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(albumColumns).
+					AddRow(1, "Abbey Road", "The Beatles", 1969, "").
+					AddRow(2, "Revolver", "The Beatles", 1966, "")
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByArtistAlbum)).WithArgs("The Beatles").WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         "The Beatles",
+			expectedError: errors.NewAlbumNotFoundByArtist("The Beatles"),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByArtistAlbum)).WithArgs("The Beatles").WillReturnRows(mock.NewRows(albumColumns))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Empty artist",
+			input:         "",
+			expectedError: errors.NewMusicValidatorError([]ct.FieldError{validators.FieldArtistEmpty}),
+		},
+	}
+
+	for _, tc := range searchByArtistMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.SearchByArtist(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_DeleteAlbum(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	deleteAlbumMocks := []struct {
+		testName       string
+		input          uint64
+		expectedOutput int64
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          1,
+			expectedOutput: 1,
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAlbum)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         2,
+			expectedError: errors.NewAlbumsNotFound(),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteAlbum)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, tc := range deleteAlbumMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.DeleteAlbum(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_SaveTrack(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	input := entity.Track{Number: 1, Title: "Come Together", Duration: 259}
+
+	saveTrackMocks := []struct {
+		testName       string
+		albumID        uint64
+		expectedOutput *entity.Track
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			albumID:        1,
+			expectedOutput: &entity.Track{ID: 5, AlbumID: 1, Number: 1, Title: "Come Together", Duration: 259},
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"id"}).AddRow(5)
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveTrack)).WithArgs(1, 1, "Come Together", 259).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Album not found",
+			albumID:       2,
+			expectedError: errors.NewAlbumsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QuerySaveTrack)).WithArgs(2, 1, "Come Together", 259).WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Invalid serial",
+			albumID:       0,
+			expectedError: errors.NewMusicInvalidSerial(),
+		},
+	}
+
+	for _, tc := range saveTrackMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			res, err := repo.SaveTrack(tc.albumID, &input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_GetAlbumTracks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	getAlbumTracksMocks := []struct {
+		testName       string
+		albumID        uint64
+		expectedOutput []entity.Track
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName: "Test Successful",
+			albumID:  1,
+			expectedOutput: []entity.Track{
+				{ID: 1, AlbumID: 1, Number: 1, Title: "Come Together", Duration: 259},
+				{ID: 2, AlbumID: 1, Number: 2, Title: "Something", Duration: 182},
+			},
+			mockFunc: func() {
+				rows := mock.NewRows(trackColumns).
+					AddRow(1, 1, 1, "Come Together", 259).
+					AddRow(2, 1, 2, "Something", 182)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAlbumTracks)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Album has no tracks",
+			albumID:       2,
+			expectedError: errors.NewTracksNotFound(),
+			mockFunc: func() {
+				rows := mock.NewRows(trackColumns).AddRow(nil, nil, nil, nil, nil)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAlbumTracks)).WithArgs(2).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Album not found",
+			albumID:       3,
+			expectedError: errors.NewAlbumsNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryGetAlbumTracks)).WithArgs(3).WillReturnRows(mock.NewRows(trackColumns))
+			},
+		},
+	}
+
+	for _, tc := range getAlbumTracksMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.GetAlbumTracks(tc.albumID)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_UpdateTrack(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	input := entity.Track{Number: 1, Title: "Come Together", Duration: 260}
+
+	updateTrackMocks := []struct {
+		testName       string
+		trackID        uint64
+		expectedOutput *entity.Track
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			trackID:        1,
+			expectedOutput: &entity.Track{ID: 1, AlbumID: 3, Number: 1, Title: "Come Together", Duration: 260},
+			mockFunc: func() {
+				rows := mock.NewRows([]string{"album_id"}).AddRow(3)
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpdateTrack)).WithArgs(1, 1, "Come Together", 260).WillReturnRows(rows)
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			trackID:       2,
+			expectedError: errors.NewTracksNotFound(),
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(QueryUpdateTrack)).WithArgs(2, 1, "Come Together", 260).WillReturnRows(mock.NewRows([]string{"album_id"}))
+			},
+		},
+	}
+
+	for _, tc := range updateTrackMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.UpdateTrack(tc.trackID, &input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_DeleteTrack(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	deleteTrackMocks := []struct {
+		testName       string
+		input          uint64
+		expectedOutput int64
+		expectedError  error
+		mockFunc       func()
+	}{
+		{
+			testName:       "Test Successful",
+			input:          1,
+			expectedOutput: 1,
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteTrack)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			testName:      "Test Unsuccessful: Not found",
+			input:         2,
+			expectedError: errors.NewTracksNotFound(),
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(QueryDeleteTrack)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, tc := range deleteTrackMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			res, err := repo.DeleteTrack(tc.input)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestMusicDBRepository_SearchByKeyword(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewMusicRepo(db)
+
+	albumRows := mock.NewRows(albumColumns).AddRow(1, "Abbey Road", "The Beatles", 1969, "Rock")
+	mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordAlbum)).WithArgs("%road%", 10).WillReturnRows(albumRows)
+
+	albums, err := repo.SearchAlbumsByKeyword("road", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.Album{{ID: 1, Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"}}, albums)
+
+	mock.ExpectQuery(regexp.QuoteMeta(QuerySearchByKeywordTrack)).WithArgs("%road%", 10).WillReturnRows(mock.NewRows(trackColumns))
+
+	tracks, err := repo.SearchTracksByKeyword("road", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.Track{}, tracks)
+}
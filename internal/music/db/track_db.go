@@ -0,0 +1,193 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"log"
+)
+
+const (
+	// QuerySaveTrack inserts nothing if the album does not exist, so the missing album is reported as sql.ErrNoRows
+	QuerySaveTrack = `INSERT INTO tracks (album_id, number, title, duration) SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM albums WHERE id=$1) RETURNING id`
+	QueryGetTrack  = `SELECT id, album_id, number, title, duration FROM tracks WHERE id=$1`
+	// QueryGetAlbumTracks returns a single row with NULL track for an existing album without tracks, to tell it apart from a missing album
+	QueryGetAlbumTracks       = `SELECT t.id, t.album_id, t.number, t.title, t.duration FROM albums a LEFT JOIN tracks t ON t.album_id = a.id WHERE a.id=$1 ORDER BY t.number`
+	QuerySearchByKeywordTrack = `SELECT id, album_id, number, title, duration FROM tracks WHERE title ILIKE $1 ORDER BY id LIMIT $2`
+	QueryUpdateTrack          = `UPDATE tracks SET number=$2, title=$3, duration=$4 WHERE id=$1 RETURNING album_id`
+	QueryDeleteTrack          = `DELETE FROM tracks WHERE id=$1`
+)
+
+func (r *MusicDBRepository) SaveTrack(albumID uint64, track *entity.Track) (*entity.Track, error) {
+	if albumID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+	var trackID uint64
+
+	err := r.database.QueryRow(QuerySaveTrack, albumID, track.Number, track.Title, track.Duration).Scan(&trackID)
+	if err == sql.ErrNoRows {
+		log.Printf("Album id#%v not found", albumID)
+		return nil, errors.NewAlbumsNotFound()
+	} else if err != nil {
+		log.Printf("Unable to save track to db: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	returnTrack := *track
+	returnTrack.ID = trackID
+	returnTrack.AlbumID = albumID
+	return &returnTrack, nil
+}
+
+func (r *MusicDBRepository) GetTrack(trackID uint64) (*entity.Track, error) {
+	if trackID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+	var track entity.Track
+
+	row := r.database.QueryRow(QueryGetTrack, trackID)
+
+	err := row.Scan(&track.ID, &track.AlbumID, &track.Number, &track.Title, &track.Duration)
+	if err == sql.ErrNoRows {
+		log.Printf("Track id#%v not found", trackID)
+		return nil, errors.NewTracksNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	return &track, nil
+}
+
+func (r *MusicDBRepository) GetAlbumTracks(albumID uint64) ([]entity.Track, error) {
+	if albumID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+
+	rows, err := r.database.Query(QueryGetAlbumTracks, albumID)
+	if err != nil {
+		log.Printf("Could not get tracks of album %v: %v", albumID, err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	albumFound := false
+	var tracks []entity.Track
+
+	for rows.Next() {
+		albumFound = true
+
+		var (
+			id, album, number, duration sql.NullInt64
+			title                       sql.NullString
+		)
+
+		if err = rows.Scan(&id, &album, &number, &title, &duration); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		if !id.Valid { // Album exists, but has no tracks
+			continue
+		}
+
+		tracks = append(tracks, entity.Track{
+			ID:       uint64(id.Int64),
+			AlbumID:  uint64(album.Int64),
+			Number:   int(number.Int64),
+			Title:    title.String,
+			Duration: int(duration.Int64),
+		})
+	}
+
+	if !albumFound {
+		log.Printf("Album id#%v not found", albumID)
+		return nil, errors.NewAlbumsNotFound()
+	}
+
+	if len(tracks) == 0 {
+		log.Printf("Album id#%v has no tracks", albumID)
+		return nil, errors.NewTracksNotFound()
+	}
+
+	return tracks, nil
+}
+
+// SearchTracksByKeyword returns tracks which contain keyword in title. Empty result is not an error
+func (r *MusicDBRepository) SearchTracksByKeyword(keyword string, limit int) ([]entity.Track, error) {
+	rows, err := r.database.Query(QuerySearchByKeywordTrack, database.ContainsPattern(keyword), limit)
+	if err != nil {
+		log.Printf("Could not search tracks by keyword %v: %v", keyword, err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	tracks := []entity.Track{}
+	for rows.Next() {
+		var tempTrack entity.Track
+
+		if err = rows.Scan(&tempTrack.ID, &tempTrack.AlbumID, &tempTrack.Number, &tempTrack.Title, &tempTrack.Duration); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		tracks = append(tracks, tempTrack)
+	}
+
+	return tracks, nil
+}
+
+func (r *MusicDBRepository) UpdateTrack(trackID uint64, track *entity.Track) (*entity.Track, error) {
+	if trackID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewMusicInvalidSerial()
+	}
+	var albumID uint64
+
+	err := r.database.QueryRow(QueryUpdateTrack, trackID, track.Number, track.Title, track.Duration).Scan(&albumID)
+	if err == sql.ErrNoRows {
+		log.Printf("Track id#%v not found", trackID)
+		return nil, errors.NewTracksNotFound()
+	} else if err != nil {
+		log.Printf("Unable to update track: %v", err)
+		return nil, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	returnTrack := *track
+	returnTrack.ID = trackID
+	returnTrack.AlbumID = albumID
+	return &returnTrack, nil
+}
+
+func (r *MusicDBRepository) DeleteTrack(trackID uint64) (int64, error) {
+	if trackID < 1 {
+		log.Printf("Serial is less than 1")
+		return 0, errors.NewMusicInvalidSerial()
+	}
+
+	res, err := r.database.Exec(QueryDeleteTrack, trackID)
+	if err != nil {
+		log.Printf("Unable to delete track: %v", err)
+		return 0, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("Unable to get affected rows track: %v", err)
+		return 0, errors.NewMusicCouldNotQuery(err.Error())
+	}
+
+	if rowsAffected == 0 {
+		return 0, errors.NewTracksNotFound()
+	}
+
+	log.Printf("Deleted rows: %v", rowsAffected)
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,35 @@
+package entity
+
+type Album struct {
+	ID     uint64 `json:"id,omitempty" binding:"omitempty,numeric,validID"`
+	Title  string `json:"title" binding:"required"`
+	Artist string `json:"artist" binding:"required"`
+	Year   int    `json:"year" binding:"required,validAlbumYear"`
+	Genre  string `json:"genre,omitempty"`
+}
+
+// Equal returns true if all fields in receiver are same as in parameter
+func (lhs Album) Equal(rhs Album) bool {
+	return rhs == lhs
+}
+
+// EqualNoID works similar to Equal, except it ignores ID
+func (lhs Album) EqualNoID(rhs Album) bool {
+	rhs.ID = lhs.ID
+	return rhs == lhs
+}
+
+// AlbumArrayEqualNoID compares two arrays of Album(s) using Album.EqualNoID on each element
+func AlbumArrayEqualNoID(lhs []Album, rhs []Album) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for idx := range lhs {
+		if !lhs[idx].EqualNoID(rhs[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/music/http/validators"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func init() {
+	validators.RegisterMusicValidators()
+}
+
+func TestAlbum_EqualNoID(t *testing.T) {
+	base := Album{ID: 1, Title: "1", Artist: "1", Year: 1, Genre: "1"}
+
+	assert.True(t, base.Equal(Album{ID: 1, Title: "1", Artist: "1", Year: 1, Genre: "1"}))
+	assert.True(t, !base.Equal(Album{ID: 2, Title: "1", Artist: "1", Year: 1, Genre: "1"}))
+	assert.True(t, base.EqualNoID(Album{ID: 2, Title: "1", Artist: "1", Year: 1, Genre: "1"}))
+	assert.True(t, !base.EqualNoID(Album{ID: 1, Title: "1", Artist: "1", Year: 1, Genre: "2"}))
+}
+
+func TestTrack_EqualNoID(t *testing.T) {
+	base := Track{ID: 1, AlbumID: 1, Number: 1, Title: "1", Duration: 1}
+
+	assert.True(t, base.Equal(Track{ID: 1, AlbumID: 1, Number: 1, Title: "1", Duration: 1}))
+	assert.True(t, !base.Equal(Track{ID: 2, AlbumID: 1, Number: 1, Title: "1", Duration: 1}))
+	assert.True(t, base.EqualNoID(Track{ID: 2, AlbumID: 1, Number: 1, Title: "1", Duration: 1}))
+	assert.True(t, !base.EqualNoID(Track{ID: 1, AlbumID: 2, Number: 1, Title: "1", Duration: 1}))
+}
+
+func TestArrayEqualNoID(t *testing.T) {
+	assert.True(t, AlbumArrayEqualNoID([]Album{{ID: 1, Title: "1"}}, []Album{{ID: 2, Title: "1"}}))
+	assert.True(t, !AlbumArrayEqualNoID([]Album{{ID: 1, Title: "1"}}, []Album{{ID: 1, Title: "2"}}))
+	assert.True(t, !AlbumArrayEqualNoID([]Album{{ID: 1, Title: "1"}}, nil))
+
+	assert.True(t, TrackArrayEqualNoID([]Track{{ID: 1, Title: "1"}}, []Track{{ID: 2, Title: "1"}}))
+	assert.True(t, !TrackArrayEqualNoID([]Track{{ID: 1, Title: "1"}}, []Track{{ID: 1, Title: "2"}}))
+	assert.True(t, !TrackArrayEqualNoID([]Track{{ID: 1, Title: "1"}}, nil))
+}
+
+func TestAlbum_IsValid(t *testing.T) {
+	testCasesValid := []Album{
+		{Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+		{Title: "Kind of Blue", Artist: "Miles Davis", Year: 1959},
+	}
+	testCasesInvalid := []Album{
+		{Artist: "The Beatles", Year: 1969},
+		{Title: "Abbey Road", Year: 1969},
+		{Title: "Abbey Road", Artist: "The Beatles"},
+		{Title: "Abbey Road", Artist: "The Beatles", Year: 1500},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Album expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Album expected to be invalid, but found valid: %v", tc)
+	}
+}
+
+func TestTrack_IsValid(t *testing.T) {
+	testCasesValid := []Track{
+		{Number: 1, Title: "Come Together", Duration: 259},
+		{AlbumID: 3, Number: 17, Title: "Her Majesty", Duration: 23},
+	}
+	testCasesInvalid := []Track{
+		{Title: "Come Together", Duration: 259},
+		{Number: 1, Duration: 259},
+		{Number: 1, Title: "Come Together"},
+		{Number: -1, Title: "Come Together", Duration: 259},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Track expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Track expected to be invalid, but found valid: %v", tc)
+	}
+}
@@ -0,0 +1,37 @@
+package entity
+
+// Track is a single song on an album. AlbumID given in the body is ignored: saved tracks belong to the album of the
+// request path, and updated tracks stay on the album they are on
+type Track struct {
+	ID       uint64 `json:"id,omitempty" binding:"omitempty,numeric,validID"`
+	AlbumID  uint64 `json:"album_id,omitempty"`
+	Number   int    `json:"number" binding:"required,validTrackNumber"`
+	Title    string `json:"title" binding:"required"`
+	Duration int    `json:"duration" binding:"required,validDuration"` // Duration in seconds
+}
+
+// Equal returns true if all fields in receiver are same as in parameter
+func (lhs Track) Equal(rhs Track) bool {
+	return rhs == lhs
+}
+
+// EqualNoID works similar to Equal, except it ignores ID
+func (lhs Track) EqualNoID(rhs Track) bool {
+	rhs.ID = lhs.ID
+	return rhs == lhs
+}
+
+// TrackArrayEqualNoID compares two arrays of Track(s) using Track.EqualNoID on each element
+func TrackArrayEqualNoID(lhs []Track, rhs []Track) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for idx := range lhs {
+		if !lhs[idx].EqualNoID(rhs[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
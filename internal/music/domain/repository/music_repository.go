@@ -0,0 +1,20 @@
+package repository
+
+import "github.com/foxfurry/simple-rest/internal/music/domain/entity"
+
+type MusicRepository interface {
+	SaveAlbum(*entity.Album) (*entity.Album, error)
+	GetAlbum(uint64) (*entity.Album, error)
+	GetAllAlbums() ([]entity.Album, error)
+	SearchByArtist(string) ([]entity.Album, error)             // An artist can have multiple albums
+	SearchAlbumsByKeyword(string, int) ([]entity.Album, error) // Up to limit albums with keyword in title, artist or genre
+	UpdateAlbum(uint64, *entity.Album) (*entity.Album, error)
+	DeleteAlbum(uint64) (int64, error) // Tracks of the album are deleted along with it
+
+	SaveTrack(uint64, *entity.Track) (*entity.Track, error) // Track is saved to the album with given id
+	GetTrack(uint64) (*entity.Track, error)
+	GetAlbumTracks(uint64) ([]entity.Track, error)
+	SearchTracksByKeyword(string, int) ([]entity.Track, error) // Up to limit tracks with keyword in title
+	UpdateTrack(uint64, *entity.Track) (*entity.Track, error)
+	DeleteTrack(uint64) (int64, error)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	musicDB "github.com/foxfurry/simple-rest/internal/music/db"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type MusicService struct {
+	dbRepo musicDB.MusicDBRepository
+}
+
+func NewMusicService(db *sql.DB) MusicService {
+	return MusicService{
+		dbRepo: musicDB.NewMusicRepo(db),
+	}
+}
+
+// bindBody reads the request body into obj and responds with an error if the body is missing or invalid
+func bindBody(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		if err == io.EOF {
+			errors.HandleMusicError(c, errors.NewMusicEmptyBody())
+		} else {
+			errors.HandleMusicError(c, errors.NewMusicValidatorError(common_translators.Translate(err)))
+		}
+		return false
+	}
+	return true
+}
+
+// paramID parses the id path parameter and responds with an error if it is not a number
+func paramID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errors.HandleMusicError(c, errors.NewMusicInvalidSerial())
+		return 0, false
+	}
+	return uint64(id), true
+}
+
+func (m *MusicService) SaveAlbum(c *gin.Context) {
+	var album entity.Album
+
+	if !bindBody(c, &album) {
+		return
+	}
+
+	saveAlbum, err := m.dbRepo.SaveAlbum(&album)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, saveAlbum, nil)
+}
+
+func (m *MusicService) GetAlbum(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	getAlbum, err := m.dbRepo.GetAlbum(id)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, getAlbum, nil)
+}
+
+func (m *MusicService) GetAllAlbums(c *gin.Context) {
+	allAlbums, err := m.dbRepo.GetAllAlbums()
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, allAlbums, nil)
+}
+
+func (m *MusicService) SearchByArtist(c *gin.Context) {
+	albumsByArtist, err := m.dbRepo.SearchByArtist(c.Param("artist"))
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, albumsByArtist, nil)
+}
+
+func (m *MusicService) UpdateAlbum(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var album entity.Album
+
+	if !bindBody(c, &album) {
+		return
+	}
+
+	updatedAlbum, err := m.dbRepo.UpdateAlbum(id, &album)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, updatedAlbum, nil)
+}
+
+func (m *MusicService) DeleteAlbum(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	_, err := m.dbRepo.DeleteAlbum(id)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, nil, nil)
+}
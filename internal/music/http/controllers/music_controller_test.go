@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/common/tests"
+	musicdb "github.com/foxfurry/simple-rest/internal/music/db"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"github.com/foxfurry/simple-rest/internal/music/http/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type albumResponse struct {
+	Data  *entity.Album  `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type trackResponse struct {
+	Data  *entity.Track  `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type tracksResponse struct {
+	Data  []entity.Track `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+var trackColumns = []string{"id", "album_id", "number", "title", "duration"}
+
+func init() {
+	validators.RegisterMusicValidators()
+}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestMusicService_SaveAlbum(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMusicService(db)
+
+	saveAlbumServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		requestBody    *entity.Album
+		expectedStatus int
+		expectedBody   *entity.Album
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+				mock.ExpectQuery(regexp.QuoteMeta(musicdb.QuerySaveAlbum)).WithArgs("Abbey Road", "The Beatles", 1969, "Rock").WillReturnRows(rows)
+			},
+			requestBody:    &entity.Album{Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Album{ID: 1, Title: "Abbey Road", Artist: "The Beatles", Year: 1969, Genre: "Rock"},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty artist",
+			requestBody:    &entity.Album{Title: "Abbey Road", Year: 1969},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldArtistEmpty},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Empty request body",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewMusicEmptyBody().Error(),
+			},
+		},
+	}
+
+	for _, tc := range saveAlbumServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			var body interface{}
+			if tc.requestBody != nil {
+				body = tc.requestBody
+			}
+
+			w := tests.Serve(service.SaveAlbum, http.MethodPost, "/album", nil, body)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := albumResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMusicService_SaveTrack(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMusicService(db)
+
+	saveTrackServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		albumID        string
+		requestBody    *entity.Track
+		expectedStatus int
+		expectedBody   *entity.Track
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(7)
+				mock.ExpectQuery(regexp.QuoteMeta(musicdb.QuerySaveTrack)).WithArgs(1, 1, "Come Together", 259).WillReturnRows(rows)
+			},
+			albumID:        "1",
+			requestBody:    &entity.Track{Number: 1, Title: "Come Together", Duration: 259},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &entity.Track{ID: 7, AlbumID: 1, Number: 1, Title: "Come Together", Duration: 259},
+		},
+		{
+			testName: "Test Unsuccessful: Album not found",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(musicdb.QuerySaveTrack)).WithArgs(2, 1, "Come Together", 259).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			albumID:        "2",
+			requestBody:    &entity.Track{Number: 1, Title: "Come Together", Duration: 259},
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewAlbumsNotFound().Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid duration",
+			albumID:        "1",
+			requestBody:    &entity.Track{Number: 1, Title: "Come Together", Duration: -1},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{validators.FieldDurationInvalid},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid serial",
+			albumID:        "abc",
+			requestBody:    &entity.Track{Number: 1, Title: "Come Together", Duration: 259},
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Msg: errors.NewMusicInvalidSerial().Error(),
+			},
+		},
+	}
+
+	for _, tc := range saveTrackServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.mockFunc != nil {
+				tc.mockFunc()
+			}
+
+			w := tests.Serve(service.SaveTrack, http.MethodPost, "/album/"+tc.albumID+"/tracks", gin.Params{{Key: "id", Value: tc.albumID}}, tc.requestBody)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := trackResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMusicService_GetAlbumTracks(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMusicService(db)
+
+	getAlbumTracksServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		albumID        string
+		expectedStatus int
+		expectedBody   []entity.Track
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				rows := sqlmock.NewRows(trackColumns).
+					AddRow(1, 1, 1, "Come Together", 259).
+					AddRow(2, 1, 2, "Something", 182)
+				mock.ExpectQuery(regexp.QuoteMeta(musicdb.QueryGetAlbumTracks)).WithArgs(1).WillReturnRows(rows)
+			},
+			albumID:        "1",
+			expectedStatus: http.StatusOK,
+			expectedBody: []entity.Track{
+				{ID: 1, AlbumID: 1, Number: 1, Title: "Come Together", Duration: 259},
+				{ID: 2, AlbumID: 1, Number: 2, Title: "Something", Duration: 182},
+			},
+		},
+		{
+			testName: "Test Unsuccessful: Album not found",
+			mockFunc: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(musicdb.QueryGetAlbumTracks)).WithArgs(2).WillReturnRows(sqlmock.NewRows(trackColumns))
+			},
+			albumID:        "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewAlbumsNotFound().Error(),
+			},
+		},
+	}
+
+	for _, tc := range getAlbumTracksServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			w := tests.Serve(service.GetAlbumTracks, http.MethodGet, "/album/"+tc.albumID+"/tracks", gin.Params{{Key: "id", Value: tc.albumID}}, nil)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := tracksResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
+
+func TestMusicService_DeleteAlbum(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	service := NewMusicService(db)
+
+	deleteAlbumServiceMocks := []struct {
+		testName       string
+		mockFunc       func()
+		albumID        string
+		expectedStatus int
+		expectedError  expectedErrors
+	}{
+		{
+			testName: "Test Successful",
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(musicdb.QueryDeleteAlbum)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			albumID:        "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "Test Unsuccessful: Not found",
+			mockFunc: func() {
+				mock.ExpectExec(regexp.QuoteMeta(musicdb.QueryDeleteAlbum)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			albumID:        "2",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewAlbumsNotFound().Error(),
+			},
+		},
+	}
+
+	for _, tc := range deleteAlbumServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mockFunc()
+
+			w := tests.Serve(service.DeleteAlbum, http.MethodDelete, "/album/"+tc.albumID, gin.Params{{Key: "id", Value: tc.albumID}}, nil)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := albumResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/music/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/music/http/errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func (m *MusicService) SaveTrack(c *gin.Context) {
+	albumID, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var track entity.Track
+
+	if !bindBody(c, &track) {
+		return
+	}
+
+	saveTrack, err := m.dbRepo.SaveTrack(albumID, &track)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, saveTrack, nil)
+}
+
+func (m *MusicService) GetTrack(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	getTrack, err := m.dbRepo.GetTrack(id)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, getTrack, nil)
+}
+
+func (m *MusicService) GetAlbumTracks(c *gin.Context) {
+	albumID, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	albumTracks, err := m.dbRepo.GetAlbumTracks(albumID)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, albumTracks, nil)
+}
+
+func (m *MusicService) UpdateTrack(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var track entity.Track
+
+	if !bindBody(c, &track) {
+		return
+	}
+
+	updatedTrack, err := m.dbRepo.UpdateTrack(id, &track)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, updatedTrack, nil)
+}
+
+func (m *MusicService) DeleteTrack(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	_, err := m.dbRepo.DeleteTrack(id)
+	if err != nil {
+		errors.HandleMusicError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, nil, nil)
+}
@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type albumsNotFound struct {
+	common_errors.CommonError
+}
+
+type albumNotFoundByArtist struct {
+	common_errors.CommonError
+}
+
+type tracksNotFound struct {
+	common_errors.CommonError
+}
+
+type musicCouldNotQuery struct {
+	common_errors.CommonError
+}
+
+type musicInvalidSerial struct {
+	common_errors.CommonError
+}
+
+type musicUnexpectedError struct {
+	common_errors.CommonError
+}
+
+type musicEmptyBody struct {
+	common_errors.CommonError
+}
+
+type musicValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewAlbumsNotFound() albumsNotFound {
+	return albumsNotFound{
+		common_errors.CommonError{Msg: "Album(s) not found in db"},
+	}
+}
+
+func NewAlbumNotFoundByArtist(artist string) albumNotFoundByArtist {
+	return albumNotFoundByArtist{
+		common_errors.CommonError{Msg: fmt.Sprintf("Album(s) with artist %v not found in db", artist)},
+	}
+}
+
+func NewTracksNotFound() tracksNotFound {
+	return tracksNotFound{
+		common_errors.CommonError{Msg: "Track(s) not found in db"},
+	}
+}
+
+func NewMusicCouldNotQuery(msg string) musicCouldNotQuery {
+	return musicCouldNotQuery{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not execute query: %v", msg)},
+	}
+}
+
+func NewMusicInvalidSerial() musicInvalidSerial {
+	return musicInvalidSerial{
+		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
+	}
+}
+
+func NewMusicUnexpectedError(msg string) musicUnexpectedError {
+	return musicUnexpectedError{
+		common_errors.CommonError{Msg: fmt.Sprintf("Unexpected error: %v", msg)},
+	}
+}
+
+func NewMusicEmptyBody() musicEmptyBody {
+	return musicEmptyBody{
+		common_errors.CommonError{Msg: "Expected body, found EOF"},
+	}
+}
+
+func NewMusicValidatorError(fields []validator.FieldError) musicValidatorError {
+	return musicValidatorError{Fields: fields}
+}
+
+func (m musicValidatorError) Error() string {
+	var res = ""
+	for _, f := range m.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+func HandleMusicError(c *gin.Context, err error) {
+	switch err.(type) {
+	case albumsNotFound, albumNotFoundByArtist, tracksNotFound:
+		common_errors.RespondNotFound(c, err)
+	case musicValidatorError, musicInvalidSerial, musicEmptyBody:
+		common_errors.RespondBadRequest(c, err)
+	case musicUnexpectedError, musicCouldNotQuery:
+		common_errors.RespondInternalError(c, err)
+	default:
+		common_errors.RespondInternalError(c, err)
+	}
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/music/http/controllers"
+	"github.com/foxfurry/simple-rest/internal/music/http/validators"
+	"github.com/gin-gonic/gin"
+)
+
+var musicPolicy = common_auth.Policy{
+	"POST /album/":           common_auth.PermWrite,
+	"POST /album/:id/tracks": common_auth.PermWrite,
+	"PUT /album/:id":         common_auth.PermWrite,
+	"DELETE /album/:id":      common_auth.PermWrite,
+	"PUT /track/:id":         common_auth.PermWrite,
+	"DELETE /track/:id":      common_auth.PermWrite,
+}
+
+func RegisterMusicRoutes(router *gin.Engine, db *sql.DB, guard *common_auth.Guard) {
+	musicRepo := controllers.NewMusicService(db)
+
+	album := router.Group("/album", guard.Enforce(musicPolicy))
+	{
+		album.GET("/:id", musicRepo.GetAlbum)
+
+		album.GET("/:id/tracks", musicRepo.GetAlbumTracks)
+		album.POST("/:id/tracks", musicRepo.SaveTrack)
+
+		album.GET("/artist/:artist", musicRepo.SearchByArtist)
+		album.GET("/artist/", musicRepo.SearchByArtist)
+
+		album.GET("/", musicRepo.GetAllAlbums)
+
+		album.POST("/", musicRepo.SaveAlbum)
+
+		album.PUT("/:id", musicRepo.UpdateAlbum)
+
+		album.DELETE("/:id", musicRepo.DeleteAlbum)
+	}
+
+	track := router.Group("/track", guard.Enforce(musicPolicy))
+	{
+		track.GET("/:id", musicRepo.GetTrack)
+
+		track.PUT("/:id", musicRepo.UpdateTrack)
+
+		track.DELETE("/:id", musicRepo.DeleteTrack)
+	}
+
+	validators.RegisterMusicValidators()
+}
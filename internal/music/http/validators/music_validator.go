@@ -0,0 +1,114 @@
+package validators
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"time"
+)
+
+const (
+	idTag          = "validID"
+	yearTag        = "validAlbumYear"
+	trackNumberTag = "validTrackNumber"
+	durationTag    = "validDuration"
+	requiredTag    = "required"
+	emptyFieldMsg  = "cannot be empty"
+
+	firstRecordingYear = 1860 // Au Clair de la Lune phonautograph
+)
+
+var (
+	invalidYearMsg = fmt.Sprintf("Year should be between %v and %v", firstRecordingYear, time.Now().Year())
+
+	FieldTitleEmpty = common_translators.FieldError{
+		Field: "Title",
+		Msg:   "Title " + emptyFieldMsg,
+	}
+	FieldArtistEmpty = common_translators.FieldError{
+		Field: "Artist",
+		Msg:   "Artist " + emptyFieldMsg,
+	}
+	FieldYearInvalid = common_translators.FieldError{
+		Field: "Year",
+		Msg:   invalidYearMsg,
+	}
+	FieldNumberInvalid = common_translators.FieldError{
+		Field: "Number",
+		Msg:   "Number should be positive non-null number",
+	}
+	FieldDurationInvalid = common_translators.FieldError{
+		Field: "Duration",
+		Msg:   "Duration should be positive number of seconds",
+	}
+)
+
+var validID validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() >= 1
+}
+
+var validYear validator.Func = func(fl validator.FieldLevel) bool {
+	year := fl.Field().Int()
+	return year >= firstRecordingYear && year <= int64(time.Now().Year())
+}
+
+var validTrackNumber validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() >= 1
+}
+
+var validDuration validator.Func = func(fl validator.FieldLevel) bool {
+	return fl.Field().Int() > 0
+}
+
+var trslValidYear validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(yearTag, invalidYearMsg, true)
+}
+
+var trslValidTrackNumber validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(trackNumberTag, FieldNumberInvalid.Msg, true)
+}
+
+var trslValidDuration validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(durationTag, FieldDurationInvalid.Msg, true)
+}
+
+var trslValidID validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(idTag, "{0} should be positive non-null number", true)
+}
+
+var requiredMessage validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(requiredTag, "{0} "+emptyFieldMsg, true)
+}
+
+// translateTag returns a translation function which renders the message registered for tag
+func translateTag(tag string) validator.TranslationFunc {
+	return func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	}
+}
+
+func RegisterMusicValidators() {
+	errTranslator := common_translators.GetTranslator()
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation(yearTag, validYear)
+		v.RegisterTranslation(yearTag, errTranslator, trslValidYear, translateTag(yearTag))
+
+		v.RegisterValidation(trackNumberTag, validTrackNumber)
+		v.RegisterTranslation(trackNumberTag, errTranslator, trslValidTrackNumber, translateTag(trackNumberTag))
+
+		v.RegisterValidation(durationTag, validDuration)
+		v.RegisterTranslation(durationTag, errTranslator, trslValidDuration, translateTag(durationTag))
+
+		v.RegisterValidation(idTag, validID)
+		v.RegisterTranslation(idTag, errTranslator, trslValidID, translateTag(idTag))
+
+		v.RegisterTranslation(requiredTag, errTranslator, requiredMessage, translateTag(requiredTag))
+	} else {
+		log.Panicf("Could not register common_translators: %v", ok)
+	}
+}
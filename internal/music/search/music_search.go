@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/music/domain/repository"
+)
+
+const (
+	KindAlbum = "album"
+	KindTrack = "track"
+)
+
+// MusicSearchProvider lets albums and tracks take part in the cross-media search
+type MusicSearchProvider struct {
+	repo repository.MusicRepository
+}
+
+func NewMusicSearchProvider(repo repository.MusicRepository) MusicSearchProvider {
+	return MusicSearchProvider{repo: repo}
+}
+
+var _ search.Provider = MusicSearchProvider{}
+
+func (p MusicSearchProvider) Kinds() []string {
+	return []string{KindAlbum, KindTrack}
+}
+
+func (p MusicSearchProvider) Search(ctx context.Context, query string, limit int) ([]search.Hit, error) {
+	// Music repository can not cancel its queries, so ctx is only checked before querying
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	albums, err := p.repo.SearchAlbumsByKeyword(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := p.repo.SearchTracksByKeyword(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, 0, len(albums)+len(tracks))
+	for _, album := range albums {
+		hits = append(hits, search.Hit{Kind: KindAlbum, ID: album.ID, Title: album.Title, Item: album})
+	}
+	for _, track := range tracks {
+		hits = append(hits, search.Hit{Kind: KindTrack, ID: track.ID, Title: track.Title, Item: track})
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	ct "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/search/http/errors"
+	"github.com/foxfurry/simple-rest/internal/search/http/validators"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SearchService struct {
+	providers []search.Provider
+}
+
+func NewSearchService(providers ...search.Provider) SearchService {
+	return SearchService{providers: providers}
+}
+
+// wantedKinds returns a set of kinds requested with kind query parameter. Nil set means every kind is wanted
+func (s *SearchService) wantedKinds(c *gin.Context) (map[string]bool, []ct.FieldError) {
+	requested := c.QueryArray("kind")
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	for _, p := range s.providers {
+		for _, kind := range p.Kinds() {
+			known[kind] = true
+		}
+	}
+
+	var fieldErrors []ct.FieldError
+	wanted := make(map[string]bool)
+	for _, kind := range requested {
+		if !known[kind] {
+			fieldErrors = append(fieldErrors, validators.NewFieldKindInvalid(kind))
+			continue
+		}
+		wanted[kind] = true
+	}
+
+	return wanted, fieldErrors
+}
+
+// Search queries every registered provider and merges their hits. Hits are grouped by provider in registration order
+func (s *SearchService) Search(c *gin.Context) {
+	var fieldErrors []ct.FieldError
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		fieldErrors = append(fieldErrors, validators.FieldQueryEmpty)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(validators.DefaultLimit)))
+	if err != nil || limit < 1 || limit > validators.MaxLimit {
+		fieldErrors = append(fieldErrors, validators.FieldLimitInvalid)
+	}
+
+	wanted, kindErrors := s.wantedKinds(c)
+	fieldErrors = append(fieldErrors, kindErrors...)
+
+	if len(fieldErrors) != 0 {
+		errors.HandleSearchError(c, errors.NewSearchValidatorError(fieldErrors))
+		return
+	}
+
+	hits := []search.Hit{}
+	for _, p := range s.providers {
+		if !providesAny(p, wanted) {
+			continue
+		}
+
+		providerHits, err := p.Search(c.Request.Context(), query, limit)
+		if err != nil {
+			errors.HandleSearchError(c, err)
+			return
+		}
+
+		for _, hit := range providerHits {
+			if wanted == nil || wanted[hit.Kind] {
+				hits = append(hits, hit)
+			}
+		}
+	}
+
+	if len(hits) == 0 {
+		errors.HandleSearchError(c, errors.NewSearchNothingFound(query))
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, hits, nil)
+}
+
+// providesAny returns true if provider can produce at least one of wanted kinds
+func providesAny(p search.Provider, wanted map[string]bool) bool {
+	if wanted == nil {
+		return true
+	}
+
+	for _, kind := range p.Kinds() {
+		if wanted[kind] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/search/http/errors"
+	"github.com/foxfurry/simple-rest/internal/search/http/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type hitsResponse struct {
+	Data  []search.Hit   `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+// fakeProvider returns hits of its kinds which have query as a title
+type fakeProvider struct {
+	kinds []string
+	hits  []search.Hit
+	err   error
+}
+
+func (f fakeProvider) Kinds() []string {
+	return f.kinds
+}
+
+func (f fakeProvider) Search(ctx context.Context, query string, limit int) ([]search.Hit, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var hits []search.Hit
+	for _, hit := range f.hits {
+		if hit.Title == query && len(hits) < limit {
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+func TestSearchService_Search(t *testing.T) {
+	books := fakeProvider{
+		kinds: []string{"book"},
+		hits: []search.Hit{
+			{Kind: "book", ID: 1, Title: "Solaris"},
+			{Kind: "book", ID: 2, Title: "Dune"},
+		},
+	}
+	music := fakeProvider{
+		kinds: []string{"album", "track"},
+		hits: []search.Hit{
+			{Kind: "album", ID: 1, Title: "Solaris"},
+			{Kind: "track", ID: 4, Title: "Solaris"},
+		},
+	}
+
+	searchServiceMocks := []struct {
+		testName       string
+		service        SearchService
+		url            string
+		expectedStatus int
+		expectedBody   []search.Hit
+		expectedError  expectedErrors
+	}{
+		{
+			testName:       "Test Successful: Every kind",
+			service:        NewSearchService(books, music),
+			url:            "/search?q=Solaris",
+			expectedStatus: http.StatusOK,
+			expectedBody: []search.Hit{
+				{Kind: "book", ID: 1, Title: "Solaris"},
+				{Kind: "album", ID: 1, Title: "Solaris"},
+				{Kind: "track", ID: 4, Title: "Solaris"},
+			},
+		},
+		{
+			testName:       "Test Successful: Filtered by kind",
+			service:        NewSearchService(books, music),
+			url:            "/search?q=Solaris&kind=track&kind=book",
+			expectedStatus: http.StatusOK,
+			expectedBody: []search.Hit{
+				{Kind: "book", ID: 1, Title: "Solaris"},
+				{Kind: "track", ID: 4, Title: "Solaris"},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Nothing found",
+			service:        NewSearchService(books, music),
+			url:            "/search?q=Hyperion",
+			expectedStatus: http.StatusNotFound,
+			expectedError: expectedErrors{
+				Msg: errors.NewSearchNothingFound("Hyperion").Error(),
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Invalid parameters",
+			service:        NewSearchService(books, music),
+			url:            "/search?q=%20&limit=1000&kind=podcast",
+			expectedStatus: http.StatusBadRequest,
+			expectedError: expectedErrors{
+				Fields: []common_translators.FieldError{
+					validators.FieldQueryEmpty,
+					validators.FieldLimitInvalid,
+					validators.NewFieldKindInvalid("podcast"),
+				},
+			},
+		},
+		{
+			testName:       "Test Unsuccessful: Provider failed",
+			service:        NewSearchService(books, fakeProvider{kinds: []string{"movie"}, err: goerrors.New("database is closed")}),
+			url:            "/search?q=Solaris",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range searchServiceMocks {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			tc.service.Search(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			resultBody := hitsResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resultBody); err != nil {
+				t.Errorf("Unable to unmarshal the body")
+			}
+
+			assert.Equal(t, tc.expectedBody, resultBody.Data)
+			assert.Equal(t, tc.expectedError, resultBody.Error)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type searchNothingFound struct {
+	common_errors.CommonError
+}
+
+type searchValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewSearchNothingFound(query string) searchNothingFound {
+	return searchNothingFound{
+		common_errors.CommonError{Msg: fmt.Sprintf("Nothing found for query %v", query)},
+	}
+}
+
+func NewSearchValidatorError(fields []validator.FieldError) searchValidatorError {
+	return searchValidatorError{Fields: fields}
+}
+
+func (s searchValidatorError) Error() string {
+	var res = ""
+	for _, f := range s.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+// HandleSearchError responds with the status matching err. Errors of media modules are passed through as internal errors
+func HandleSearchError(c *gin.Context, err error) {
+	switch err.(type) {
+	case searchNothingFound:
+		common_errors.RespondNotFound(c, err)
+	case searchValidatorError:
+		common_errors.RespondBadRequest(c, err)
+	default:
+		if goerrors.Is(err, context.DeadlineExceeded) {
+			common_errors.RespondGatewayTimeout(c, err)
+			return
+		}
+		common_errors.RespondInternalError(c, err)
+	}
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/search"
+	"github.com/foxfurry/simple-rest/internal/search/http/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSearchRoutes registers the cross-media search over all given providers
+func RegisterSearchRoutes(router *gin.Engine, providers ...search.Provider) {
+	searchRepo := controllers.NewSearchService(providers...)
+
+	router.GET("/search", searchRepo.Search)
+}
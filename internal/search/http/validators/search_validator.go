@@ -0,0 +1,27 @@
+package validators
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	FieldQueryEmpty = common_translators.FieldError{
+		Field: "q",
+		Msg:   "Search query cannot be empty",
+	}
+	FieldLimitInvalid = common_translators.FieldError{
+		Field: "limit",
+		Msg:   fmt.Sprintf("Limit should be between 1 and %v", MaxLimit),
+	}
+)
+
+// NewFieldKindInvalid returns a field error for a kind which no search provider can produce
+func NewFieldKindInvalid(kind string) common_translators.FieldError {
+	return common_translators.CreateFieldError("kind", fmt.Sprintf("Unknown kind %v", kind))
+}
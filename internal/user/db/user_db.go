@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"log"
+	"time"
+)
+
+// UserDBRepository stores users in postgres or SQLite. Queries are written for postgres and rebound for the dialect
+type UserDBRepository struct {
+	database *sql.DB
+	dialect  database.Dialect
+}
+
+func NewUserRepo(db *sql.DB) UserDBRepository {
+	return UserDBRepository{database: db, dialect: database.DialectOf(db)}
+}
+
+var _ repository.UserRepository = &UserDBRepository{}
+
+const (
+	QuerySaveUser          = `INSERT INTO users (username, password_hash, role, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	QueryGetUser           = `SELECT id, username, password_hash, role, created_at FROM users WHERE id=$1`
+	QueryGetUserByUsername = `SELECT id, username, password_hash, role, created_at FROM users WHERE username=$1`
+	QueryCountUsers        = `SELECT COUNT(*) FROM users`
+	QueryGetUsers          = `SELECT id, username, password_hash, role, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	QuerySetUserRole       = `UPDATE users SET role=$2 WHERE id=$1 RETURNING id, username, password_hash, role, created_at`
+	QueryRecordDenial      = `INSERT INTO access_denials (actor, role, method, route, path, permission, status, reason, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	QueryCountDenials = `SELECT COUNT(*) FROM access_denials`
+	QueryGetDenials   = `SELECT id, actor, role, method, route, path, permission, status, reason, request_id, created_at
+		FROM access_denials ORDER BY id DESC LIMIT $1 OFFSET $2`
+)
+
+// rowScanner is either *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner, user *entity.User) error {
+	return row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt)
+}
+
+func (r *UserDBRepository) SaveUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	returnUser := *user
+	returnUser.Role = common_auth.RoleReader
+	returnUser.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	err := r.database.QueryRowContext(ctx, r.dialect.Rebind(QuerySaveUser), returnUser.Username, returnUser.PasswordHash,
+		returnUser.Role, returnUser.CreatedAt).Scan(&returnUser.ID)
+
+	if database.IsUniqueViolation(err) {
+		log.Printf("Username %v is already taken", user.Username)
+		return nil, errors.NewUserAlreadyExists(user.Username)
+	} else if err != nil {
+		log.Printf("Unable to save user to db: %v", err)
+		return nil, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	return &returnUser, nil
+}
+
+func (r *UserDBRepository) GetUser(ctx context.Context, userID uint64) (*entity.User, error) {
+	if userID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewUserInvalidSerial()
+	}
+
+	return r.getUser(ctx, QueryGetUser, userID)
+}
+
+func (r *UserDBRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return r.getUser(ctx, QueryGetUserByUsername, username)
+}
+
+func (r *UserDBRepository) getUser(ctx context.Context, query string, arg interface{}) (*entity.User, error) {
+	var user entity.User
+
+	err := scanUser(r.database.QueryRowContext(ctx, r.dialect.Rebind(query), arg), &user)
+
+	if err == sql.ErrNoRows {
+		log.Printf("User %v not found", arg)
+		return nil, errors.NewUsersNotFound()
+	} else if err != nil {
+		log.Printf("Could not execute the query: %v", err)
+		return nil, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	return &user, nil
+}
+
+// GetUsers returns users of the limit/offset window and the total count of users
+func (r *UserDBRepository) GetUsers(ctx context.Context, limit int, offset int) ([]entity.User, int64, error) {
+	var total int64
+
+	err := r.database.QueryRowContext(ctx, QueryCountUsers).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count users: %v", err)
+		return nil, 0, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	users := []entity.User{}
+	if total == 0 {
+		return users, 0, nil
+	}
+
+	rows, err := r.database.QueryContext(ctx, r.dialect.Rebind(QueryGetUsers), limit, offset)
+	if err != nil {
+		log.Printf("Unable to get users: %v", err)
+		return nil, 0, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user entity.User
+
+		if err := scanUser(rows, &user); err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	return users, total, nil
+}
+
+func (r *UserDBRepository) SetUserRole(ctx context.Context, userID uint64, role common_auth.Role) (*entity.User, error) {
+	if userID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewUserInvalidSerial()
+	}
+
+	var user entity.User
+
+	err := scanUser(r.database.QueryRowContext(ctx, r.dialect.Rebind(QuerySetUserRole), userID, role), &user)
+
+	if err == sql.ErrNoRows {
+		log.Printf("User %v not found", userID)
+		return nil, errors.NewUsersNotFound()
+	} else if err != nil {
+		log.Printf("Unable to set role of user: %v", err)
+		return nil, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	return &user, nil
+}
+
+func (r *UserDBRepository) RecordDenial(ctx context.Context, denial common_auth.Denial) error {
+	_, err := r.database.ExecContext(ctx, r.dialect.Rebind(QueryRecordDenial), denial.Actor, denial.Role, denial.Method,
+		denial.Route, denial.Path, denial.Permission, denial.Status, denial.Reason, denial.RequestID, denial.CreatedAt)
+
+	if err != nil {
+		log.Printf("Unable to record denial: %v", err)
+		return errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	return nil
+}
+
+// GetDenials returns denials of the limit/offset window, the latest first, and the total count of denials
+func (r *UserDBRepository) GetDenials(ctx context.Context, limit int, offset int) ([]common_auth.Denial, int64, error) {
+	var total int64
+
+	err := r.database.QueryRowContext(ctx, QueryCountDenials).Scan(&total)
+	if err != nil {
+		log.Printf("Unable to count denials: %v", err)
+		return nil, 0, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	denials := []common_auth.Denial{}
+	if total == 0 {
+		return denials, 0, nil
+	}
+
+	rows, err := r.database.QueryContext(ctx, r.dialect.Rebind(QueryGetDenials), limit, offset)
+	if err != nil {
+		log.Printf("Unable to get denials: %v", err)
+		return nil, 0, errors.NewUserCouldNotQuery(err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var d common_auth.Denial
+
+		err := rows.Scan(&d.ID, &d.Actor, &d.Role, &d.Method, &d.Route, &d.Path, &d.Permission, &d.Status, &d.Reason,
+			&d.RequestID, &d.CreatedAt)
+		if err != nil {
+			log.Printf("Could not scan the row: %v", err)
+			continue
+		}
+
+		denials = append(denials, d)
+	}
+
+	return denials, total, nil
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/foxfurry/simple-rest/internal/common/database"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var userColumns = []string{"id", "username", "password_hash", "role", "created_at"}
+
+func newMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("Could not create a new mock: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestUserDBRepository_SaveUser(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QuerySaveUser)).
+			WithArgs("reader", "hash", common_auth.RoleReader, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+		user, err := repo.SaveUser(context.Background(), &entity.User{Username: "reader", PasswordHash: "hash"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), user.ID)
+		assert.Equal(t, common_auth.RoleReader, user.Role)
+		assert.False(t, user.CreatedAt.IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Unsuccessful: Username taken", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QuerySaveUser)).
+			WithArgs("reader", "hash", common_auth.RoleReader, sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		user, err := repo.SaveUser(context.Background(), &entity.User{Username: "reader", PasswordHash: "hash"})
+
+		assert.Nil(t, user)
+		assert.Equal(t, errors.NewUserAlreadyExists("reader"), err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserDBRepository_GetUserByUsername(t *testing.T) {
+	db, mock := newMock()
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	createdAt := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Test Successful", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetUserByUsername)).WithArgs("reader").
+			WillReturnRows(mock.NewRows(userColumns).AddRow(1, "reader", "hash", "librarian", createdAt))
+
+		user, err := repo.GetUserByUsername(context.Background(), "reader")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.User{ID: 1, Username: "reader", PasswordHash: "hash", Role: common_auth.RoleLibrarian, CreatedAt: createdAt}, user)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Unsuccessful: Not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(QueryGetUserByUsername)).WithArgs("writer").WillReturnRows(mock.NewRows(userColumns))
+
+		user, err := repo.GetUserByUsername(context.Background(), "writer")
+
+		assert.Nil(t, user)
+		assert.Equal(t, errors.NewUsersNotFound(), err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// testRepository runs the checks every implementation of repository.UserRepository should pass
+func testRepository(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+
+	saved, err := repo.SaveUser(ctx, &entity.User{Username: "reader", PasswordHash: "hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), saved.ID)
+	assert.Equal(t, common_auth.RoleReader, saved.Role, "Users should be saved as readers")
+
+	_, err = repo.SaveUser(ctx, &entity.User{Username: "reader", PasswordHash: "another hash"})
+	assert.Equal(t, errors.NewUserAlreadyExists("reader"), err)
+
+	user, err := repo.GetUser(ctx, saved.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, saved.Username, user.Username)
+	assert.Equal(t, saved.PasswordHash, user.PasswordHash)
+	assert.True(t, saved.CreatedAt.Equal(user.CreatedAt))
+
+	user, err = repo.GetUserByUsername(ctx, "reader")
+	assert.NoError(t, err)
+	assert.Equal(t, saved.ID, user.ID)
+
+	_, err = repo.GetUser(ctx, 2)
+	assert.Equal(t, errors.NewUsersNotFound(), err)
+
+	_, err = repo.GetUser(ctx, 0)
+	assert.Equal(t, errors.NewUserInvalidSerial(), err)
+
+	writer, err := repo.SaveUser(ctx, &entity.User{Username: "writer", PasswordHash: "hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, common_auth.RoleReader, writer.Role)
+
+	writer, err = repo.SetUserRole(ctx, writer.ID, common_auth.RoleLibrarian)
+	assert.NoError(t, err)
+	assert.Equal(t, common_auth.RoleLibrarian, writer.Role)
+
+	user, err = repo.GetUserByUsername(ctx, "writer")
+	assert.NoError(t, err)
+	assert.Equal(t, common_auth.RoleLibrarian, user.Role)
+
+	_, err = repo.SetUserRole(ctx, 9, common_auth.RoleAdmin)
+	assert.Equal(t, errors.NewUsersNotFound(), err)
+
+	users, total, err := repo.GetUsers(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "writer", users[0].Username)
+	}
+
+	for _, path := range []string{"/book/", "/book/trash"} {
+		err = repo.RecordDenial(ctx, common_auth.Denial{
+			Actor:      "writer",
+			Role:       common_auth.RoleLibrarian,
+			Method:     "DELETE",
+			Route:      path,
+			Path:       path,
+			Permission: common_auth.PermDeleteAll,
+			Status:     403,
+			Reason:     "Permission delete_all is required",
+			RequestID:  "request",
+			CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		})
+		assert.NoError(t, err)
+	}
+
+	denials, total, err := repo.GetDenials(ctx, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, denials, 2) {
+		assert.Equal(t, "/book/trash", denials[0].Path, "The latest denial should come first")
+		assert.Equal(t, common_auth.RoleLibrarian, denials[0].Role)
+		assert.Equal(t, common_auth.PermDeleteAll, denials[0].Permission)
+		assert.Equal(t, 403, denials[0].Status)
+		assert.Greater(t, denials[0].ID, denials[1].ID)
+	}
+}
+
+func TestUserDBRepository_SQLite(t *testing.T) {
+	db := database.CreateSQLitePool(filepath.Join(t.TempDir(), "users.db"), 1, 1, time.Minute)
+	defer db.Close()
+
+	repo := NewUserRepo(db)
+	testRepository(t, &repo)
+}
+
+func TestUserMemoryRepository(t *testing.T) {
+	testRepository(t, NewUserMemoryRepo())
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxMemoryDenials bounds the denials kept in memory. The oldest ones are dropped first
+const maxMemoryDenials = 10000
+
+// UserMemoryRepository keeps users in memory, along with the books of the memory driver. It reports the same errors
+// as UserDBRepository and is safe for concurrent use
+type UserMemoryRepository struct {
+	mu           sync.RWMutex
+	users        map[uint64]entity.User
+	byUsername   map[string]uint64
+	nextID       uint64
+	denials      []common_auth.Denial // The oldest first
+	nextDenialID uint64
+}
+
+func NewUserMemoryRepo() *UserMemoryRepository {
+	return &UserMemoryRepository{
+		users:        make(map[uint64]entity.User),
+		byUsername:   make(map[string]uint64),
+		nextID:       1,
+		nextDenialID: 1,
+	}
+}
+
+var _ repository.UserRepository = &UserMemoryRepository{}
+
+func (r *UserMemoryRepository) SaveUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, taken := r.byUsername[user.Username]; taken {
+		log.Printf("Username %v is already taken", user.Username)
+		return nil, errors.NewUserAlreadyExists(user.Username)
+	}
+
+	returnUser := *user
+	returnUser.ID = r.nextID
+	returnUser.Role = common_auth.RoleReader
+	returnUser.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	r.users[returnUser.ID] = returnUser
+	r.byUsername[returnUser.Username] = returnUser.ID
+	r.nextID++
+
+	return &returnUser, nil
+}
+
+func (r *UserMemoryRepository) GetUser(ctx context.Context, userID uint64) (*entity.User, error) {
+	if userID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewUserInvalidSerial()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		log.Printf("User %v not found", userID)
+		return nil, errors.NewUsersNotFound()
+	}
+
+	return &user, nil
+}
+
+func (r *UserMemoryRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userID, ok := r.byUsername[username]
+	if !ok {
+		log.Printf("User %v not found", username)
+		return nil, errors.NewUsersNotFound()
+	}
+
+	user := r.users[userID]
+	return &user, nil
+}
+
+func (r *UserMemoryRepository) GetUsers(ctx context.Context, limit int, offset int) ([]entity.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []entity.User{}
+	for id := uint64(1); id < r.nextID && len(users) < limit; id++ {
+		if int(id) <= offset {
+			continue
+		}
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+
+	return users, int64(len(r.users)), nil
+}
+
+func (r *UserMemoryRepository) SetUserRole(ctx context.Context, userID uint64, role common_auth.Role) (*entity.User, error) {
+	if userID < 1 {
+		log.Printf("Serial is less than 1")
+		return nil, errors.NewUserInvalidSerial()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		log.Printf("User %v not found", userID)
+		return nil, errors.NewUsersNotFound()
+	}
+
+	user.Role = role
+	r.users[userID] = user
+
+	return &user, nil
+}
+
+func (r *UserMemoryRepository) RecordDenial(ctx context.Context, denial common_auth.Denial) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	denial.ID = r.nextDenialID
+	r.nextDenialID++
+
+	if len(r.denials) == maxMemoryDenials {
+		r.denials = append(r.denials[:0], r.denials[1:]...)
+	}
+	r.denials = append(r.denials, denial)
+
+	return nil
+}
+
+func (r *UserMemoryRepository) GetDenials(ctx context.Context, limit int, offset int) ([]common_auth.Denial, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	denials := []common_auth.Denial{}
+	for i := len(r.denials) - 1 - offset; i >= 0 && len(denials) < limit; i-- {
+		denials = append(denials, r.denials[i])
+	}
+
+	return denials, int64(len(r.denials)), nil
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"strings"
+	"time"
+)
+
+// User is an account requests can be signed in with. Password is kept as a bcrypt hash only, which is never sent back
+type User struct {
+	ID           uint64           `json:"id"`
+	Username     string           `json:"username"`
+	PasswordHash string           `json:"-"`
+	Role         common_auth.Role `json:"role"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// Registration is what a new user signs up with
+type Registration struct {
+	Username string `json:"username" binding:"required,validUsername"`
+	Password string `json:"password" binding:"required,validPassword"`
+}
+
+// Credentials are what a user signs in with. They are not checked against the rules of Registration, so signing in
+// with a wrong password of any length is told apart from nothing else
+type Credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Refresh asks for new tokens in exchange for a refresh token
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RoleChange gives a user another role
+type RoleChange struct {
+	Role string `json:"role" binding:"required,validRole"`
+}
+
+// NormalizeUsername returns the username as it is stored: trimmed and lower case, so usernames are unique regardless
+// of case
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"github.com/foxfurry/simple-rest/internal/user/http/validators"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func init() {
+	validators.RegisterUserValidators()
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	assert.Equal(t, "reader", NormalizeUsername("  Reader "))
+	assert.Equal(t, "j.doe", NormalizeUsername("J.Doe"))
+}
+
+func TestRegistration_IsValid(t *testing.T) {
+	testCasesValid := []Registration{
+		{Username: "reader", Password: "password"},
+		{Username: " J.Doe_2 ", Password: strings.Repeat("p", 72)},
+	}
+	testCasesInvalid := []Registration{
+		{},
+		{Username: "reader"},
+		{Username: "re", Password: "password"},
+		{Username: "read er", Password: "password"},
+		{Username: "reader", Password: "short"},
+		{Username: "reader", Password: strings.Repeat("p", 73)},
+	}
+
+	for _, tc := range testCasesValid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) == nil, "Registration expected to be valid, but found invalid: %v", tc)
+	}
+	for _, tc := range testCasesInvalid {
+		assert.True(t, binding.Validator.ValidateStruct(tc) != nil, "Registration expected to be invalid, but found valid: %v", tc)
+	}
+}
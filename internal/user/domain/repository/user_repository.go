@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+)
+
+// UserRepository stores user accounts, along with the audit of requests denied to them. Usernames are expected to be
+// normalized by entity.NormalizeUsername
+type UserRepository interface {
+	common_auth.DenialRecorder
+
+	SaveUser(context.Context, *entity.User) (*entity.User, error) // Fails if the username is taken. Users are saved as readers
+	GetUser(context.Context, uint64) (*entity.User, error)
+	GetUserByUsername(context.Context, string) (*entity.User, error)
+	GetUsers(ctx context.Context, limit int, offset int) ([]entity.User, int64, error) // Ordered by id, along with the total count
+	SetUserRole(context.Context, uint64, common_auth.Role) (*entity.User, error)
+	GetDenials(ctx context.Context, limit int, offset int) ([]common_auth.Denial, int64, error) // The latest first, along with the total count
+}
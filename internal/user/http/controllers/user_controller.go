@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_pagination"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_response"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type UserService struct {
+	repo   repository.UserRepository
+	signer *common_auth.Signer
+	cost   int // Of bcrypt hashes
+}
+
+func NewUserService(repo repository.UserRepository, signer *common_auth.Signer) UserService {
+	return UserService{
+		repo:   repo,
+		signer: signer,
+		cost:   bcrypt.DefaultCost,
+	}
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// compareDummy spends about as long as checking a password of an existing user, so a missing user cannot be told
+// apart by the response time
+func (u *UserService) compareDummy(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), u.cost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// bindBody reads the request body into obj and responds with an error if the body is missing, malformed or invalid
+func bindBody(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	if err == io.EOF {
+		errors.HandleUserError(c, errors.NewUserEmptyBody())
+	} else if _, ok := err.(validator.ValidationErrors); ok {
+		errors.HandleUserError(c, errors.NewUserValidatorError(common_translators.Translate(err)))
+	} else {
+		errors.HandleUserError(c, errors.NewUserBadBody(err.Error()))
+	}
+	return false
+}
+
+// paramID parses the path parameter named key and responds with an error if it is not a number
+func paramID(c *gin.Context, key string) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param(key), 10, 64)
+	if err != nil {
+		errors.HandleUserError(c, errors.NewUserInvalidSerial())
+		return 0, false
+	}
+	return id, true
+}
+
+// Register creates a reader with the username and password of the body. The user signs in afterwards. Admins are
+// appointed by the auth.admin configuration key, and give other roles themselves
+func (u *UserService) Register(c *gin.Context) {
+	var registration entity.Registration
+
+	if !bindBody(c, &registration) {
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(registration.Password), u.cost)
+	if err != nil {
+		errors.HandleUserError(c, errors.NewUserUnexpectedError(err.Error()))
+		return
+	}
+
+	user, err := u.repo.SaveUser(c.Request.Context(), &entity.User{
+		Username:     entity.NormalizeUsername(registration.Username),
+		PasswordHash: string(hash),
+	})
+	if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, user, nil)
+}
+
+// Login responds with new tokens of the user whose username and password are in the body
+func (u *UserService) Login(c *gin.Context) {
+	var credentials entity.Credentials
+
+	if !bindBody(c, &credentials) {
+		return
+	}
+
+	user, err := u.repo.GetUserByUsername(c.Request.Context(), entity.NormalizeUsername(credentials.Username))
+	if errors.IsUserNotFound(err) {
+		u.compareDummy(credentials.Password)
+		errors.HandleUserError(c, errors.NewUserInvalidCredentials())
+		return
+	} else if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)) != nil {
+		errors.HandleUserError(c, errors.NewUserInvalidCredentials())
+		return
+	}
+
+	u.respondWithTokens(c, user)
+}
+
+// Refresh responds with new tokens in exchange for a refresh token of an existing user
+func (u *UserService) Refresh(c *gin.Context) {
+	var refresh entity.Refresh
+
+	if !bindBody(c, &refresh) {
+		return
+	}
+
+	tokenUser, err := u.signer.Verify(refresh.RefreshToken, common_auth.KindRefresh)
+	if err != nil {
+		errors.HandleUserError(c, errors.NewUserInvalidToken(err.Error()))
+		return
+	}
+
+	user, err := u.repo.GetUser(c.Request.Context(), tokenUser.ID)
+	if err != nil {
+		if errors.IsUserNotFound(err) {
+			err = errors.NewUserInvalidToken(common_auth.ErrTokenInvalid.Error())
+		}
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	u.respondWithTokens(c, user)
+}
+
+// Me responds with the signed in user
+func (u *UserService) Me(c *gin.Context) {
+	signedIn, _ := common_auth.UserFrom(c.Request.Context())
+
+	user, err := u.repo.GetUser(c.Request.Context(), signedIn.ID)
+	if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, user, nil)
+}
+
+// GetUsers responds with the requested page of users ordered by id
+func (u *UserService) GetUsers(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleUserError(c, errors.NewUserValidatorError(fieldErrors))
+		return
+	}
+
+	users, total, err := u.repo.GetUsers(c.Request.Context(), page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, users, common_pagination.NewPageMeta(c, page, total))
+}
+
+// SetUserRole gives the user the role of the body. Signed in users cannot change their own role. The new role takes
+// effect once the user gets new tokens
+func (u *UserService) SetUserRole(c *gin.Context) {
+	userID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var change entity.RoleChange
+
+	if !bindBody(c, &change) {
+		return
+	}
+
+	if signedIn, _ := common_auth.UserFrom(c.Request.Context()); signedIn.ID == userID {
+		errors.HandleUserError(c, errors.NewUserOwnRole())
+		return
+	}
+
+	user, err := u.repo.SetUserRole(c.Request.Context(), userID, common_auth.Role(change.Role))
+	if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, user, nil)
+}
+
+// GetDenials responds with the requested page of denied requests, the latest first
+func (u *UserService) GetDenials(c *gin.Context) {
+	page, fieldErrors := common_pagination.ParsePage(c)
+	if fieldErrors != nil {
+		errors.HandleUserError(c, errors.NewUserValidatorError(fieldErrors))
+		return
+	}
+
+	denials, total, err := u.repo.GetDenials(c.Request.Context(), page.Limit(), page.Offset())
+	if err != nil {
+		errors.HandleUserError(c, err)
+		return
+	}
+
+	common_response.RespondWithMeta(c, http.StatusOK, denials, common_pagination.NewPageMeta(c, page, total))
+}
+
+// respondWithTokens issues tokens with the role the user has now, so refreshing the tokens picks up a changed role
+func (u *UserService) respondWithTokens(c *gin.Context, user *entity.User) {
+	tokens, err := u.signer.Issue(common_auth.User{ID: user.ID, Username: user.Username, Role: user.Role})
+	if err != nil {
+		errors.HandleUserError(c, errors.NewUserUnexpectedError(err.Error()))
+		return
+	}
+
+	common_response.Respond(c, http.StatusOK, tokens, nil)
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	userdb "github.com/foxfurry/simple-rest/internal/user/db"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"github.com/foxfurry/simple-rest/internal/user/http/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type expectedErrors struct {
+	Msg    string                          `json:"msg,omitempty"`
+	Fields []common_translators.FieldError `json:"fields,omitempty"`
+}
+
+type userResponse struct {
+	Data  *entity.User   `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type usersResponse struct {
+	Data  []entity.User  `json:"data"`
+	Error expectedErrors `json:"error"`
+}
+
+type denialsResponse struct {
+	Data  []common_auth.Denial `json:"data"`
+	Error expectedErrors       `json:"error"`
+}
+
+type tokensResponse struct {
+	Data  *common_auth.Tokens `json:"data"`
+	Error expectedErrors      `json:"error"`
+}
+
+func init() {
+	validators.RegisterUserValidators()
+}
+
+func TestUserService(t *testing.T) {
+	signer := common_auth.NewSigner([]byte("secret"), time.Minute, time.Hour)
+
+	repo := userdb.NewUserMemoryRepo()
+	service := NewUserService(repo, signer)
+	service.cost = bcrypt.MinCost
+
+	router := gin.New()
+	auth := router.Group("/auth", common_auth.NewGuard(signer, repo).Enforce(common_auth.Policy{
+		"POST /auth/register":      common_auth.Public,
+		"POST /auth/login":         common_auth.Public,
+		"POST /auth/refresh":       common_auth.Public,
+		"GET /auth/me":             common_auth.PermSignedIn,
+		"GET /auth/users":          common_auth.PermManageUsers,
+		"PUT /auth/users/:id/role": common_auth.PermManageUsers,
+		"GET /auth/denials":        common_auth.PermManageUsers,
+	}))
+	auth.POST("/register", service.Register)
+	auth.POST("/login", service.Login)
+	auth.POST("/refresh", service.Refresh)
+	auth.GET("/me", service.Me)
+	auth.GET("/users", service.GetUsers)
+	auth.PUT("/users/:id/role", service.SetUserRole)
+	auth.GET("/denials", service.GetDenials)
+
+	request := func(method string, url string, body string, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		if token != "" {
+			req.Header.Set(common_auth.HeaderAuthorization, "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, body interface{}) {
+		if err := json.Unmarshal(w.Body.Bytes(), body); err != nil {
+			t.Fatalf("Unable to unmarshal the body: %v", err)
+		}
+	}
+
+	w := request("POST", "/auth/register", `{"username": " Reader ", "password": "password"}`, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var registered userResponse
+	decode(w, &registered)
+	assert.Equal(t, "reader", registered.Data.Username, "Username should be stored lower case")
+	assert.Equal(t, common_auth.RoleReader, registered.Data.Role, "Registered users should be readers")
+	assert.NotContains(t, w.Body.String(), "password", "Password hash should not be sent back")
+
+	// Admins are appointed by configuration on start, not over HTTP
+	_, err := repo.SetUserRole(context.Background(), registered.Data.ID, common_auth.RoleAdmin)
+	assert.NoError(t, err)
+
+	w = request("POST", "/auth/register", `{"username": "READER", "password": "password"}`, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = request("POST", "/auth/register", `{"username": "re", "password": "short"}`, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var invalid userResponse
+	decode(w, &invalid)
+	assert.Equal(t, []common_translators.FieldError{validators.FieldUsernameInvalid, validators.FieldPasswordInvalid}, invalid.Error.Fields)
+
+	w = request("POST", "/auth/register", `{"username": 1}`, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Malformed body should be rejected")
+
+	for _, body := range []string{`{"username": "reader", "password": "wrong password"}`, `{"username": "writer", "password": "password"}`} {
+		w = request("POST", "/auth/login", body, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		decode(w, &invalid)
+		assert.Equal(t, errors.NewUserInvalidCredentials().Error(), invalid.Error.Msg)
+	}
+
+	w = request("POST", "/auth/login", `{"username": "READER", "password": "password"}`, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var login tokensResponse
+	decode(w, &login)
+	assert.Equal(t, common_auth.TokenType, login.Data.TokenType)
+
+	w = request("GET", "/auth/me", "", login.Data.AccessToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var me userResponse
+	decode(w, &me)
+	assert.Equal(t, registered.Data.ID, me.Data.ID)
+
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/auth/me", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/auth/me", "", login.Data.RefreshToken).Code)
+
+	w = request("POST", "/auth/refresh", `{"refresh_token": "`+login.Data.AccessToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Access token should not refresh")
+
+	w = request("POST", "/auth/refresh", `{"refresh_token": "`+login.Data.RefreshToken+`"}`, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var refreshed tokensResponse
+	decode(w, &refreshed)
+	assert.Equal(t, http.StatusOK, request("GET", "/auth/me", "", refreshed.Data.AccessToken).Code)
+
+	t.Run("Roles", func(t *testing.T) {
+		w := request("POST", "/auth/register", `{"username": "writer", "password": "password"}`, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var writer userResponse
+		decode(w, &writer)
+		assert.Equal(t, common_auth.RoleReader, writer.Data.Role)
+
+		w = request("POST", "/auth/login", `{"username": "writer", "password": "password"}`, "")
+		var writerLogin tokensResponse
+		decode(w, &writerLogin)
+
+		assert.Equal(t, http.StatusForbidden, request("GET", "/auth/users", "", writerLogin.Data.AccessToken).Code)
+
+		w = request("GET", "/auth/users", "", login.Data.AccessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var users usersResponse
+		decode(w, &users)
+		assert.Len(t, users.Data, 2)
+
+		w = request("PUT", "/auth/users/2/role", `{"role": "owner"}`, login.Data.AccessToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		decode(w, &invalid)
+		assert.Equal(t, []common_translators.FieldError{validators.FieldRoleInvalid}, invalid.Error.Fields)
+
+		w = request("PUT", "/auth/users/1/role", `{"role": "reader"}`, login.Data.AccessToken)
+		assert.Equal(t, http.StatusConflict, w.Code, "Admin should not demote themself")
+
+		assert.Equal(t, http.StatusNotFound, request("PUT", "/auth/users/9/role", `{"role": "reader"}`, login.Data.AccessToken).Code)
+
+		w = request("PUT", "/auth/users/2/role", `{"role": "librarian"}`, login.Data.AccessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		decode(w, &writer)
+		assert.Equal(t, common_auth.RoleLibrarian, writer.Data.Role)
+
+		w = request("POST", "/auth/refresh", `{"refresh_token": "`+writerLogin.Data.RefreshToken+`"}`, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		decode(w, &writerLogin)
+		user, err := signer.Verify(writerLogin.Data.AccessToken, common_auth.KindAccess)
+		assert.NoError(t, err)
+		assert.Equal(t, common_auth.RoleLibrarian, user.Role, "Refreshed tokens should carry the new role")
+
+		w = request("GET", "/auth/denials", "", login.Data.AccessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var denials denialsResponse
+		decode(w, &denials)
+		if assert.NotEmpty(t, denials.Data) {
+			latest := denials.Data[0]
+			assert.Equal(t, "writer", latest.Actor)
+			assert.Equal(t, common_auth.RoleReader, latest.Role)
+			assert.Equal(t, "/auth/users", latest.Route)
+			assert.Equal(t, common_auth.PermManageUsers, latest.Permission)
+			assert.Equal(t, http.StatusForbidden, latest.Status)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_errors"
+	validator "github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+type usersNotFound struct {
+	common_errors.CommonError
+}
+
+type userAlreadyExists struct {
+	common_errors.CommonError
+}
+
+type userInvalidCredentials struct {
+	common_errors.CommonError
+}
+
+type userInvalidToken struct {
+	common_errors.CommonError
+}
+
+type userOwnRole struct {
+	common_errors.CommonError
+}
+
+type userCouldNotQuery struct {
+	common_errors.CommonError
+}
+
+type userInvalidSerial struct {
+	common_errors.CommonError
+}
+
+type userUnexpectedError struct {
+	common_errors.CommonError
+}
+
+type userEmptyBody struct {
+	common_errors.CommonError
+}
+
+type userBadBody struct {
+	common_errors.CommonError
+}
+
+type userValidatorError struct {
+	Fields []validator.FieldError `json:"fields"`
+}
+
+func NewUsersNotFound() usersNotFound {
+	return usersNotFound{
+		common_errors.CommonError{Msg: "User(s) not found in db"},
+	}
+}
+
+// IsUserNotFound returns true if err tells that the user does not exist
+func IsUserNotFound(err error) bool {
+	_, ok := err.(usersNotFound)
+	return ok
+}
+
+func NewUserAlreadyExists(username string) userAlreadyExists {
+	return userAlreadyExists{
+		common_errors.CommonError{Msg: fmt.Sprintf("Username %v is already taken", username)},
+	}
+}
+
+// NewUserInvalidCredentials does not tell a wrong password from a missing user, so usernames cannot be guessed by it
+func NewUserInvalidCredentials() userInvalidCredentials {
+	return userInvalidCredentials{
+		common_errors.CommonError{Msg: "Invalid username or password"},
+	}
+}
+
+func NewUserInvalidToken(msg string) userInvalidToken {
+	return userInvalidToken{
+		common_errors.CommonError{Msg: fmt.Sprintf("Refresh %v", msg)},
+	}
+}
+
+// NewUserOwnRole is returned to admins changing their own role, so there is always an admin left to change it back
+func NewUserOwnRole() userOwnRole {
+	return userOwnRole{
+		common_errors.CommonError{Msg: "Users cannot change their own role"},
+	}
+}
+
+func NewUserCouldNotQuery(msg string) userCouldNotQuery {
+	return userCouldNotQuery{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not execute query: %v", msg)},
+	}
+}
+
+func NewUserInvalidSerial() userInvalidSerial {
+	return userInvalidSerial{
+		common_errors.CommonError{Msg: "Invalid serial. Serial must be more than 1"},
+	}
+}
+
+func NewUserUnexpectedError(msg string) userUnexpectedError {
+	return userUnexpectedError{
+		common_errors.CommonError{Msg: fmt.Sprintf("Unexpected error: %v", msg)},
+	}
+}
+
+func NewUserEmptyBody() userEmptyBody {
+	return userEmptyBody{
+		common_errors.CommonError{Msg: "Expected body, found EOF"},
+	}
+}
+
+func NewUserBadBody(msg string) userBadBody {
+	return userBadBody{
+		common_errors.CommonError{Msg: fmt.Sprintf("Could not read the body: %v", msg)},
+	}
+}
+
+func NewUserValidatorError(fields []validator.FieldError) userValidatorError {
+	return userValidatorError{Fields: fields}
+}
+
+func (a userValidatorError) Error() string {
+	var res = ""
+	for _, f := range a.Fields {
+		tmp, err := json.Marshal(f)
+		if err != nil {
+			log.Fatalf("Could not marshal field error: %v", err)
+		}
+		res += fmt.Sprintf("%s", tmp)
+	}
+	return res
+}
+
+func HandleUserError(c *gin.Context, err error) {
+	switch err.(type) {
+	case usersNotFound:
+		common_errors.RespondNotFound(c, err)
+	case userAlreadyExists:
+		common_errors.RespondAlreadyExists(c, err)
+	case userOwnRole:
+		common_errors.RespondConflict(c, err)
+	case userInvalidCredentials, userInvalidToken:
+		common_errors.RespondUnauthorized(c, err)
+	case userValidatorError, userInvalidSerial, userEmptyBody, userBadBody:
+		common_errors.RespondBadRequest(c, err)
+	case userUnexpectedError, userCouldNotQuery:
+		common_errors.RespondInternalError(c, err)
+	default:
+		common_errors.RespondInternalError(c, err)
+	}
+}
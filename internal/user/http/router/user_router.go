@@ -0,0 +1,40 @@
+package router
+
+import (
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/controllers"
+	"github.com/foxfurry/simple-rest/internal/user/http/validators"
+	"github.com/gin-gonic/gin"
+)
+
+// userPolicy leaves signing up and in open to everyone, while only admins manage users and read the denied requests
+var userPolicy = common_auth.Policy{
+	"POST /auth/register":      common_auth.Public,
+	"POST /auth/login":         common_auth.Public,
+	"POST /auth/refresh":       common_auth.Public,
+	"GET /auth/me":             common_auth.PermSignedIn,
+	"GET /auth/users":          common_auth.PermManageUsers,
+	"PUT /auth/users/:id/role": common_auth.PermManageUsers,
+	"GET /auth/denials":        common_auth.PermManageUsers,
+}
+
+func RegisterUserRoutes(router *gin.Engine, repo repository.UserRepository, signer *common_auth.Signer, guard *common_auth.Guard) {
+	userRepo := controllers.NewUserService(repo, signer)
+
+	auth := router.Group("/auth", guard.Enforce(userPolicy))
+	{
+		auth.POST("/register", userRepo.Register)
+		auth.POST("/login", userRepo.Login)
+		auth.POST("/refresh", userRepo.Refresh)
+
+		auth.GET("/me", userRepo.Me)
+
+		auth.GET("/users", userRepo.GetUsers)
+		auth.PUT("/users/:id/role", userRepo.SetUserRole)
+
+		auth.GET("/denials", userRepo.GetDenials)
+	}
+
+	validators.RegisterUserValidators()
+}
@@ -0,0 +1,118 @@
+package validators
+
+import (
+	"fmt"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_translators"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"regexp"
+	"strings"
+)
+
+const (
+	usernameTag   = "validUsername"
+	passwordTag   = "validPassword"
+	roleTag       = "validRole"
+	requiredTag   = "required"
+	emptyFieldMsg = "cannot be empty"
+
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt ignores the bytes past 72
+)
+
+var (
+	invalidUsernameMsg = "should be 3 to 32 letters, digits, dots, dashes or underscores"
+	invalidPasswordMsg = fmt.Sprintf("should be %v to %v bytes long", MinPasswordLength, MaxPasswordLength)
+	invalidRoleMsg     = fmt.Sprintf("should be one of %v", common_auth.Roles)
+
+	FieldUsernameEmpty = common_translators.FieldError{
+		Field: "Username",
+		Msg:   "Username " + emptyFieldMsg,
+	}
+	FieldUsernameInvalid = common_translators.FieldError{
+		Field: "Username",
+		Msg:   "Username " + invalidUsernameMsg,
+	}
+	FieldPasswordEmpty = common_translators.FieldError{
+		Field: "Password",
+		Msg:   "Password " + emptyFieldMsg,
+	}
+	FieldPasswordInvalid = common_translators.FieldError{
+		Field: "Password",
+		Msg:   "Password " + invalidPasswordMsg,
+	}
+	FieldRefreshTokenEmpty = common_translators.FieldError{
+		Field: "RefreshToken",
+		Msg:   "RefreshToken " + emptyFieldMsg,
+	}
+	FieldRoleEmpty = common_translators.FieldError{
+		Field: "Role",
+		Msg:   "Role " + emptyFieldMsg,
+	}
+	FieldRoleInvalid = common_translators.FieldError{
+		Field: "Role",
+		Msg:   "Role " + invalidRoleMsg,
+	}
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{3,32}$`)
+
+// validUsername checks the username as it is stored, trimmed
+var validUsername validator.Func = func(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(strings.TrimSpace(fl.Field().String()))
+}
+
+var validPassword validator.Func = func(fl validator.FieldLevel) bool {
+	length := len(fl.Field().String())
+	return length >= MinPasswordLength && length <= MaxPasswordLength
+}
+
+var validRole validator.Func = func(fl validator.FieldLevel) bool {
+	return common_auth.Role(fl.Field().String()).Valid()
+}
+
+var trslValidUsername validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(usernameTag, "{0} "+invalidUsernameMsg, true)
+}
+
+var trslValidPassword validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(passwordTag, "{0} "+invalidPasswordMsg, true)
+}
+
+var trslValidRole validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(roleTag, "{0} "+invalidRoleMsg, true)
+}
+
+var requiredMessage validator.RegisterTranslationsFunc = func(ut ut.Translator) error {
+	return ut.Add(requiredTag, "{0} "+emptyFieldMsg, true)
+}
+
+// translateTag returns a translation function which renders the message registered for tag
+func translateTag(tag string) validator.TranslationFunc {
+	return func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T(tag, fe.Field())
+		return t
+	}
+}
+
+func RegisterUserValidators() {
+	errTranslator := common_translators.GetTranslator()
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation(usernameTag, validUsername)
+		v.RegisterTranslation(usernameTag, errTranslator, trslValidUsername, translateTag(usernameTag))
+
+		v.RegisterValidation(passwordTag, validPassword)
+		v.RegisterTranslation(passwordTag, errTranslator, trslValidPassword, translateTag(passwordTag))
+
+		v.RegisterValidation(roleTag, validRole)
+		v.RegisterTranslation(roleTag, errTranslator, trslValidRole, translateTag(roleTag))
+
+		v.RegisterTranslation(requiredTag, errTranslator, requiredMessage, translateTag(requiredTag))
+	} else {
+		log.Panicf("Could not register common_translators: %v", ok)
+	}
+}
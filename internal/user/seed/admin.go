@@ -0,0 +1,35 @@
+package seed
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/domain/repository"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Admin appoints the user with username an admin. A user who is not registered yet is registered with password first,
+// unless password is empty. Users registered over HTTP are readers, so this is how the first admin comes to be
+func Admin(ctx context.Context, repo repository.UserRepository, username string, password string) (*entity.User, error) {
+	username = entity.NormalizeUsername(username)
+
+	user, err := repo.GetUserByUsername(ctx, username)
+	if errors.IsUserNotFound(err) && password != "" {
+		hash, hashErr := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if hashErr != nil {
+			return nil, errors.NewUserUnexpectedError(hashErr.Error())
+		}
+
+		user, err = repo.SaveUser(ctx, &entity.User{Username: username, PasswordHash: string(hash)})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == common_auth.RoleAdmin {
+		return user, nil
+	}
+
+	return repo.SetUserRole(ctx, user.ID, common_auth.RoleAdmin)
+}
@@ -0,0 +1,52 @@
+package seed
+
+import (
+	"context"
+	"github.com/foxfurry/simple-rest/internal/common/server/common_auth"
+	userdb "github.com/foxfurry/simple-rest/internal/user/db"
+	"github.com/foxfurry/simple-rest/internal/user/domain/entity"
+	"github.com/foxfurry/simple-rest/internal/user/http/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Test Successful: Registered reader is promoted", func(t *testing.T) {
+		repo := userdb.NewUserMemoryRepo()
+		reader, err := repo.SaveUser(ctx, &entity.User{Username: "root", PasswordHash: "hash"})
+		assert.NoError(t, err)
+
+		admin, err := Admin(ctx, repo, " Root ", "password")
+
+		assert.NoError(t, err)
+		assert.Equal(t, reader.ID, admin.ID)
+		assert.Equal(t, common_auth.RoleAdmin, admin.Role)
+		assert.Equal(t, "hash", admin.PasswordHash, "Password of a registered user should be kept")
+	})
+
+	t.Run("Test Successful: Missing user is registered", func(t *testing.T) {
+		repo := userdb.NewUserMemoryRepo()
+
+		admin, err := Admin(ctx, repo, "root", "password")
+
+		assert.NoError(t, err)
+		assert.Equal(t, common_auth.RoleAdmin, admin.Role)
+
+		stored, err := repo.GetUserByUsername(ctx, "root")
+		assert.NoError(t, err)
+		assert.Equal(t, common_auth.RoleAdmin, stored.Role)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("password")))
+	})
+
+	t.Run("Test Unsuccessful: Missing user without password", func(t *testing.T) {
+		repo := userdb.NewUserMemoryRepo()
+
+		admin, err := Admin(ctx, repo, "root", "")
+
+		assert.Nil(t, admin)
+		assert.Equal(t, errors.NewUsersNotFound(), err)
+	})
+}